@@ -1,8 +1,43 @@
 package socketio
 
+import "context"
+
 // EachFunc typed for each callback function
 type EachFunc func(Conn)
 
+// RoomHandler is called when a room is created or deleted
+type RoomHandler func(room string)
+
+// RoomConnHandler is called when a connection joins or leaves a room
+type RoomConnHandler func(room string, conn Conn)
+
+// AdapterHealth is the state of the connection of a broadcast adapter to the
+// other nodes of the cluster
+type AdapterHealth int
+
+const (
+	// AdapterHealthy means broadcasts and room queries reach the other nodes.
+	AdapterHealthy AdapterHealth = iota + 1
+	// AdapterReconnecting means the adapter lost its subscription and is
+	// reconnecting, remote broadcasts are not received meanwhile.
+	AdapterReconnecting
+	// AdapterClosed means the adapter was closed with the server.
+	AdapterClosed
+)
+
+func (h AdapterHealth) String() string {
+	switch h {
+	case AdapterHealthy:
+		return "healthy"
+	case AdapterReconnecting:
+		return "reconnecting"
+	case AdapterClosed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
 // Broadcaster is the adaptor to handle broadcasts & rooms for socket.io server API
 type Broadcaster interface {
 	Join(room string, connection Conn)            // Join causes the connection to join a room
@@ -17,13 +52,40 @@ type Broadcaster interface {
 	AllRooms() []string                           // Gives list of all the rooms the connection joined
 }
 
+// contextQuerier is implemented by the broadcasters whose room queries are
+// bounded by a context. They return partial results and an error when some
+// nodes did not answer.
+type contextQuerier interface {
+	lenContext(ctx context.Context, room string) (int, error)
+	allRoomsContext(ctx context.Context) ([]string, error)
+}
+
+// roomHooksSetter is implemented by the broadcasters calling hooks on the
+// transitions of the rooms of this node. The hooks are called once per
+// transition, in the order of the transitions, possibly from another goroutine
+// than the one causing them. A room is deleted when its last connection
+// leaves or it is cleared.
+type roomHooksSetter interface {
+	onRoomCreated(f RoomHandler)
+	onRoomDeleted(f RoomHandler)
+	onJoin(f RoomConnHandler)
+	onLeave(f RoomConnHandler)
+}
+
 // broadcast gives Join, Leave & BroadcastTO server API support to socket.io along with room management
 // map of rooms where each room contains a map of connection id to connections in that room
 type broadcast struct {
 	*broadcastLocal
 }
 
-var _ Broadcaster = &broadcast{}
+var (
+	_ Broadcaster     = &broadcast{}
+	_ contextQuerier  = &broadcast{}
+	_ roomHooksSetter = &broadcast{}
+	_ presenceTracker = &broadcast{}
+	_ roomDataStore   = &broadcast{}
+	_ socketQuerier   = &broadcast{}
+)
 
 // newBroadcast creates a new broadcast adapter
 func newBroadcast() *broadcast {
@@ -72,6 +134,11 @@ func (bc *broadcast) Len(room string) int {
 	return bc.lenRoom(room)
 }
 
+// lenContext gives number of connections in the room
+func (bc *broadcast) lenContext(_ context.Context, room string) (int, error) {
+	return bc.lenRoom(room), nil
+}
+
 // Rooms gives the list of all the rooms available for broadcast in case of
 // no connection is given, in case of a connection is given, it gives
 // list of all the rooms the connection is joined to
@@ -83,3 +150,81 @@ func (bc *broadcast) Rooms(conn Conn) []string {
 func (bc *broadcast) AllRooms() []string {
 	return bc.allRooms()
 }
+
+// allRoomsContext gives list of all rooms available for broadcast
+func (bc *broadcast) allRoomsContext(_ context.Context) ([]string, error) {
+	return bc.allRooms(), nil
+}
+
+// onRoomCreated sets the hook called when a room is created
+func (bc *broadcast) onRoomCreated(f RoomHandler) {
+	bc.roomsSync.setHooks(func(hooks *roomHooks) { hooks.onRoomCreated = f })
+}
+
+// onRoomDeleted sets the hook called when a room is deleted
+func (bc *broadcast) onRoomDeleted(f RoomHandler) {
+	bc.roomsSync.setHooks(func(hooks *roomHooks) { hooks.onRoomDeleted = f })
+}
+
+// onJoin sets the hook called when a connection joins a room
+func (bc *broadcast) onJoin(f RoomConnHandler) {
+	bc.roomsSync.setHooks(func(hooks *roomHooks) { hooks.onJoin = f })
+}
+
+// onLeave sets the hook called when a connection leaves a room
+func (bc *broadcast) onLeave(f RoomConnHandler) {
+	bc.roomsSync.setHooks(func(hooks *roomHooks) { hooks.onLeave = f })
+}
+
+// bindUser binds the connection to the user, replacing its previous user
+func (bc *broadcast) bindUser(user string, conn Conn) {
+	prev, online, prevOffline := bc.presence.bind(user, conn)
+	if prevOffline {
+		bc.presence.notify(prev, false)
+	}
+	if online {
+		bc.presence.notify(user, true)
+	}
+}
+
+// unbindUser removes the binding of the connection to its user
+func (bc *broadcast) unbindUser(conn Conn) {
+	if user, offline := bc.presence.unbind(conn); offline {
+		bc.presence.notify(user, false)
+	}
+}
+
+// userSockets gives the ids of the connections bound to the user
+func (bc *broadcast) userSockets(_ context.Context, user string) ([]string, error) {
+	return bc.presence.sockets(user), nil
+}
+
+// onPresence sets the handler called when a user comes online or goes offline
+func (bc *broadcast) onPresence(f PresenceHandler) {
+	bc.presence.setHandler(f)
+}
+
+// setRoomData attaches data to the room
+func (bc *broadcast) setRoomData(_ context.Context, room string, data []byte) (int64, error) {
+	return bc.roomsSync.setData(room, data)
+}
+
+// roomData gives the data attached to the room and their version
+func (bc *broadcast) roomData(_ context.Context, room string) ([]byte, int64, error) {
+	return bc.roomsSync.getData(room)
+}
+
+// updateRoomData attaches data to the room if its data are still at version
+func (bc *broadcast) updateRoomData(_ context.Context, room string, version int64, data []byte) (int64, error) {
+	return bc.roomsSync.updateData(room, version, data)
+}
+
+// fetchSockets describes the connections in the room whose data match filter
+func (bc *broadcast) fetchSockets(_ context.Context, room string, filter DataFilter) ([]SocketInfo, error) {
+	return bc.broadcastLocal.fetchSockets(room, filter), nil
+}
+
+// sendWhere sends given event & args to the connections in the room whose data match filter
+func (bc *broadcast) sendWhere(room string, filter DataFilter, event string, args ...interface{}) {
+	bc.broadcastLocal.sendWhere(room, filter, event, args...)
+}
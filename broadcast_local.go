@@ -6,6 +6,7 @@ func newBroadcastLocal(nsp string) *broadcastLocal {
 		nsp:       nsp,
 		uid:       uid,
 		roomsSync: newRoomMap(),
+		presence:  newLocalPresence(),
 	}
 }
 
@@ -14,6 +15,7 @@ type broadcastLocal struct {
 	uid string
 
 	roomsSync *roomMap
+	presence  *localPresence
 }
 
 func (bc *broadcastLocal) forEach(room string, f EachFunc) {
@@ -62,8 +64,15 @@ func (bc *broadcastLocal) send(room string, event string, args ...interface{}) {
 }
 
 func (bc *broadcastLocal) sendAll(event string, args ...interface{}) {
+	// the connections of several rooms receive the event once
+	sent := make(map[string]struct{})
 	bc.roomsSync.forEach(func(_ string, conn *connMap) bool {
-		conn.forEach(func(_ string, conn Conn) bool {
+		conn.forEach(func(id string, conn Conn) bool {
+			if _, ok := sent[id]; ok {
+				return true
+			}
+			sent[id] = struct{}{}
+
 			// TODO: review this concurrent
 			go conn.Emit(event, args...)
 			return true
@@ -82,14 +91,11 @@ func (bc *broadcastLocal) allRooms() []string {
 }
 
 func (bc *broadcastLocal) lenRoom(roomID string) int {
-	var res int
-	bc.roomsSync.forEach(func(room string, _ *connMap) bool {
-		if room == roomID {
-			res++
-		}
-		return true
-	})
-	return res
+	conns, ok := bc.getOccupants(roomID)
+	if !ok {
+		return 0
+	}
+	return conns.len()
 }
 
 func (bc *broadcastLocal) getRoomsByConn(connection Conn) []string {
@@ -105,3 +111,93 @@ func (bc *broadcastLocal) getRoomsByConn(connection Conn) []string {
 	})
 	return rooms
 }
+
+// match returns the connections which joined any of rooms (every connection
+// when rooms is empty) and none of except
+func (bc *broadcastLocal) match(rooms, except []string) map[string]Conn {
+	conns := make(map[string]Conn)
+	if len(rooms) == 0 {
+		bc.roomsSync.forEach(func(_ string, cm *connMap) bool {
+			cm.forEach(func(connID string, conn Conn) bool {
+				conns[connID] = conn
+				return true
+			})
+			return true
+		})
+	}
+
+	for _, room := range rooms {
+		bc.forEach(room, func(conn Conn) {
+			conns[conn.ID()] = conn
+		})
+	}
+
+	for _, room := range except {
+		bc.forEach(room, func(conn Conn) {
+			delete(conns, conn.ID())
+		})
+	}
+
+	return conns
+}
+
+// broadcast sends given event & args once to each connection matching rooms and except
+func (bc *broadcastLocal) broadcast(rooms, except []string, event string, args ...interface{}) {
+	for _, conn := range bc.match(rooms, except) {
+		// TODO: review this concurrent
+		go conn.Emit(event, args...)
+	}
+}
+
+// matchData returns the connections which joined room (every connection when
+// room is empty) and whose data match filter
+func (bc *broadcastLocal) matchData(room string, filter DataFilter) map[string]Conn {
+	var rooms []string
+	if room != "" {
+		rooms = []string{room}
+	}
+
+	conns := bc.match(rooms, nil)
+	if len(filter) == 0 {
+		return conns
+	}
+
+	for connID, conn := range conns {
+		if !filter.match(connValues(conn)) {
+			delete(conns, connID)
+		}
+	}
+	return conns
+}
+
+// fetchSockets describes the connections matching room and filter
+func (bc *broadcastLocal) fetchSockets(room string, filter DataFilter) []SocketInfo {
+	conns := bc.matchData(room, filter)
+
+	sockets := make([]SocketInfo, 0, len(conns))
+	for _, conn := range conns {
+		sockets = append(sockets, bc.socketInfo(conn))
+	}
+	return sockets
+}
+
+func (bc *broadcastLocal) socketInfo(conn Conn) SocketInfo {
+	rooms := bc.getRoomsByConn(conn)
+	if rooms == nil {
+		rooms = []string{}
+	}
+
+	return SocketInfo{
+		ID:    conn.ID(),
+		Rooms: rooms,
+		Data:  connValues(conn),
+	}
+}
+
+// sendWhere sends given event & args to the connections matching room and filter
+func (bc *broadcastLocal) sendWhere(room string, filter DataFilter, event string, args ...interface{}) {
+	for _, conn := range bc.matchData(room, filter) {
+		// TODO: review this concurrent
+		go conn.Emit(event, args...)
+	}
+}
@@ -1,5 +1,7 @@
 package socketio
 
+import "context"
+
 func newBroadcastRemote(nsp string, opts *RedisAdapterConfig) (*broadcastRemote, error) {
 	rbcLocal := newBroadcastLocal(nsp)
 	rbcRemote, err := newRedisBroadcastRemoteV9(nsp, opts, rbcLocal)
@@ -13,13 +15,57 @@ func newBroadcastRemote(nsp string, opts *RedisAdapterConfig) (*broadcastRemote,
 	}, nil
 }
 
+func newBroadcastBus(nsp string, opts *BusAdapterConfig) *broadcastRemote {
+	rbcLocal := newBroadcastLocal(nsp)
+
+	return &broadcastRemote{
+		remote: newBusBroadcastRemote(nsp, opts, rbcLocal),
+		local:  rbcLocal,
+	}
+}
+
+// remoteAdapter propagates broadcasts and room queries to the other nodes of a cluster
+type remoteAdapter interface {
+	lenRoom(ctx context.Context, room string) (int, error)
+	allRooms(ctx context.Context) ([]string, error)
+	send(room string, event string, args ...interface{})
+	sendAll(event string, args ...interface{})
+	clear(room string)
+	bindUser(user, sid string)
+	unbindUser(user, sid string)
+	userSockets(ctx context.Context, user string) ([]string, error)
+	setRoomData(ctx context.Context, room string, data []byte) (int64, error)
+	roomData(ctx context.Context, room string) ([]byte, int64, error)
+	updateRoomData(ctx context.Context, room string, version int64, data []byte) (int64, error)
+	fetchSockets(ctx context.Context, room string, filter DataFilter) ([]SocketInfo, error)
+	sendWhere(room string, filter DataFilter, event string, args ...interface{})
+	health() AdapterHealth
+	close() error
+}
+
+// clusterAdapter is implemented by the broadcasters connected to the other nodes of a cluster
+type clusterAdapter interface {
+	health() AdapterHealth
+	close() error
+}
+
 // broadcastRemote gives Join, Leave & BroadcastTO server API support to socket.io along with room management
 // map of rooms where each room contains a map of connection id to connections in that room
 type broadcastRemote struct {
-	remote *redisBroadcastRemoteV9
+	remote remoteAdapter
 	local  *broadcastLocal
 }
 
+var (
+	_ Broadcaster     = &broadcastRemote{}
+	_ contextQuerier  = &broadcastRemote{}
+	_ roomHooksSetter = &broadcastRemote{}
+	_ clusterAdapter  = &broadcastRemote{}
+	_ presenceTracker = &broadcastRemote{}
+	_ roomDataStore   = &broadcastRemote{}
+	_ socketQuerier   = &broadcastRemote{}
+)
+
 // Join joins the given connection to the broadcastRemote room.
 func (bc *broadcastRemote) Join(room string, conn Conn) {
 	bc.local.join(room, conn)
@@ -53,7 +99,14 @@ func (bc *broadcastRemote) Rooms(connection Conn) []string {
 
 // AllRooms gives list of all rooms available for broadcastRemote.
 func (bc *broadcastRemote) AllRooms() []string {
-	return bc.remote.allRooms()
+	rooms, _ := bc.allRoomsContext(context.Background())
+	return rooms
+}
+
+// allRoomsContext gives list of all rooms available for broadcastRemote, or
+// the rooms known so far and ErrRequestTimeout if some nodes did not answer in time.
+func (bc *broadcastRemote) allRoomsContext(ctx context.Context) ([]string, error) {
+	return bc.remote.allRooms(ctx)
 }
 
 // Clear clears the room.
@@ -76,5 +129,102 @@ func (bc *broadcastRemote) SendAll(event string, args ...interface{}) {
 
 // Len gives number of connections in the room.
 func (bc *broadcastRemote) Len(room string) int {
-	return bc.remote.lenRoom(room)
+	n, _ := bc.lenContext(context.Background(), room)
+	return n
+}
+
+// lenContext gives number of connections in the room, or the connections
+// counted so far and ErrRequestTimeout if some nodes did not answer in time.
+func (bc *broadcastRemote) lenContext(ctx context.Context, room string) (int, error) {
+	return bc.remote.lenRoom(ctx, room)
+}
+
+// onRoomCreated sets the hook called when a room of this node is created.
+func (bc *broadcastRemote) onRoomCreated(f RoomHandler) {
+	bc.local.roomsSync.setHooks(func(hooks *roomHooks) { hooks.onRoomCreated = f })
+}
+
+// onRoomDeleted sets the hook called when a room of this node is deleted,
+// including when another node cleared it.
+func (bc *broadcastRemote) onRoomDeleted(f RoomHandler) {
+	bc.local.roomsSync.setHooks(func(hooks *roomHooks) { hooks.onRoomDeleted = f })
+}
+
+// onJoin sets the hook called when a connection of this node joins a room.
+func (bc *broadcastRemote) onJoin(f RoomConnHandler) {
+	bc.local.roomsSync.setHooks(func(hooks *roomHooks) { hooks.onJoin = f })
+}
+
+// onLeave sets the hook called when a connection of this node leaves a room.
+func (bc *broadcastRemote) onLeave(f RoomConnHandler) {
+	bc.local.roomsSync.setHooks(func(hooks *roomHooks) { hooks.onLeave = f })
+}
+
+// bindUser binds the connection to the user, replacing its previous user. The
+// remote adapter tells the nodes when the user comes online or goes offline.
+func (bc *broadcastRemote) bindUser(user string, conn Conn) {
+	prev, _, _ := bc.local.presence.bind(user, conn)
+	if prev == user {
+		return
+	}
+	if prev != "" {
+		bc.remote.unbindUser(prev, conn.ID())
+	}
+	bc.remote.bindUser(user, conn.ID())
+}
+
+// unbindUser removes the binding of the connection to its user.
+func (bc *broadcastRemote) unbindUser(conn Conn) {
+	if user, _ := bc.local.presence.unbind(conn); user != "" {
+		bc.remote.unbindUser(user, conn.ID())
+	}
+}
+
+// userSockets gives the ids of the connections bound to the user on every node.
+func (bc *broadcastRemote) userSockets(ctx context.Context, user string) ([]string, error) {
+	return bc.remote.userSockets(ctx, user)
+}
+
+// onPresence sets the handler called when a user comes online or goes offline.
+func (bc *broadcastRemote) onPresence(f PresenceHandler) {
+	bc.local.presence.setHandler(f)
+}
+
+// setRoomData attaches data to the room, shared by all the nodes.
+func (bc *broadcastRemote) setRoomData(ctx context.Context, room string, data []byte) (int64, error) {
+	return bc.remote.setRoomData(ctx, room, data)
+}
+
+// roomData gives the data attached to the room and their version.
+func (bc *broadcastRemote) roomData(ctx context.Context, room string) ([]byte, int64, error) {
+	return bc.remote.roomData(ctx, room)
+}
+
+// updateRoomData attaches data to the room if its data are still at version.
+func (bc *broadcastRemote) updateRoomData(ctx context.Context, room string, version int64, data []byte) (int64, error) {
+	return bc.remote.updateRoomData(ctx, room, version, data)
+}
+
+// fetchSockets describes the connections in the room whose data match filter
+// on every node, or the connections known so far and ErrRequestTimeout if
+// some nodes did not answer in time.
+func (bc *broadcastRemote) fetchSockets(ctx context.Context, room string, filter DataFilter) ([]SocketInfo, error) {
+	return bc.remote.fetchSockets(ctx, room, filter)
+}
+
+// sendWhere sends given event & args to the connections in the room whose
+// data match filter on every node.
+func (bc *broadcastRemote) sendWhere(room string, filter DataFilter, event string, args ...interface{}) {
+	bc.local.sendWhere(room, filter, event, args...)
+	bc.remote.sendWhere(room, filter, event, args...)
+}
+
+// health gives the state of the connection to the other nodes.
+func (bc *broadcastRemote) health() AdapterHealth {
+	return bc.remote.health()
+}
+
+// close stops propagating broadcasts to and from the other nodes.
+func (bc *broadcastRemote) close() error {
+	return bc.remote.close()
 }
@@ -0,0 +1,427 @@
+package socketio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Bus is an in-process message bus. Servers configured with the same bus
+// share broadcasts, room queries and room clearing as if they were the nodes
+// of one cluster, which allows clustered behaviour to be tested without redis.
+type Bus struct {
+	nodes map[string]map[string]*busBroadcastRemote
+	rooms map[string]map[string]*roomMeta
+	users map[string]map[string]int
+	mutex sync.RWMutex
+}
+
+// NewBus creates a new empty bus.
+func NewBus() *Bus {
+	return &Bus{
+		nodes: make(map[string]map[string]*busBroadcastRemote),
+		rooms: make(map[string]map[string]*roomMeta),
+		users: make(map[string]map[string]int),
+	}
+}
+
+func (b *Bus) register(node *busBroadcastRemote) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	nodes, ok := b.nodes[node.local.nsp]
+	if !ok {
+		nodes = make(map[string]*busBroadcastRemote)
+		b.nodes[node.local.nsp] = nodes
+	}
+	nodes[node.local.uid] = node
+}
+
+func (b *Bus) unregister(node *busBroadcastRemote) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	delete(b.nodes[node.local.nsp], node.local.uid)
+}
+
+// bindUser counts a connection bound to user in the namespace, it returns
+// whether the user came online in the cluster
+func (b *Bus) bindUser(nsp, user string) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	users, ok := b.users[nsp]
+	if !ok {
+		users = make(map[string]int)
+		b.users[nsp] = users
+	}
+	users[user]++
+	return users[user] == 1
+}
+
+// unbindUser uncounts a connection bound to user in the namespace, it returns
+// whether the user went offline in the cluster
+func (b *Bus) unbindUser(nsp, user string) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	users := b.users[nsp]
+	if users[user] == 0 {
+		return false
+	}
+	users[user]--
+	if users[user] > 0 {
+		return false
+	}
+	delete(users, user)
+	return true
+}
+
+// peers return all the nodes registered for the namespace, including the caller
+func (b *Bus) peers(nsp string) []*busBroadcastRemote {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	nodes := make([]*busBroadcastRemote, 0, len(b.nodes[nsp]))
+	for _, node := range b.nodes[nsp] {
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+func newBusBroadcastRemote(nsp string, opts *BusAdapterConfig, rbcLocal *broadcastLocal) *busBroadcastRemote {
+	bc := &busBroadcastRemote{
+		bus:      opts.Bus,
+		latency:  opts.Latency,
+		lossRate: opts.LossRate,
+		rand:     rand.New(rand.NewSource(opts.Seed)),
+		local:    rbcLocal,
+	}
+	bc.bus.register(bc)
+
+	rbcLocal.roomsSync.setLifecycleHooks(func(hooks *roomHooks) {
+		hooks.onRoomDeleted = bc.onRoomDeleted
+	})
+
+	return bc
+}
+
+type busBroadcastRemote struct {
+	bus      *Bus
+	latency  time.Duration
+	lossRate float64
+	local    *broadcastLocal
+
+	rand      *rand.Rand
+	randMutex sync.Mutex
+
+	closed atomic.Bool
+}
+
+func (bc *busBroadcastRemote) lenRoom(ctx context.Context, room string) (int, error) {
+	if err := bc.wait(ctx); err != nil {
+		return bc.local.lenRoom(room), err
+	}
+
+	var res int
+	for _, node := range bc.bus.peers(bc.local.nsp) {
+		res += node.local.lenRoom(room)
+	}
+	return res, nil
+}
+
+func (bc *busBroadcastRemote) allRooms(ctx context.Context) ([]string, error) {
+	if err := bc.wait(ctx); err != nil {
+		return bc.local.allRooms(), err
+	}
+
+	set := make(map[string]bool)
+	for _, node := range bc.bus.peers(bc.local.nsp) {
+		for _, room := range node.local.allRooms() {
+			set[room] = true
+		}
+	}
+	return getKeysOfMap(set), nil
+}
+
+func (bc *busBroadcastRemote) send(room string, event string, args ...interface{}) {
+	args, ok := bc.copyArgs(args)
+	if !ok {
+		return
+	}
+
+	bc.publish(func(node *busBroadcastRemote) {
+		node.local.send(room, event, args...)
+	})
+}
+
+func (bc *busBroadcastRemote) sendAll(event string, args ...interface{}) {
+	args, ok := bc.copyArgs(args)
+	if !ok {
+		return
+	}
+
+	bc.publish(func(node *busBroadcastRemote) {
+		node.local.sendAll(event, args...)
+	})
+}
+
+func (bc *busBroadcastRemote) clear(room string) {
+	bc.publish(func(node *busBroadcastRemote) {
+		node.local.clear(room)
+	})
+}
+
+func (bc *busBroadcastRemote) bindUser(user, _ string) {
+	if bc.bus.bindUser(bc.local.nsp, user) {
+		bc.notifyPresence(user, true)
+	}
+}
+
+func (bc *busBroadcastRemote) unbindUser(user, _ string) {
+	if bc.bus.unbindUser(bc.local.nsp, user) {
+		bc.notifyPresence(user, false)
+	}
+}
+
+func (bc *busBroadcastRemote) userSockets(ctx context.Context, user string) ([]string, error) {
+	if err := bc.wait(ctx); err != nil {
+		return bc.local.presence.sockets(user), err
+	}
+
+	var res []string
+	for _, node := range bc.bus.peers(bc.local.nsp) {
+		res = append(res, node.local.presence.sockets(user)...)
+	}
+	return res, nil
+}
+
+// notifyPresence calls the presence handler of every node, presence changes
+// are neither delayed nor lost
+func (bc *busBroadcastRemote) notifyPresence(user string, online bool) {
+	for _, node := range bc.bus.peers(bc.local.nsp) {
+		node.local.presence.notify(user, online)
+	}
+}
+
+func (bc *busBroadcastRemote) setRoomData(ctx context.Context, room string, data []byte) (int64, error) {
+	return bc.updateRoomMeta(ctx, room, func(meta *roomMeta) error {
+		return nil
+	}, data)
+}
+
+func (bc *busBroadcastRemote) roomData(ctx context.Context, room string) ([]byte, int64, error) {
+	if err := bc.wait(ctx); err != nil {
+		return nil, 0, err
+	}
+	if !bc.roomExists(room) {
+		return nil, 0, ErrRoomNotFound
+	}
+
+	bc.bus.mutex.RLock()
+	defer bc.bus.mutex.RUnlock()
+
+	meta, ok := bc.bus.rooms[bc.local.nsp][room]
+	if !ok {
+		return nil, 0, nil
+	}
+	return meta.data, meta.version, nil
+}
+
+func (bc *busBroadcastRemote) updateRoomData(ctx context.Context, room string, version int64, data []byte) (int64, error) {
+	return bc.updateRoomMeta(ctx, room, func(meta *roomMeta) error {
+		if meta.version != version {
+			return ErrRoomDataConflict
+		}
+		return nil
+	}, data)
+}
+
+// updateRoomMeta sets the data of the room unless check fails
+func (bc *busBroadcastRemote) updateRoomMeta(ctx context.Context, room string, check func(meta *roomMeta) error, data []byte) (int64, error) {
+	if err := bc.wait(ctx); err != nil {
+		return 0, err
+	}
+	if !bc.roomExists(room) {
+		return 0, ErrRoomNotFound
+	}
+
+	bc.bus.mutex.Lock()
+	defer bc.bus.mutex.Unlock()
+
+	rooms, ok := bc.bus.rooms[bc.local.nsp]
+	if !ok {
+		rooms = make(map[string]*roomMeta)
+		bc.bus.rooms[bc.local.nsp] = rooms
+	}
+
+	meta, ok := rooms[room]
+	if !ok {
+		meta = &roomMeta{}
+	}
+	if err := check(meta); err != nil {
+		return meta.version, err
+	}
+
+	rooms[room] = meta
+	meta.version++
+	meta.data = data
+
+	return meta.version, nil
+}
+
+// roomExists tells whether a node has connections in the room
+func (bc *busBroadcastRemote) roomExists(room string) bool {
+	for _, node := range bc.bus.peers(bc.local.nsp) {
+		if _, ok := node.local.getOccupants(room); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// onRoomDeleted deletes the data of the room once no node has connections in it
+func (bc *busBroadcastRemote) onRoomDeleted(room string) {
+	if bc.roomExists(room) {
+		return
+	}
+
+	bc.bus.mutex.Lock()
+	defer bc.bus.mutex.Unlock()
+
+	delete(bc.bus.rooms[bc.local.nsp], room)
+}
+
+func (bc *busBroadcastRemote) fetchSockets(ctx context.Context, room string, filter DataFilter) ([]SocketInfo, error) {
+	if err := bc.wait(ctx); err != nil {
+		return bc.local.fetchSockets(room, filter), err
+	}
+
+	var res []SocketInfo
+	for _, node := range bc.bus.peers(bc.local.nsp) {
+		sockets := node.local.fetchSockets(room, filter)
+		if node != bc {
+			sockets = bc.copySockets(sockets)
+		}
+		res = append(res, sockets...)
+	}
+	return res, nil
+}
+
+func (bc *busBroadcastRemote) sendWhere(room string, filter DataFilter, event string, args ...interface{}) {
+	args, ok := bc.copyArgs(args)
+	if !ok {
+		return
+	}
+
+	bc.publish(func(node *busBroadcastRemote) {
+		node.local.sendWhere(room, filter, event, args...)
+	})
+}
+
+func (bc *busBroadcastRemote) health() AdapterHealth {
+	if bc.closed.Load() {
+		return AdapterClosed
+	}
+	return AdapterHealthy
+}
+
+// close unregisters the node, then releases its presence and rooms like the
+// redis adapter does, the other nodes seeing its users go offline
+func (bc *busBroadcastRemote) close() error {
+	if !bc.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+	bc.bus.unregister(bc)
+
+	for user, sids := range bc.local.presence.bindings() {
+		for range sids {
+			bc.unbindUser(user, "")
+		}
+	}
+	for _, room := range bc.local.allRooms() {
+		bc.onRoomDeleted(room)
+	}
+	return nil
+}
+
+// publish delivers the message to every other node, honoring latency and loss
+func (bc *busBroadcastRemote) publish(deliver func(node *busBroadcastRemote)) {
+	for _, node := range bc.bus.peers(bc.local.nsp) {
+		if node == bc || bc.lost() {
+			continue
+		}
+
+		node := node
+		if bc.latency > 0 {
+			time.AfterFunc(bc.latency, func() {
+				deliver(node)
+			})
+			continue
+		}
+		deliver(node)
+	}
+}
+
+// copyArgs encodes args the same way the redis adapter does, so that remote
+// handlers observe the same values whatever adapter is used
+func (bc *busBroadcastRemote) copyArgs(args []interface{}) ([]interface{}, bool) {
+	argsJSON, err := json.Marshal(args)
+	if err != nil {
+		return nil, false
+	}
+
+	var res []interface{}
+	if err := json.Unmarshal(argsJSON, &res); err != nil {
+		return nil, false
+	}
+	return res, true
+}
+
+// copySockets encodes the sockets of another node like the redis adapter
+// does, so that their data hold the same values whatever adapter is used
+func (bc *busBroadcastRemote) copySockets(sockets []SocketInfo) []SocketInfo {
+	socketsJSON, err := json.Marshal(sockets)
+	if err != nil {
+		return nil
+	}
+
+	var res []SocketInfo
+	if err := json.Unmarshal(socketsJSON, &res); err != nil {
+		return nil
+	}
+	return res
+}
+
+func (bc *busBroadcastRemote) lost() bool {
+	if bc.lossRate <= 0 {
+		return false
+	}
+
+	bc.randMutex.Lock()
+	defer bc.randMutex.Unlock()
+
+	return bc.rand.Float64() < bc.lossRate
+}
+
+// wait simulates the latency of a query, the local node answers when ctx is done first
+func (bc *busBroadcastRemote) wait(ctx context.Context) error {
+	if bc.latency <= 0 {
+		return nil
+	}
+
+	select {
+	case <-time.After(bc.latency):
+		return nil
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("%w: only the local node answered", ErrRequestTimeout)
+		}
+		return ctx.Err()
+	}
+}
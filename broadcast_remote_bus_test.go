@@ -0,0 +1,267 @@
+package socketio
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vchitai/go-socket.io/v4/engineio"
+	"github.com/vchitai/go-socket.io/v4/engineio/session"
+)
+
+type busTestConn struct {
+	Conn
+
+	id     string
+	data   *SocketData
+	events chan []interface{}
+}
+
+func newBusTestConn(id string) *busTestConn {
+	return &busTestConn{
+		id:     id,
+		data:   newSocketData(),
+		events: make(chan []interface{}, 10),
+	}
+}
+
+func (c *busTestConn) ID() string {
+	return c.id
+}
+
+func (c *busTestConn) Data() *SocketData {
+	return c.data
+}
+
+func (c *busTestConn) URL() url.URL {
+	return url.URL{Path: "/socket.io/"}
+}
+
+func (c *busTestConn) RemoteAddr() net.Addr {
+	return nil
+}
+
+func (c *busTestConn) RemoteHeader() http.Header {
+	return http.Header{}
+}
+
+func (c *busTestConn) Emit(eventName string, v ...interface{}) {
+	c.events <- append([]interface{}{eventName}, v...)
+}
+
+func newBusTestServers(t *testing.T, n int, opts BusAdapterConfig) []*Server {
+	must := require.New(t)
+
+	servers := make([]*Server, n)
+	for i := range servers {
+		// the session ids of the nodes of a cluster are distinct
+		servers[i] = NewServer(&engineio.Options{
+			SessionIDGenerator: &session.NodeIDGenerator{NodeID: strconv.Itoa(i)},
+		})
+
+		ok, err := servers[i].BusAdapter(&opts)
+		must.NoError(err)
+		must.True(ok)
+
+		servers[i].OnConnect("/", func(Conn, map[string]interface{}) error {
+			return nil
+		})
+	}
+	return servers
+}
+
+func TestBusAdapter(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	servers := newBusTestServers(t, 2, BusAdapterConfig{Bus: NewBus()})
+
+	c1 := newBusTestConn("c1")
+	c2 := newBusTestConn("c2")
+	must.True(servers[0].JoinRoom("/", "room", c1))
+	must.True(servers[1].JoinRoom("/", "room", c2))
+	must.True(servers[1].JoinRoom("/", "other", c2))
+
+	should.Equal(2, servers[0].RoomLen("/", "room"))
+	should.Equal(1, servers[0].RoomLen("/", "other"))
+
+	rooms := servers[0].Rooms("/")
+	sort.Strings(rooms)
+	should.Equal([]string{"other", "room"}, rooms)
+
+	must.True(servers[0].BroadcastToRoom("/", "room", "event", "payload"))
+	for _, c := range []*busTestConn{c1, c2} {
+		select {
+		case got := <-c.events:
+			should.Equal("event", got[0])
+			should.Equal("payload", got[1])
+		case <-time.After(time.Second):
+			t.Fatalf("%s did not receive the broadcast", c.id)
+		}
+	}
+
+	must.True(servers[0].ClearRoom("/", "other"))
+	should.Equal(0, servers[1].RoomLen("/", "other"))
+}
+
+func TestBusAdapterHTTP(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	servers := newBusTestServers(t, 3, BusAdapterConfig{Bus: NewBus()})
+	clients := make([]*testClient, len(servers))
+	joined := make(chan struct{}, len(servers))
+	for i, srv := range servers {
+		srv := srv
+		srv.OnEvent("/", "join", func(c Conn, room string) {
+			c.Join(room)
+			joined <- struct{}{}
+		})
+		// the broadcasts of any node reach the clients of the others
+		srv.OnEvent("/", "broadcast", func(_ Conn, room, msg string) {
+			srv.BroadcastToRoom("/", room, "message", msg)
+		})
+		clients[i] = newTestClient(t, newTestHTTPServer(t, srv))
+	}
+
+	for _, c := range clients[1:] {
+		c.emit(t, "join", "room")
+		<-joined
+	}
+	for _, srv := range servers {
+		should.Equal(2, srv.RoomLen("/", "room"))
+		should.Contains(srv.Rooms("/"), "room")
+	}
+
+	clients[0].emit(t, "broadcast", "room", "hello")
+	for _, c := range clients[1:] {
+		c.expect(t, "message", "hello")
+	}
+
+	must.True(servers[2].BroadcastToNamespace("/", "all"))
+	for _, c := range clients {
+		c.expect(t, "all")
+	}
+
+	// a closed client leaves the rooms of its node
+	must.NoError(clients[1].ws.Close())
+	must.Eventually(func() bool {
+		return servers[0].RoomLen("/", "room") == 1
+	}, time.Second, 10*time.Millisecond)
+
+	must.True(servers[0].ClearRoom("/", "room"))
+	must.Eventually(func() bool {
+		return servers[2].RoomLen("/", "room") == 0
+	}, time.Second, 10*time.Millisecond)
+
+	clients[0].emit(t, "broadcast", "room", "nobody")
+	select {
+	case got := <-clients[2].events:
+		t.Fatalf("unexpected event %v", got)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestBusAdapterLatencyAndLoss(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	bus := NewBus()
+	slow := newBusTestServers(t, 2, BusAdapterConfig{Bus: bus, Latency: 50 * time.Millisecond})
+	lossy := newBusTestServers(t, 1, BusAdapterConfig{Bus: bus, LossRate: 1})
+
+	c := newBusTestConn("c")
+	must.True(slow[1].JoinRoom("/", "room", c))
+
+	must.True(lossy[0].BroadcastToNamespace("/", "lost"))
+	start := time.Now()
+	must.True(slow[0].BroadcastToNamespace("/", "delayed"))
+
+	select {
+	case got := <-c.events:
+		should.Equal("delayed", got[0])
+		should.GreaterOrEqual(time.Since(start), 50*time.Millisecond)
+	case <-time.After(time.Second):
+		t.Fatal("broadcast was not delivered")
+	}
+
+	select {
+	case got := <-c.events:
+		t.Fatalf("unexpected event %v", got)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestBusAdapterRequiresBus(t *testing.T) {
+	ok, err := NewServer(nil).BusAdapter(&BusAdapterConfig{})
+	assert.False(t, ok)
+	assert.Error(t, err)
+}
+
+func TestBusAdapterQueryTimeout(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	servers := newBusTestServers(t, 2, BusAdapterConfig{Bus: NewBus(), Latency: time.Second})
+	must.True(servers[0].JoinRoom("/", "room", newBusTestConn("c1")))
+	must.True(servers[1].JoinRoom("/", "room", newBusTestConn("c2")))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	n, err := servers[0].RoomLenContext(ctx, "/", "room")
+	should.ErrorIs(err, ErrRequestTimeout)
+	should.Equal(1, n)
+
+	_, err = servers[0].RoomsContext(context.Background(), "/unknown")
+	should.Error(err)
+}
+
+func TestAdapterHealth(t *testing.T) {
+	should := assert.New(t)
+
+	local := NewServer(nil)
+	local.OnConnect("/", func(Conn, map[string]interface{}) error {
+		return nil
+	})
+	should.Equal(AdapterHealthy, local.AdapterHealth("/"))
+	should.Equal("unknown", local.AdapterHealth("/unknown").String())
+
+	servers := newBusTestServers(t, 2, BusAdapterConfig{Bus: NewBus()})
+	should.Equal(AdapterHealthy, servers[0].AdapterHealth("/"))
+
+	c := newBusTestConn("c")
+	should.True(servers[1].JoinRoom("/", "room", c))
+	should.NoError(servers[1].Close())
+	should.Equal(AdapterClosed, servers[1].AdapterHealth("/"))
+
+	// a closed node does not take part in the cluster anymore
+	should.Equal(0, servers[0].RoomLen("/", "room"))
+}
+
+func TestBusAdapterCloseRooms(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	servers := newBusTestServers(t, 2, BusAdapterConfig{Bus: NewBus()})
+
+	must.True(servers[1].JoinRoom("/", "room", newBusTestConn("c1")))
+	must.NoError(servers[1].SetRoomData("/", "room", "data"))
+	must.NoError(servers[1].Close())
+
+	// the data of the rooms of a closed node are deleted with them
+	must.True(servers[0].JoinRoom("/", "room", newBusTestConn("c2")))
+	var data string
+	version, err := servers[0].RoomData("/", "room", &data)
+	must.NoError(err)
+	should.Zero(version)
+	should.Empty(data)
+}
@@ -5,134 +5,270 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	redis "github.com/redis/go-redis/v9"
+
+	"github.com/vchitai/go-socket.io/v4/logger"
 )
 
+// redisPingInterval is the time without message after which the subscription
+// is checked with a ping. It is lost if the pong does not arrive in as much time.
+const redisPingInterval = 5 * time.Second
+
 func newRedisBroadcastRemoteV9(
 	nsp string, opts *RedisAdapterConfig,
 	rbcLocal *broadcastLocal,
 ) (*redisBroadcastRemoteV9, error) {
-	addr := opts.getAddr()
-	redisOpts := &redis.Options{
-		Addr:     addr,
-		Network:  opts.Network,
-		Password: opts.Password,
-		DB:       opts.DB,
+	redisCli, ownClient := opts.Client, false
+	if redisCli == nil {
+		redisCli, ownClient = redis.NewUniversalClient(opts.universalOptions()), true
 	}
 
-	redisCli := redis.NewClient(redisOpts)
 	ctx := context.TODO()
 	if err := redisCli.Ping(ctx).Err(); err != nil {
+		if ownClient {
+			_ = redisCli.Close()
+		}
 		return nil, err
 	}
 
-	subConn := redisCli.PSubscribe(ctx, fmt.Sprintf("%s#%s#*", opts.Prefix, nsp))
-
 	rbc := &redisBroadcastRemoteV9{
-		pub:        redisCli,
-		sub:        subConn,
-		reqChannel: fmt.Sprintf("%s-request#%s", opts.Prefix, nsp),
-		resChannel: fmt.Sprintf("%s-response#%s", opts.Prefix, nsp),
-		key:        fmt.Sprintf("%s#%s#%s", opts.Prefix, nsp, rbcLocal.uid),
-		local:      rbcLocal,
-		requests:   make(map[string]interface{}),
+		pub:             redisCli,
+		ownClient:       ownClient,
+		local:           rbcLocal,
+		requests:        make(map[string]*clusterRequest),
+		requestsTimeout: opts.RequestsTimeout,
+		nodeCompatible:  opts.NodeCompatible,
+
+		reconnectDelay:    opts.ReconnectDelay,
+		reconnectDelayMax: opts.ReconnectDelayMax,
+		quit:              make(chan struct{}),
+		presenceTTL:       opts.PresenceTTL,
+		roomsRegistered:   make(map[string]bool),
+	}
+	if rbc.reconnectDelay <= 0 || rbc.reconnectDelayMax <= 0 {
+		rbc.reconnectDelay = defaultConfig().ReconnectDelay
+		rbc.reconnectDelayMax = defaultConfig().ReconnectDelayMax
+	}
+	if rbc.presenceTTL <= 0 {
+		rbc.presenceTTL = defaultConfig().PresenceTTL
 	}
 
-	if err := subConn.Subscribe(ctx, rbc.reqChannel, rbc.resChannel); err != nil {
+	if opts.NodeCompatible {
+		rbc.setNodeChannels(opts.Prefix)
+		rbc.setPresenceChannels(opts.Prefix, rbc.nodeNsp)
+		rbc.setRoomKeys(opts.Prefix, rbc.nodeNsp)
+	} else {
+		rbc.pattern = fmt.Sprintf("%s#%s#*", opts.Prefix, nsp)
+		rbc.reqChannel = fmt.Sprintf("%s-request#%s", opts.Prefix, nsp)
+		rbc.resChannel = fmt.Sprintf("%s-response#%s", opts.Prefix, nsp)
+		rbc.key = fmt.Sprintf("%s#%s#%s", opts.Prefix, nsp, rbcLocal.uid)
+		rbc.setPresenceChannels(opts.Prefix, nsp)
+		rbc.setRoomKeys(opts.Prefix, nsp)
+	}
+
+	sub, err := rbc.subscribe(ctx)
+	if err != nil {
+		if ownClient {
+			_ = redisCli.Close()
+		}
 		return nil, err
 	}
+	rbc.sub = sub
+	rbc.state.Store(int32(AdapterHealthy))
 
-	// FIXME: review this concurrent
-	go rbc.dispatch()
+	rbcLocal.roomsSync.setLifecycleHooks(func(hooks *roomHooks) {
+		hooks.onRoomDeleted = rbc.onRoomDeleted
+	})
+
+	go rbc.supervise(sub)
+	go rbc.keepAlive()
 
 	return rbc, nil
 }
 
 type redisBroadcastRemoteV9 struct {
-	pub        *redis.Client
-	sub        *redis.PubSub
+	pub        redis.UniversalClient
+	ownClient  bool
 	key        string
+	pattern    string
 	reqChannel string
 	resChannel string
-	requests   map[string]interface{}
 	local      *broadcastLocal
+
+	// sub is replaced when the subscription is lost, see supervise
+	sub               *redis.PubSub
+	subMutex          sync.Mutex
+	state             atomic.Int32
+	quit              chan struct{}
+	reconnectDelay    time.Duration
+	reconnectDelayMax time.Duration
+
+	requests        map[string]*clusterRequest
+	requestsMutex   sync.RWMutex
+	requestsTimeout time.Duration
+
+	// user presence, see broadcast_remote_redis_presence.go
+	presenceKey     string
+	presenceChannel string
+	presenceTTL     time.Duration
+
+	// room data, see broadcast_remote_redis_rooms.go
+	roomKeyPrefix   string
+	roomsRegistered map[string]bool
+	roomsMutex      sync.Mutex
+
+	// node compatible mode, see RedisAdapterConfig.NodeCompatible
+	nodeCompatible bool
+	nodeNsp        string
+	channel        string
+	uidResChannel  string
 }
 
-func (bc *redisBroadcastRemoteV9) lenRoom(room string) int {
+func (bc *redisBroadcastRemoteV9) lenRoom(ctx context.Context, room string) (int, error) {
+	if bc.nodeCompatible {
+		return bc.nodeLenRoom(ctx, room)
+	}
+
 	req := roomLenRequest{
 		RequestType: roomLenReqType,
 		RequestID:   newV4UUID(),
 		Room:        room,
 	}
 
-	reqJSON, err := json.Marshal(&req)
-	if err != nil {
-		return -1
-	}
-
-	numSub, err := bc.getNumSub(bc.reqChannel)
+	numSub, err := bc.getNumSub(ctx, bc.reqChannel)
 	if err != nil {
-		return -1
+		return -1, err
 	}
 
-	req.numSub = numSub
-
-	req.done = make(chan bool, 1)
-
-	bc.requests[req.RequestID] = &req
-	_, err = bc.pub.Publish(context.TODO(), bc.reqChannel, reqJSON).Result()
-	if err != nil {
-		return -1
+	state := newClusterRequest(numSub, 0)
+	err = bc.request(ctx, req.RequestID, state, &req)
+	if err != nil && !errors.Is(err, ErrRequestTimeout) {
+		return -1, err
 	}
 
-	<-req.done
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
 
-	delete(bc.requests, req.RequestID)
-	return req.connections
+	return state.connections, err
 }
 
 func (bc *redisBroadcastRemoteV9) send(room string, event string, args ...interface{}) {
+	if bc.nodeCompatible {
+		// FIXME: review this concurrent
+		go bc.publishNodeMessage(room, event, args...)
+		return
+	}
+
 	// FIXME: review this concurrent
-	go bc.publishMessage(room, event, args...)
+	go bc.publishMessage(room, nil, event, args...)
 }
 func (bc *redisBroadcastRemoteV9) sendAll(event string, args ...interface{}) {
+	if bc.nodeCompatible {
+		// FIXME: review this concurrent
+		go bc.publishNodeMessage("", event, args...)
+		return
+	}
+
 	// FIXME: review this concurrent
-	go bc.publishMessage("", event, args...)
+	go bc.publishMessage("", nil, event, args...)
 }
 func (bc *redisBroadcastRemoteV9) clear(room string) {
+	// the data are deleted before the other nodes clear the room, whether
+	// they registered in it or not
+	if bc.nodeCompatible {
+		// FIXME: review this concurrent
+		go func() {
+			bc.deleteRoomData(room)
+			bc.publishNodeClear(room)
+		}()
+		return
+	}
+
 	// FIXME: review this concurrent
-	go bc.publishClear(room)
+	go func() {
+		bc.deleteRoomData(room)
+		bc.publishClear(room)
+	}()
 }
-func (bc *redisBroadcastRemoteV9) allRooms() []string {
+
+func (bc *redisBroadcastRemoteV9) allRooms(ctx context.Context) ([]string, error) {
+	if bc.nodeCompatible {
+		return bc.nodeAllRooms(ctx)
+	}
+
 	req := allRoomRequest{
 		RequestType: allRoomReqType,
 		RequestID:   newV4UUID(),
 	}
-	reqJSON, _ := json.Marshal(&req)
 
-	req.rooms = make(map[string]bool)
-	numSub, _ := bc.getNumSub(bc.reqChannel)
-	req.numSub = numSub
-	req.done = make(chan bool, 1)
+	numSub, err := bc.getNumSub(ctx, bc.reqChannel)
+	if err != nil {
+		return []string{}, err // if error occurred,return empty
+	}
 
-	bc.requests[req.RequestID] = &req
-	_, err := bc.pub.Publish(context.TODO(), bc.reqChannel, reqJSON).Result()
+	state := newClusterRequest(numSub, 0)
+	err = bc.request(ctx, req.RequestID, state, &req)
+	if err != nil && !errors.Is(err, ErrRequestTimeout) {
+		return []string{}, err
+	}
+
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+
+	return getKeysOfMap(state.rooms), err
+}
+
+// request publishes a cluster request and waits until every subscribed node
+// answered it, ctx is done or the requests timeout elapsed. On timeout, the
+// answers received so far are kept in req and ErrRequestTimeout is returned.
+func (bc *redisBroadcastRemoteV9) request(ctx context.Context, requestID string, req *clusterRequest, msg interface{}) error {
+	if bc.requestsTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, bc.requestsTimeout)
+		defer cancel()
+	}
+
+	reqJSON, err := json.Marshal(msg)
 	if err != nil {
-		return []string{} // if error occurred,return empty
+		return err
 	}
 
-	<-req.done
+	bc.addRequest(requestID, req)
+	defer bc.removeRequest(requestID)
 
-	rooms := make([]string, 0, len(req.rooms))
-	for room := range req.rooms {
-		rooms = append(rooms, room)
+	if err := bc.pub.Publish(ctx, bc.reqChannel, reqJSON).Err(); err != nil {
+		return err
 	}
 
-	delete(bc.requests, req.RequestID)
-	return rooms
+	return req.wait(ctx)
+}
+
+func (bc *redisBroadcastRemoteV9) addRequest(requestID string, req *clusterRequest) {
+	bc.requestsMutex.Lock()
+	defer bc.requestsMutex.Unlock()
+
+	bc.requests[requestID] = req
+}
+
+func (bc *redisBroadcastRemoteV9) getRequest(requestID string) (*clusterRequest, bool) {
+	bc.requestsMutex.RLock()
+	defer bc.requestsMutex.RUnlock()
+
+	req, ok := bc.requests[requestID]
+	return req, ok
+}
+
+func (bc *redisBroadcastRemoteV9) removeRequest(requestID string) {
+	bc.requestsMutex.Lock()
+	defer bc.requestsMutex.Unlock()
+
+	delete(bc.requests, requestID)
 }
 
 func (bc *redisBroadcastRemoteV9) onMessage(channel string, msg []byte) error {
@@ -155,6 +291,14 @@ func (bc *redisBroadcastRemoteV9) onMessage(channel string, msg []byte) error {
 
 	args := bcMessage["args"]
 	opts := bcMessage["opts"]
+	if len(opts) > 0 {
+		if _, ok := opts[0].(float64); ok {
+			return bc.onFilteredMessage(opts, args)
+		}
+	}
+	if len(opts) < 2 {
+		return errors.New("invalid broadcast options")
+	}
 
 	room, ok := opts[0].(string)
 	if !ok {
@@ -175,9 +319,52 @@ func (bc *redisBroadcastRemoteV9) onMessage(channel string, msg []byte) error {
 	return nil
 }
 
-// Get the number of subscribers of a channel.
-func (bc *redisBroadcastRemoteV9) getNumSub(channel string) (int, error) {
-	rs, err := bc.pub.PubSubNumSub(context.TODO(), channel).Result()
+// onFilteredMessage handles the broadcasts to the connections whose data match
+// a filter, whose options are [version, room, event, filter]. The version
+// comes first so that the nodes not knowing the filters reject them rather
+// than broadcasting to every connection.
+func (bc *redisBroadcastRemoteV9) onFilteredMessage(opts []interface{}, args []interface{}) error {
+	if version, _ := opts[0].(float64); version != filteredMessageVersion || len(opts) != 4 {
+		return errors.New("unsupported broadcast version")
+	}
+
+	room, ok := opts[1].(string)
+	if !ok {
+		return errors.New("invalid room")
+	}
+
+	event, ok := opts[2].(string)
+	if !ok {
+		return errors.New("invalid event")
+	}
+
+	filter, ok := opts[3].(map[string]interface{})
+	if !ok {
+		return errors.New("invalid filter")
+	}
+
+	bc.local.sendWhere(room, filter, event, args...)
+	return nil
+}
+
+// Get the number of subscribers of a channel. With Redis Cluster, the
+// subscribers are connected to different shards and counted on each of them.
+func (bc *redisBroadcastRemoteV9) getNumSub(ctx context.Context, channel string) (int, error) {
+	if cluster, ok := bc.pub.(*redis.ClusterClient); ok {
+		var numSub int64
+		err := cluster.ForEachShard(ctx, func(ctx context.Context, shard *redis.Client) error {
+			n, err := numSubOf(ctx, shard, channel)
+			atomic.AddInt64(&numSub, int64(n))
+			return err
+		})
+		return int(numSub), err
+	}
+
+	return numSubOf(ctx, bc.pub, channel)
+}
+
+func numSubOf(ctx context.Context, cli redis.UniversalClient, channel string) (int, error) {
+	rs, err := cli.PubSubNumSub(ctx, channel).Result()
 	if err != nil {
 		return 0, err
 	}
@@ -190,35 +377,43 @@ func (bc *redisBroadcastRemoteV9) getNumSub(channel string) (int, error) {
 
 // Handle request from redis channel.
 func (bc *redisBroadcastRemoteV9) onRequest(msg []byte) {
-	var req map[string]string
+	var req requestMessage
 
 	if err := json.Unmarshal(msg, &req); err != nil {
 		return
 	}
 
 	var res interface{}
-	switch req["RequestType"] {
+	switch req.RequestType {
 	case roomLenReqType:
 		res = roomLenResponse{
-			RequestType: req["RequestType"],
-			RequestID:   req["RequestID"],
-			Connections: bc.local.lenRoom(req["Room"]),
+			RequestType: req.RequestType,
+			RequestID:   req.RequestID,
+			Connections: bc.local.lenRoom(req.Room),
 		}
 		bc.publish(bc.resChannel, &res)
 
 	case allRoomReqType:
 		res := allRoomResponse{
-			RequestType: req["RequestType"],
-			RequestID:   req["RequestID"],
+			RequestType: req.RequestType,
+			RequestID:   req.RequestID,
 			Rooms:       bc.local.allRooms(),
 		}
 		bc.publish(bc.resChannel, &res)
 
 	case clearRoomReqType:
-		if bc.local.uid == req["UUID"] {
+		if bc.local.uid == req.UUID {
 			return
 		}
-		bc.local.clear(req["Room"])
+		bc.local.clear(req.Room)
+
+	case fetchSocketsReqType:
+		res := fetchSocketsResponse{
+			RequestType: req.RequestType,
+			RequestID:   req.RequestID,
+			Sockets:     bc.local.fetchSockets(req.Room, req.Filter),
+		}
+		bc.publish(bc.resChannel, &res)
 
 	default:
 	}
@@ -245,42 +440,41 @@ func (bc *redisBroadcastRemoteV9) onResponse(msg []byte) {
 		return
 	}
 
-	req, ok := bc.requests[res["RequestID"].(string)]
+	requestID, ok := res["RequestID"].(string)
+	if !ok {
+		return
+	}
+
+	req, ok := bc.getRequest(requestID)
 	if !ok {
 		return
 	}
 
 	switch res["RequestType"] {
 	case roomLenReqType:
-		roomLenReq := req.(*roomLenRequest)
-
-		roomLenReq.mutex.Lock()
-		roomLenReq.msgCount++
-		roomLenReq.connections += int(res["Connections"].(float64))
-		roomLenReq.mutex.Unlock()
-
-		if roomLenReq.numSub == roomLenReq.msgCount {
-			roomLenReq.done <- true
-		}
+		connections, _ := res["Connections"].(float64)
+		req.answer(func() {
+			req.connections += int(connections)
+		})
 
 	case allRoomReqType:
-		allRoomReq := req.(*allRoomRequest)
-		rooms, ok := res["Rooms"].([]interface{})
-		if !ok {
-			allRoomReq.done <- true
-			return
-		}
-
-		allRoomReq.mutex.Lock()
-		allRoomReq.msgCount++
-		for _, room := range rooms {
-			allRoomReq.rooms[room.(string)] = true
-		}
-		allRoomReq.mutex.Unlock()
+		rooms, _ := res["Rooms"].([]interface{})
+		req.answer(func() {
+			for _, room := range rooms {
+				if room, ok := room.(string); ok {
+					req.rooms[room] = true
+				}
+			}
+		})
 
-		if allRoomReq.numSub == allRoomReq.msgCount {
-			allRoomReq.done <- true
+	case fetchSocketsReqType:
+		var fetchRes fetchSocketsResponse
+		if err := json.Unmarshal(msg, &fetchRes); err != nil {
+			return
 		}
+		req.answer(func() {
+			req.details = append(req.details, fetchRes.Sockets...)
+		})
 
 	default:
 	}
@@ -297,10 +491,13 @@ func (bc *redisBroadcastRemoteV9) publishClear(room string) {
 	bc.publish(bc.reqChannel, &req)
 }
 
-func (bc *redisBroadcastRemoteV9) publishMessage(room string, event string, args ...interface{}) {
-	opts := make([]interface{}, 2)
-	opts[0] = room
-	opts[1] = event
+// publishMessage publishes a broadcast to the connections in the room, or in
+// the namespace when room is empty, whose data match filter if it is not nil
+func (bc *redisBroadcastRemoteV9) publishMessage(room string, filter DataFilter, event string, args ...interface{}) {
+	opts := []interface{}{room, event}
+	if filter != nil {
+		opts = []interface{}{filteredMessageVersion, room, event, filter}
+	}
 
 	bcMessage := map[string][]interface{}{
 		"opts": opts,
@@ -317,51 +514,206 @@ func (bc *redisBroadcastRemoteV9) publishMessage(room string, event string, args
 	}
 }
 
-func (bc *redisBroadcastRemoteV9) dispatch() {
-	ch := bc.sub.ChannelWithSubscriptions()
-	for rec := range ch {
-		switch m := rec.(type) {
-		case *redis.Message:
-			switch m.Channel {
-			case bc.reqChannel:
-				bc.onRequest([]byte(m.Payload))
-				continue
-			case bc.resChannel:
-				bc.onResponse([]byte(m.Payload))
-				continue
-			default:
-				err := bc.onMessage(m.Channel, []byte(m.Payload))
-				if err != nil {
-					return
-				}
+// subscribe opens a new subscription to the broadcast pattern and to the
+// request and response channels.
+func (bc *redisBroadcastRemoteV9) subscribe(ctx context.Context) (*redis.PubSub, error) {
+	channels := []string{bc.reqChannel, bc.resChannel, bc.presenceChannel}
+	if bc.uidResChannel != "" {
+		channels = append(channels, bc.uidResChannel)
+	}
+
+	sub := bc.pub.PSubscribe(ctx, bc.pattern)
+	if err := sub.Subscribe(ctx, channels...); err != nil {
+		_ = sub.Close()
+		return nil, err
+	}
+
+	// wait for the confirmations, so that the node receives the broadcasts
+	// and is counted by the cluster requests once subscribed
+	for confirmed := 0; confirmed < len(channels)+1; {
+		rec, err := sub.ReceiveTimeout(ctx, redisPingInterval)
+		if err != nil {
+			_ = sub.Close()
+			return nil, err
+		}
+		if _, ok := rec.(*redis.Subscription); ok {
+			confirmed++
+		}
+	}
+
+	return sub, nil
+}
+
+// supervise dispatches the messages of the subscription and, whenever it is
+// lost, subscribes again until the adapter is closed.
+func (bc *redisBroadcastRemoteV9) supervise(sub *redis.PubSub) {
+	ll := logger.GetLogger("socketio.adapter.redis")
+
+	for {
+		err := bc.dispatch(sub)
+		_ = sub.Close()
+		if bc.closed() {
+			return
+		}
+
+		bc.state.Store(int32(AdapterReconnecting))
+		ll.Error(err, "subscription lost, reconnecting", "namespace", bc.local.nsp)
+
+		if sub = bc.resubscribe(); sub == nil {
+			return
+		}
+		bc.state.Store(int32(AdapterHealthy))
+	}
+}
+
+// resubscribe retries to subscribe with an exponential backoff. It returns nil
+// if the adapter was closed meanwhile.
+func (bc *redisBroadcastRemoteV9) resubscribe() *redis.PubSub {
+	ll := logger.GetLogger("socketio.adapter.redis")
+
+	delay := bc.reconnectDelay
+	for {
+		select {
+		case <-bc.quit:
+			return nil
+		case <-time.After(delay):
+		}
+
+		sub, err := bc.subscribe(context.TODO())
+		if err == nil {
+			bc.subMutex.Lock()
+			defer bc.subMutex.Unlock()
+
+			if bc.closed() {
+				_ = sub.Close()
+				return nil
 			}
-		case *redis.Subscription:
-			if m.Count == 0 {
-				return
+			bc.sub = sub
+			return sub
+		}
+
+		ll.V(1).Info("subscribe failed", "namespace", bc.local.nsp, "error", err.Error(), "retry", delay)
+
+		delay *= 2
+		if delay > bc.reconnectDelayMax {
+			delay = bc.reconnectDelayMax
+		}
+	}
+}
+
+// dispatch handles the messages of the subscription until it fails. When no
+// message arrives for a while, the connection is checked with a ping.
+func (bc *redisBroadcastRemoteV9) dispatch(sub *redis.PubSub) error {
+	pinged := false
+	for {
+		rec, err := sub.ReceiveTimeout(context.TODO(), redisPingInterval)
+		if err != nil {
+			var netErr net.Error
+			if !errors.As(err, &netErr) || !netErr.Timeout() {
+				return err
 			}
-		case error:
-			return
+			if pinged {
+				return errRedisNoPong
+			}
+			if err := sub.Ping(context.TODO()); err != nil {
+				return err
+			}
+			pinged = true
+			continue
+		}
+		pinged = false
+
+		if m, ok := rec.(*redis.Message); ok {
+			bc.onDispatch(m.Channel, []byte(m.Payload))
+		}
+	}
+}
+
+func (bc *redisBroadcastRemoteV9) onDispatch(channel string, msg []byte) {
+	if channel == bc.presenceChannel {
+		bc.onPresence(msg)
+		return
+	}
+
+	if bc.nodeCompatible {
+		bc.onNodeDispatch(channel, msg)
+		return
+	}
+
+	switch channel {
+	case bc.reqChannel:
+		bc.onRequest(msg)
+	case bc.resChannel:
+		bc.onResponse(msg)
+	default:
+		// a malformed message must not stop the other broadcasts
+		if err := bc.onMessage(channel, msg); err != nil {
+			logger.GetLogger("socketio.adapter.redis").Error(err, "ignoring broadcast", "channel", channel)
 		}
 	}
 }
 
+func (bc *redisBroadcastRemoteV9) health() AdapterHealth {
+	if bc.closed() {
+		return AdapterClosed
+	}
+	return AdapterHealth(bc.state.Load())
+}
+
+func (bc *redisBroadcastRemoteV9) closed() bool {
+	select {
+	case <-bc.quit:
+		return true
+	default:
+		return false
+	}
+}
+
+// close stops the subscription and closes the connections to redis.
+func (bc *redisBroadcastRemoteV9) close() error {
+	bc.subMutex.Lock()
+	defer bc.subMutex.Unlock()
+
+	if bc.closed() {
+		return nil
+	}
+	close(bc.quit)
+
+	bc.releasePresence()
+	bc.releaseRooms()
+
+	_ = bc.sub.Close()
+	if !bc.ownClient {
+		return nil
+	}
+	return bc.pub.Close()
+}
+
 // request types
 const (
-	roomLenReqType   = "0"
-	clearRoomReqType = "1"
-	allRoomReqType   = "2"
+	roomLenReqType      = "0"
+	clearRoomReqType    = "1"
+	allRoomReqType      = "2"
+	fetchSocketsReqType = "3"
 )
 
+// filteredMessageVersion is the version of the broadcasts with a filter
+const filteredMessageVersion = 1
+
+// requestMessage holds the fields of every request type
+type requestMessage struct {
+	RequestType string
+	RequestID   string
+	Room        string
+	UUID        string
+	Filter      DataFilter
+}
+
 // request structs
 type roomLenRequest struct {
 	RequestType string
 	RequestID   string
 	Room        string
-	numSub      int        `json:"-"`
-	msgCount    int        `json:"-"`
-	connections int        `json:"-"`
-	mutex       sync.Mutex `json:"-"`
-	done        chan bool  `json:"-"`
 }
 
 type clearRoomRequest struct {
@@ -374,11 +726,70 @@ type clearRoomRequest struct {
 type allRoomRequest struct {
 	RequestType string
 	RequestID   string
-	rooms       map[string]bool
+}
+
+// clusterRequest tracks the answers of the nodes to a request
+type clusterRequest struct {
+	requestType int
 	numSub      int
 	msgCount    int
+	connections int
+	rooms       map[string]bool
+	sockets     map[string]bool
+	details     []SocketInfo
 	mutex       sync.Mutex
-	done        chan bool
+	done        chan struct{}
+}
+
+// newClusterRequest creates a request expecting numSub answers, answered of
+// which are already known
+func newClusterRequest(numSub, answered int) *clusterRequest {
+	req := &clusterRequest{
+		numSub:   numSub,
+		msgCount: answered,
+		rooms:    make(map[string]bool),
+		sockets:  make(map[string]bool),
+		done:     make(chan struct{}),
+	}
+	if req.msgCount >= req.numSub {
+		close(req.done)
+	}
+	return req
+}
+
+// answer applies the answer of a node to the request
+func (r *clusterRequest) answer(apply func()) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.msgCount >= r.numSub {
+		return
+	}
+
+	apply()
+
+	r.msgCount++
+	if r.msgCount == r.numSub {
+		close(r.done)
+	}
+}
+
+// wait blocks until all the nodes answered or ctx is done
+func (r *clusterRequest) wait(ctx context.Context) error {
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return ctx.Err()
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return fmt.Errorf("%w: %d of %d nodes answered", ErrRequestTimeout, r.msgCount, r.numSub)
 }
 
 // response struct
@@ -0,0 +1,499 @@
+package socketio
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// node adapter request types, see RequestType of @socket.io/redis-adapter
+const (
+	nodeSocketsReqType = iota
+	nodeAllRoomsReqType
+	nodeRemoteJoinReqType
+	nodeRemoteLeaveReqType
+	nodeRemoteDisconnectReqType
+	nodeRemoteFetchReqType
+	nodeServerSideEmitReqType
+)
+
+// socket.io packet types carried by node adapter broadcasts
+const (
+	nodeEventPacket       = 2
+	nodeBinaryEventPacket = 5
+)
+
+// setNodeChannels uses the channel layout of the node adapter:
+// prefix#nsp# for broadcasts (followed by room# when targeting a single room),
+// prefix-request#nsp# and prefix-response#nsp# for cluster requests.
+func (bc *redisBroadcastRemoteV9) setNodeChannels(prefix string) {
+	bc.nodeNsp = bc.local.nsp
+	if bc.nodeNsp == rootNamespace {
+		bc.nodeNsp = aliasRootNamespace
+	}
+
+	bc.channel = fmt.Sprintf("%s#%s#", prefix, bc.nodeNsp)
+	bc.pattern = bc.channel + "*"
+	bc.reqChannel = fmt.Sprintf("%s-request#%s#", prefix, bc.nodeNsp)
+	bc.resChannel = fmt.Sprintf("%s-response#%s#", prefix, bc.nodeNsp)
+	bc.uidResChannel = fmt.Sprintf("%s%s#", bc.resChannel, bc.local.uid)
+}
+
+func (bc *redisBroadcastRemoteV9) nodeLenRoom(ctx context.Context, room string) (int, error) {
+	req, err := bc.nodeRequest(ctx, nodeRequest{
+		Type:  nodeSocketsReqType,
+		Rooms: []string{room},
+	})
+	if err != nil && !errors.Is(err, ErrRequestTimeout) {
+		return -1, err
+	}
+
+	req.mutex.Lock()
+	defer req.mutex.Unlock()
+
+	return len(req.sockets), err
+}
+
+func (bc *redisBroadcastRemoteV9) nodeAllRooms(ctx context.Context) ([]string, error) {
+	req, err := bc.nodeRequest(ctx, nodeRequest{
+		Type: nodeAllRoomsReqType,
+	})
+	if err != nil && !errors.Is(err, ErrRequestTimeout) {
+		return []string{}, err // if error occurred,return empty
+	}
+
+	req.mutex.Lock()
+	defer req.mutex.Unlock()
+
+	return getKeysOfMap(req.rooms), err
+}
+
+// nodeRequest answers the request locally, then publishes it and waits for
+// the answer of every other node like the node adapter does.
+func (bc *redisBroadcastRemoteV9) nodeRequest(ctx context.Context, msg nodeRequest) (*clusterRequest, error) {
+	numSub, err := bc.getNumSub(ctx, bc.reqChannel)
+	if err != nil {
+		return nil, err
+	}
+	if numSub < 1 {
+		numSub = 1
+	}
+
+	req := newClusterRequest(numSub, 0)
+	req.requestType = msg.Type
+	bc.answerNodeRequest(req, bc.nodeLocalResponse(&msg))
+
+	if numSub == 1 {
+		return req, nil
+	}
+
+	msg.UID = bc.local.uid
+	msg.RequestID = newV4UUID()
+
+	return req, bc.request(ctx, msg.RequestID, req, &msg)
+}
+
+// nodeLocalResponse gives the answer of the local node to a sockets, all
+// rooms or fetch request
+func (bc *redisBroadcastRemoteV9) nodeLocalResponse(req *nodeRequest) *nodeResponse {
+	res := &nodeResponse{RequestID: req.RequestID}
+
+	switch req.Type {
+	case nodeSocketsReqType:
+		for connID := range bc.local.match(req.Rooms, nil) {
+			res.Sockets = append(res.Sockets, connID)
+		}
+
+	case nodeAllRoomsReqType:
+		res.Rooms = bc.local.allRooms()
+
+	case nodeRemoteFetchReqType:
+		for _, conn := range bc.local.match(req.Opts.Rooms, req.Opts.Except) {
+			res.Sockets = append(res.Sockets, bc.nodeSocketDetails(conn))
+		}
+	}
+
+	return res
+}
+
+func (bc *redisBroadcastRemoteV9) onNodeDispatch(channel string, msg []byte) {
+	switch channel {
+	case bc.reqChannel:
+		bc.onNodeRequest(msg)
+	case bc.resChannel, bc.uidResChannel:
+		bc.onNodeResponse(msg)
+	default:
+		// like the node adapter, malformed broadcasts are ignored
+		_ = bc.onNodeMessage(channel, msg)
+	}
+}
+
+func (bc *redisBroadcastRemoteV9) onNodeMessage(channel string, msg []byte) error {
+	if !strings.HasPrefix(channel, bc.channel) {
+		return nil
+	}
+
+	room := strings.TrimSuffix(strings.TrimPrefix(channel, bc.channel), "#")
+	if room != "" {
+		if _, ok := bc.local.getOccupants(room); !ok {
+			return nil
+		}
+	}
+
+	var bcMessage nodeBroadcastMessage
+	if err := decodeNodeMessage(msg, &bcMessage); err != nil {
+		return errors.New("invalid broadcast message")
+	}
+
+	if bcMessage.UID == bc.local.uid {
+		return nil
+	}
+
+	packet := bcMessage.Packet
+	if packet.Nsp == "" {
+		packet.Nsp = aliasRootNamespace
+	}
+	if packet.Nsp != bc.nodeNsp {
+		return nil
+	}
+
+	if packet.Type != nodeEventPacket && packet.Type != nodeBinaryEventPacket {
+		return errors.New("invalid packet type")
+	}
+
+	if len(packet.Data) == 0 {
+		return errors.New("invalid event")
+	}
+
+	event, ok := packet.Data[0].(string)
+	if !ok {
+		return errors.New("invalid event")
+	}
+
+	bc.local.broadcast(bcMessage.Opts.Rooms, bcMessage.Opts.Except, event, packet.Data[1:]...)
+
+	return nil
+}
+
+// Handle request from redis channel, the way the node adapter does.
+func (bc *redisBroadcastRemoteV9) onNodeRequest(msg []byte) {
+	var req nodeRequest
+	if err := decodeNodeMessage(msg, &req); err != nil {
+		return
+	}
+
+	// ignore our own requests
+	if _, ok := bc.getRequest(req.RequestID); ok {
+		return
+	}
+
+	switch req.Type {
+	case nodeSocketsReqType:
+		sockets := make([]string, 0)
+		for connID := range bc.local.match(req.Rooms, nil) {
+			sockets = append(sockets, connID)
+		}
+		bc.publish(bc.resChannel, &nodeSocketsResponse{
+			RequestID: req.RequestID,
+			Sockets:   sockets,
+		})
+
+	case nodeAllRoomsReqType:
+		bc.publish(bc.resChannel, &nodeAllRoomsResponse{
+			RequestID: req.RequestID,
+			Rooms:     bc.local.allRooms(),
+		})
+
+	case nodeRemoteJoinReqType, nodeRemoteLeaveReqType:
+		if req.UID == bc.local.uid {
+			return
+		}
+
+		apply := bc.local.join
+		if req.Type == nodeRemoteLeaveReqType {
+			apply = bc.local.leave
+		}
+
+		if req.Opts != nil {
+			for _, conn := range bc.local.match(req.Opts.Rooms, req.Opts.Except) {
+				for _, room := range req.Rooms {
+					apply(room, conn)
+				}
+			}
+			return
+		}
+
+		conn, ok := bc.nodeSocket(req.SID)
+		if !ok {
+			return
+		}
+		apply(req.Room, conn)
+		bc.publish(bc.resChannel, &nodeAckResponse{RequestID: req.RequestID})
+
+	case nodeRemoteDisconnectReqType:
+		if req.UID == bc.local.uid {
+			return
+		}
+
+		if req.Opts != nil {
+			for _, conn := range bc.local.match(req.Opts.Rooms, req.Opts.Except) {
+				_ = conn.Close()
+			}
+			return
+		}
+
+		conn, ok := bc.nodeSocket(req.SID)
+		if !ok {
+			return
+		}
+		_ = conn.Close()
+		bc.publish(bc.resChannel, &nodeAckResponse{RequestID: req.RequestID})
+
+	case nodeRemoteFetchReqType:
+		var rooms, except []string
+		if req.Opts != nil {
+			rooms, except = req.Opts.Rooms, req.Opts.Except
+		}
+
+		sockets := make([]nodeSocketDetails, 0)
+		for _, conn := range bc.local.match(rooms, except) {
+			sockets = append(sockets, bc.nodeSocketDetails(conn))
+		}
+		bc.publish(bc.resChannel, &nodeFetchResponse{
+			RequestID: req.RequestID,
+			Sockets:   sockets,
+		})
+
+	default:
+	}
+}
+
+// Handle response from redis channel, the way the node adapter does.
+func (bc *redisBroadcastRemoteV9) onNodeResponse(msg []byte) {
+	var res nodeResponse
+	if err := decodeNodeMessage(msg, &res); err != nil {
+		return
+	}
+
+	req, ok := bc.getRequest(res.RequestID)
+	if !ok {
+		return
+	}
+
+	bc.answerNodeRequest(req, &res)
+}
+
+func (bc *redisBroadcastRemoteV9) answerNodeRequest(req *clusterRequest, res *nodeResponse) {
+	req.answer(func() {
+		switch req.requestType {
+		case nodeSocketsReqType:
+			for _, socket := range res.Sockets {
+				if id, ok := socket.(string); ok {
+					req.sockets[id] = true
+				}
+			}
+
+		case nodeAllRoomsReqType:
+			for _, room := range res.Rooms {
+				req.rooms[room] = true
+			}
+
+		case nodeRemoteFetchReqType:
+			for _, socket := range res.Sockets {
+				if info, ok := nodeSocketInfo(socket); ok {
+					req.details = append(req.details, info)
+				}
+			}
+		}
+	})
+}
+
+func (bc *redisBroadcastRemoteV9) publishNodeClear(room string) {
+	bc.publish(bc.reqChannel, &nodeRequest{
+		UID:  bc.local.uid,
+		Type: nodeRemoteLeaveReqType,
+		Opts: &nodeBroadcastOptions{
+			Rooms:  []string{room},
+			Except: []string{},
+		},
+		Rooms: []string{room},
+	})
+}
+
+func (bc *redisBroadcastRemoteV9) publishNodeMessage(room string, event string, args ...interface{}) {
+	channel := bc.channel
+	opts := nodeBroadcastOptions{
+		Rooms:  []string{},
+		Except: []string{},
+	}
+	if room != "" {
+		channel += room + "#"
+		opts.Rooms = append(opts.Rooms, room)
+	}
+
+	bcMessage, err := encodeNodeMessage(&nodeBroadcastMessage{
+		UID: bc.local.uid,
+		Packet: nodePacket{
+			Type: nodeEventPacket,
+			Data: append([]interface{}{event}, args...),
+			Nsp:  bc.nodeNsp,
+		},
+		Opts: opts,
+	})
+	if err != nil {
+		return
+	}
+
+	_, err = bc.pub.Publish(context.TODO(), channel, bcMessage).Result()
+	if err != nil {
+		return
+	}
+}
+
+// nodeSocket returns the local connection with given id, every connection
+// joins the room named after its id
+func (bc *redisBroadcastRemoteV9) nodeSocket(sid string) (Conn, bool) {
+	conns, ok := bc.local.getOccupants(sid)
+	if !ok {
+		return nil, false
+	}
+	return conns.getConn(sid)
+}
+
+func (bc *redisBroadcastRemoteV9) nodeSocketDetails(conn Conn) nodeSocketDetails {
+	headers := make(map[string]string)
+	for k, v := range conn.RemoteHeader() {
+		headers[strings.ToLower(k)] = strings.Join(v, ", ")
+	}
+
+	u := conn.URL()
+	query := make(map[string]string)
+	for k := range u.Query() {
+		query[k] = u.Query().Get(k)
+	}
+
+	var address string
+	if addr := conn.RemoteAddr(); addr != nil {
+		address = addr.String()
+	}
+
+	rooms := bc.local.getRoomsByConn(conn)
+	if rooms == nil {
+		rooms = []string{}
+	}
+
+	return nodeSocketDetails{
+		ID: conn.ID(),
+		Handshake: nodeHandshake{
+			Headers: headers,
+			Address: address,
+			URL:     u.RequestURI(),
+			Query:   query,
+		},
+		Rooms: rooms,
+		Data:  connValues(conn),
+	}
+}
+
+// encodeNodeMessage encodes v with msgpack, honoring json struct tags like
+// the node adapter's notepack parser expects for plain objects
+func encodeNodeMessage(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	enc.UseCompactInts(true)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeNodeMessage decodes a JSON message if it starts with "{", else a
+// msgpack one
+func decodeNodeMessage(b []byte, v interface{}) error {
+	if len(b) > 0 && b[0] == '{' {
+		return json.Unmarshal(b, v)
+	}
+
+	dec := msgpack.NewDecoder(bytes.NewReader(b))
+	dec.SetCustomStructTag("json")
+	return dec.Decode(v)
+}
+
+// broadcast structs
+type nodeBroadcastMessage struct {
+	_msgpack struct{} `msgpack:",as_array"`
+
+	UID    string
+	Packet nodePacket
+	Opts   nodeBroadcastOptions
+}
+
+type nodePacket struct {
+	Type int           `json:"type"`
+	Data []interface{} `json:"data"`
+	Nsp  string        `json:"nsp,omitempty"`
+}
+
+type nodeBroadcastOptions struct {
+	Rooms  []string               `json:"rooms"`
+	Except []string               `json:"except"`
+	Flags  map[string]interface{} `json:"flags,omitempty"`
+}
+
+// request structs
+type nodeRequest struct {
+	UID       string                `json:"uid"`
+	RequestID string                `json:"requestId,omitempty"`
+	Type      int                   `json:"type"`
+	Rooms     []string              `json:"rooms,omitempty"`
+	Opts      *nodeBroadcastOptions `json:"opts,omitempty"`
+	SID       string                `json:"sid,omitempty"`
+	Room      string                `json:"room,omitempty"`
+	Close     bool                  `json:"close,omitempty"`
+}
+
+// response structs
+type nodeResponse struct {
+	RequestID string        `json:"requestId"`
+	Sockets   []interface{} `json:"sockets"`
+	Rooms     []string      `json:"rooms"`
+}
+
+type nodeAckResponse struct {
+	RequestID string `json:"requestId"`
+}
+
+type nodeSocketsResponse struct {
+	RequestID string   `json:"requestId"`
+	Sockets   []string `json:"sockets"`
+}
+
+type nodeAllRoomsResponse struct {
+	RequestID string   `json:"requestId"`
+	Rooms     []string `json:"rooms"`
+}
+
+type nodeFetchResponse struct {
+	RequestID string              `json:"requestId"`
+	Sockets   []nodeSocketDetails `json:"sockets"`
+}
+
+type nodeSocketDetails struct {
+	ID        string        `json:"id"`
+	Handshake nodeHandshake `json:"handshake"`
+	Rooms     []string      `json:"rooms"`
+	Data      interface{}   `json:"data"`
+}
+
+type nodeHandshake struct {
+	Headers map[string]string `json:"headers"`
+	Address string            `json:"address"`
+	URL     string            `json:"url"`
+	Query   map[string]string `json:"query"`
+}
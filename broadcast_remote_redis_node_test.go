@@ -0,0 +1,123 @@
+package socketio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func newNodeTestRemote(nsp string) *redisBroadcastRemoteV9 {
+	bc := &redisBroadcastRemoteV9{
+		local:          newBroadcastLocal(nsp),
+		requests:       make(map[string]*clusterRequest),
+		nodeCompatible: true,
+	}
+	bc.setNodeChannels("socket.io")
+
+	return bc
+}
+
+func TestNodeChannels(t *testing.T) {
+	should := assert.New(t)
+
+	bc := newNodeTestRemote("")
+	should.Equal("socket.io#/#*", bc.pattern)
+	should.Equal("socket.io-request#/#", bc.reqChannel)
+	should.Equal("socket.io-response#/#", bc.resChannel)
+	should.Equal("socket.io-response#/#"+bc.local.uid+"#", bc.uidResChannel)
+
+	bc = newNodeTestRemote("/chat")
+	should.Equal("socket.io#/chat#*", bc.pattern)
+}
+
+func TestNodeOnMessage(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	bc := newNodeTestRemote("")
+
+	c1 := newBusTestConn("c1")
+	c2 := newBusTestConn("c2")
+	bc.local.join("room", c1)
+	bc.local.join("room", c2)
+	bc.local.join("c2", c2)
+
+	// message as published by a node server
+	msg, err := msgpack.Marshal([]interface{}{
+		"node-uid",
+		map[string]interface{}{
+			"type": 2,
+			"data": []interface{}{"hello", "world"},
+			"nsp":  "/",
+		},
+		map[string]interface{}{
+			"rooms":  []string{"room"},
+			"except": []string{"c2"},
+			"flags":  map[string]interface{}{},
+		},
+	})
+	must.NoError(err)
+
+	must.NoError(bc.onNodeMessage("socket.io#/#room#", msg))
+
+	select {
+	case got := <-c1.events:
+		should.Equal([]interface{}{"hello", "world"}, got)
+	case <-time.After(time.Second):
+		t.Fatal("broadcast was not delivered")
+	}
+
+	select {
+	case got := <-c2.events:
+		t.Fatalf("unexpected event %v", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// unknown rooms and other namespaces are ignored
+	must.NoError(bc.onNodeMessage("socket.io#/#unknown#", msg))
+	must.NoError(newNodeTestRemote("/chat").onNodeMessage("socket.io#/chat#", msg))
+
+	should.Error(bc.onNodeMessage("socket.io#/#", []byte{0xc1}))
+}
+
+func TestNodeMessageEncoding(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	msg, err := encodeNodeMessage(&nodeBroadcastMessage{
+		UID: "go-uid",
+		Packet: nodePacket{
+			Type: nodeEventPacket,
+			Data: []interface{}{"event", map[string]interface{}{"k": "v"}},
+			Nsp:  "/",
+		},
+		Opts: nodeBroadcastOptions{
+			Rooms:  []string{"room"},
+			Except: []string{},
+		},
+	})
+	must.NoError(err)
+
+	var decoded []interface{}
+	must.NoError(msgpack.Unmarshal(msg, &decoded))
+	must.Len(decoded, 3)
+
+	should.Equal("go-uid", decoded[0])
+
+	packet := decoded[1].(map[string]interface{})
+	should.EqualValues(nodeEventPacket, packet["type"])
+	should.Equal("/", packet["nsp"])
+	should.Equal([]interface{}{"event", map[string]interface{}{"k": "v"}}, packet["data"])
+
+	opts := decoded[2].(map[string]interface{})
+	should.Equal([]interface{}{"room"}, opts["rooms"])
+	should.Equal([]interface{}{}, opts["except"])
+
+	var req nodeRequest
+	must.NoError(decodeNodeMessage([]byte(`{"uid":"abc","requestId":"42","type":1}`), &req))
+	should.Equal(nodeAllRoomsReqType, req.Type)
+	should.Equal("42", req.RequestID)
+}
@@ -0,0 +1,246 @@
+package socketio
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+
+	"github.com/vchitai/go-socket.io/v4/logger"
+)
+
+// The sockets of a user are stored in a sorted set scored by their expiry,
+// which the node owning them refreshes every third of the presence TTL. The
+// users are indexed in another sorted set scored by their latest expiry, so
+// that the nodes can find the users whose sockets all expired after a crash.
+// The keys share a hash tag to be usable by scripts with Redis Cluster.
+
+// KEYS[1] sockets of the user, KEYS[2] users index
+// ARGV[1] now, ARGV[2] expiry, ARGV[3] sid, ARGV[4] user, ARGV[5] ttl
+// returns 1 when the user came online
+var presenceBindScript = redis.NewScript(`
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+local added = redis.call('ZADD', KEYS[1], ARGV[2], ARGV[3])
+redis.call('PEXPIRE', KEYS[1], ARGV[5])
+redis.call('ZADD', KEYS[2], ARGV[2], ARGV[4])
+if added == 1 and redis.call('ZCARD', KEYS[1]) == 1 then
+	return 1
+end
+return 0
+`)
+
+// KEYS[1] sockets of the user, KEYS[2] users index
+// ARGV[1] now, ARGV[2] sid, ARGV[3] user
+// returns 1 when the user went offline
+var presenceUnbindScript = redis.NewScript(`
+local removed = redis.call('ZREM', KEYS[1], ARGV[2])
+removed = removed + redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+if removed > 0 and redis.call('ZCARD', KEYS[1]) == 0 then
+	return redis.call('ZREM', KEYS[2], ARGV[3])
+end
+return 0
+`)
+
+// KEYS[1] sockets of the user, KEYS[2] users index
+// ARGV[1] now, ARGV[2] user
+// returns 1 when the user went offline
+var presenceSweepScript = redis.NewScript(`
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+if redis.call('ZCARD', KEYS[1]) == 0 then
+	return redis.call('ZREM', KEYS[2], ARGV[2])
+end
+local last = redis.call('ZRANGE', KEYS[1], -1, -1, 'WITHSCORES')
+redis.call('ZADD', KEYS[2], last[2], ARGV[2])
+return 0
+`)
+
+// presenceEvent is published when a user comes online or goes offline
+type presenceEvent struct {
+	User   string
+	Online bool
+}
+
+func (bc *redisBroadcastRemoteV9) setPresenceChannels(prefix, nsp string) {
+	bc.presenceKey = fmt.Sprintf("{%s-presence#%s}", prefix, nsp)
+	bc.presenceChannel = fmt.Sprintf("%s-presence#%s", prefix, nsp)
+}
+
+func (bc *redisBroadcastRemoteV9) userKey(user string) string {
+	return bc.presenceKey + "#" + user
+}
+
+func (bc *redisBroadcastRemoteV9) bindUser(user, sid string) {
+	ctx, cancel := bc.requestContext(context.Background())
+	defer cancel()
+
+	if err := bc.refreshUser(ctx, user, sid); err != nil {
+		logger.GetLogger("socketio.adapter.redis").Error(err, "bind user", "user", user)
+	}
+}
+
+func (bc *redisBroadcastRemoteV9) unbindUser(user, sid string) {
+	ctx, cancel := bc.requestContext(context.Background())
+	defer cancel()
+
+	offline, err := presenceUnbindScript.Run(ctx, bc.pub,
+		[]string{bc.userKey(user), bc.presenceKey},
+		nowMillis(), sid, user,
+	).Int()
+	if err != nil {
+		logger.GetLogger("socketio.adapter.redis").Error(err, "unbind user", "user", user)
+		return
+	}
+
+	if offline == 1 {
+		bc.publishPresence(ctx, user, false)
+	}
+}
+
+func (bc *redisBroadcastRemoteV9) userSockets(ctx context.Context, user string) ([]string, error) {
+	sids, err := bc.pub.ZRangeByScore(ctx, bc.userKey(user), &redis.ZRangeBy{
+		Min: "(" + nowMillis(),
+		Max: "+inf",
+	}).Result()
+
+	// the local sockets are known even when redis is not reachable
+	set := make(map[string]bool, len(sids))
+	for _, sid := range sids {
+		set[sid] = true
+	}
+	for _, sid := range bc.local.presence.sockets(user) {
+		set[sid] = true
+	}
+
+	return getKeysOfMap(set), err
+}
+
+// refreshUser binds sid to user until the presence TTL elapses, and tells the
+// nodes when the user came online.
+func (bc *redisBroadcastRemoteV9) refreshUser(ctx context.Context, user, sid string) error {
+	now := time.Now()
+	expiry := now.Add(bc.presenceTTL)
+
+	online, err := presenceBindScript.Run(ctx, bc.pub,
+		[]string{bc.userKey(user), bc.presenceKey},
+		strconv.FormatInt(now.UnixMilli(), 10),
+		strconv.FormatInt(expiry.UnixMilli(), 10),
+		sid, user, bc.presenceTTL.Milliseconds(),
+	).Int()
+	if err != nil {
+		return err
+	}
+
+	if online == 1 {
+		bc.publishPresence(ctx, user, true)
+	}
+	return nil
+}
+
+// keepAlive refreshes the bindings and the rooms of the node, and expires the
+// bindings of the nodes which stopped refreshing them, until the adapter is closed.
+func (bc *redisBroadcastRemoteV9) keepAlive() {
+	ll := logger.GetLogger("socketio.adapter.redis")
+
+	ticker := time.NewTicker(bc.presenceTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-bc.quit:
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := bc.requestContext(context.Background())
+		for user, sids := range bc.local.presence.bindings() {
+			for _, sid := range sids {
+				if err := bc.refreshUser(ctx, user, sid); err != nil {
+					ll.Error(err, "refresh user", "user", user)
+				}
+			}
+		}
+
+		if err := bc.sweepPresence(ctx); err != nil {
+			ll.Error(err, "expire users")
+		}
+
+		if err := bc.refreshRooms(ctx); err != nil {
+			ll.Error(err, "refresh rooms")
+		}
+		cancel()
+	}
+}
+
+// sweepPresence removes the expired sockets of the users, and tells the nodes
+// when users went offline.
+func (bc *redisBroadcastRemoteV9) sweepPresence(ctx context.Context) error {
+	now := nowMillis()
+
+	users, err := bc.pub.ZRangeByScore(ctx, bc.presenceKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: now,
+	}).Result()
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		offline, err := presenceSweepScript.Run(ctx, bc.pub,
+			[]string{bc.userKey(user), bc.presenceKey},
+			now, user,
+		).Int()
+		if err != nil {
+			return err
+		}
+
+		if offline == 1 {
+			bc.publishPresence(ctx, user, false)
+		}
+	}
+	return nil
+}
+
+// releasePresence unbinds the sockets of the node when it is closed.
+func (bc *redisBroadcastRemoteV9) releasePresence() {
+	for user, sids := range bc.local.presence.bindings() {
+		for _, sid := range sids {
+			bc.unbindUser(user, sid)
+		}
+	}
+}
+
+func (bc *redisBroadcastRemoteV9) publishPresence(ctx context.Context, user string, online bool) {
+	msg, err := json.Marshal(presenceEvent{User: user, Online: online})
+	if err != nil {
+		return
+	}
+
+	if err := bc.pub.Publish(ctx, bc.presenceChannel, msg).Err(); err != nil {
+		logger.GetLogger("socketio.adapter.redis").Error(err, "publish presence", "user", user)
+	}
+}
+
+// Handle presence change from redis channel, including those of this node.
+func (bc *redisBroadcastRemoteV9) onPresence(msg []byte) {
+	var event presenceEvent
+	if err := json.Unmarshal(msg, &event); err != nil || event.User == "" {
+		return
+	}
+
+	bc.local.presence.notify(event.User, event.Online)
+}
+
+// requestContext bounds ctx with the requests timeout
+func (bc *redisBroadcastRemoteV9) requestContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if bc.requestsTimeout > 0 {
+		return context.WithTimeout(ctx, bc.requestsTimeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+func nowMillis() string {
+	return strconv.FormatInt(time.Now().UnixMilli(), 10)
+}
@@ -0,0 +1,318 @@
+package socketio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+
+	"github.com/vchitai/go-socket.io/v4/logger"
+)
+
+// The data of a room are stored in a hash with their version. The nodes having
+// connections in a room with data register in a sorted set scored by their
+// expiry, which they refresh like the user presence. The data are deleted with
+// the last registration, so that they live as long as the room in the cluster.
+// Both keys share a hash tag to be usable by scripts with Redis Cluster.
+//
+// Joining and leaving the rooms without data costs no request: a node
+// registers when it sets the data, and the other nodes of the room at their
+// next refresh. Until then, the data are deleted if the registered nodes leave
+// the room first.
+
+// KEYS[1] room data, KEYS[2] room nodes
+// ARGV[1] expiry, ARGV[2] uid, ARGV[3] ttl
+// returns 1 when the room has data and the node was registered, else 0
+var roomRegisterScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+redis.call('ZADD', KEYS[2], ARGV[1], ARGV[2])
+redis.call('PEXPIRE', KEYS[2], ARGV[3])
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+return 1
+`)
+
+// KEYS[1] room data, KEYS[2] room nodes
+// ARGV[1] now, ARGV[2] uid
+var roomUnregisterScript = redis.NewScript(`
+redis.call('ZREM', KEYS[2], ARGV[2])
+redis.call('ZREMRANGEBYSCORE', KEYS[2], '-inf', ARGV[1])
+if redis.call('ZCARD', KEYS[2]) == 0 then
+	redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// KEYS[1] room data, KEYS[2] room nodes
+// ARGV[1] now, ARGV[2] expiry, ARGV[3] uid, ARGV[4] 1 to register the node,
+// ARGV[5] expected version or empty, ARGV[6] data, ARGV[7] ttl
+// returns {status, version}, status is 0 on success, -1 when the room does
+// not exist and -2 on version conflict
+var roomSetScript = redis.NewScript(`
+if ARGV[4] == '1' then
+	redis.call('ZADD', KEYS[2], ARGV[2], ARGV[3])
+	redis.call('PEXPIRE', KEYS[2], ARGV[7])
+end
+redis.call('ZREMRANGEBYSCORE', KEYS[2], '-inf', ARGV[1])
+if redis.call('ZCARD', KEYS[2]) == 0 then
+	redis.call('DEL', KEYS[1])
+	return {-1, 0}
+end
+local version = tonumber(redis.call('HGET', KEYS[1], 'version') or '0')
+if ARGV[5] ~= '' and tonumber(ARGV[5]) ~= version then
+	return {-2, version}
+end
+version = redis.call('HINCRBY', KEYS[1], 'version', 1)
+redis.call('HSET', KEYS[1], 'data', ARGV[6])
+redis.call('PEXPIRE', KEYS[1], ARGV[7])
+return {0, version}
+`)
+
+// KEYS[1] room data, KEYS[2] room nodes
+// ARGV[1] now
+// returns nil when the room does not exist, else {version, data}
+var roomGetScript = redis.NewScript(`
+redis.call('ZREMRANGEBYSCORE', KEYS[2], '-inf', ARGV[1])
+if redis.call('ZCARD', KEYS[2]) == 0 then
+	redis.call('DEL', KEYS[1])
+	return false
+end
+return redis.call('HMGET', KEYS[1], 'version', 'data')
+`)
+
+func (bc *redisBroadcastRemoteV9) setRoomKeys(prefix, nsp string) {
+	bc.roomKeyPrefix = fmt.Sprintf("%s-room#%s#", prefix, nsp)
+}
+
+// roomKeys gives the keys of the data and the nodes of the room
+func (bc *redisBroadcastRemoteV9) roomKeys(room string) []string {
+	dataKey := "{" + bc.roomKeyPrefix + room + "}"
+	return []string{dataKey, dataKey + "#nodes"}
+}
+
+func (bc *redisBroadcastRemoteV9) setRoomData(ctx context.Context, room string, data []byte) (int64, error) {
+	return bc.runRoomSet(ctx, room, "", data)
+}
+
+func (bc *redisBroadcastRemoteV9) updateRoomData(ctx context.Context, room string, version int64, data []byte) (int64, error) {
+	return bc.runRoomSet(ctx, room, strconv.FormatInt(version, 10), data)
+}
+
+func (bc *redisBroadcastRemoteV9) runRoomSet(ctx context.Context, room, version string, data []byte) (int64, error) {
+	ctx, cancel := bc.requestContext(ctx)
+	defer cancel()
+
+	_, local := bc.local.getOccupants(room)
+	res, err := bc.roomSet(ctx, room, local, version, data)
+	if err == nil && !local && res[0] == -1 {
+		// the room may have connections on nodes which did not register, as
+		// it had no data. This node registers until they do at their next
+		// refresh, its registration expiring as it does not refresh it.
+		var exists bool
+		if exists, err = bc.roomExists(ctx, room); exists {
+			res, err = bc.roomSet(ctx, room, true, version, data)
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	switch res[0] {
+	case -1:
+		return 0, ErrRoomNotFound
+	case -2:
+		return res[1], ErrRoomDataConflict
+	}
+	if local {
+		bc.markRoom(room, true)
+	}
+	return res[1], nil
+}
+
+// roomSet runs the script setting the data of the room, which registers the
+// node if register is true
+func (bc *redisBroadcastRemoteV9) roomSet(ctx context.Context, room string, register bool, version string, data []byte) ([]int64, error) {
+	registerArg := "0"
+	if register {
+		registerArg = "1"
+	}
+
+	now := time.Now()
+	res, err := roomSetScript.Run(ctx, bc.pub, bc.roomKeys(room),
+		now.UnixMilli(), now.Add(bc.presenceTTL).UnixMilli(), bc.local.uid, registerArg,
+		version, data, bc.presenceTTL.Milliseconds(),
+	).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+	if len(res) != 2 {
+		return nil, errors.New("invalid room data response")
+	}
+	return res, nil
+}
+
+func (bc *redisBroadcastRemoteV9) roomData(ctx context.Context, room string) ([]byte, int64, error) {
+	ctx, cancel := bc.requestContext(ctx)
+	defer cancel()
+
+	res, err := roomGetScript.Run(ctx, bc.pub, bc.roomKeys(room), nowMillis()).Slice()
+	if errors.Is(err, redis.Nil) {
+		// no node registered, the room has no data if it exists
+		exists, err := bc.roomExists(ctx, room)
+		if err != nil {
+			return nil, 0, err
+		}
+		if !exists {
+			return nil, 0, ErrRoomNotFound
+		}
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(res) != 2 || res[0] == nil {
+		return nil, 0, nil
+	}
+
+	version, err := strconv.ParseInt(fmt.Sprint(res[0]), 10, 64)
+	if err != nil {
+		return nil, 0, err
+	}
+	data, _ := res[1].(string)
+
+	return []byte(data), version, nil
+}
+
+// roomExists tells whether a node has connections in the room, asking the
+// cluster when this node has none
+func (bc *redisBroadcastRemoteV9) roomExists(ctx context.Context, room string) (bool, error) {
+	if _, ok := bc.local.getOccupants(room); ok {
+		return true, nil
+	}
+
+	n, err := bc.lenRoom(ctx, room)
+	if err != nil && !errors.Is(err, ErrRequestTimeout) {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// socketRoom tells whether the room is the one named after the id of its
+// connection, which never has data
+func (bc *redisBroadcastRemoteV9) socketRoom(room string) bool {
+	conns, ok := bc.local.getOccupants(room)
+	if !ok {
+		return false
+	}
+	_, ok = conns.getConn(room)
+	return ok
+}
+
+// markRoom records whether the node is registered in the room, and returns
+// whether it was
+func (bc *redisBroadcastRemoteV9) markRoom(room string, registered bool) bool {
+	bc.roomsMutex.Lock()
+	defer bc.roomsMutex.Unlock()
+
+	was := bc.roomsRegistered[room]
+	if registered {
+		bc.roomsRegistered[room] = true
+	} else {
+		delete(bc.roomsRegistered, room)
+	}
+	return was
+}
+
+// registeredRooms returns the rooms the node is registered in
+func (bc *redisBroadcastRemoteV9) registeredRooms() []string {
+	bc.roomsMutex.Lock()
+	defer bc.roomsMutex.Unlock()
+
+	return getKeysOfMap(bc.roomsRegistered)
+}
+
+// unregisterRoom deletes the data of the room if no other node has
+// connections in it
+func (bc *redisBroadcastRemoteV9) unregisterRoom(ctx context.Context, room string) error {
+	return roomUnregisterScript.Run(ctx, bc.pub, bc.roomKeys(room),
+		nowMillis(), bc.local.uid,
+	).Err()
+}
+
+// deleteRoomData deletes the data and the registrations of a cleared room
+func (bc *redisBroadcastRemoteV9) deleteRoomData(room string) {
+	ctx, cancel := bc.requestContext(context.Background())
+	defer cancel()
+
+	if err := bc.pub.Del(ctx, bc.roomKeys(room)...).Err(); err != nil {
+		logger.GetLogger("socketio.adapter.redis").Error(err, "delete room data", "room", room)
+	}
+}
+
+func (bc *redisBroadcastRemoteV9) onRoomDeleted(room string) {
+	if !bc.markRoom(room, false) {
+		return
+	}
+
+	ctx, cancel := bc.requestContext(context.Background())
+	defer cancel()
+
+	if err := bc.unregisterRoom(ctx, room); err != nil {
+		logger.GetLogger("socketio.adapter.redis").Error(err, "unregister room", "room", room)
+	}
+}
+
+// refreshRooms registers the node in its rooms with data, keeping the
+// registrations alive, in a single pipeline
+func (bc *redisBroadcastRemoteV9) refreshRooms(ctx context.Context) error {
+	var rooms []string
+	for _, room := range bc.local.allRooms() {
+		if !bc.socketRoom(room) {
+			rooms = append(rooms, room)
+		}
+	}
+	if len(rooms) == 0 {
+		return nil
+	}
+
+	expiry := time.Now().Add(bc.presenceTTL)
+	cmds := make([]*redis.Cmd, len(rooms))
+	_, err := bc.pub.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, room := range rooms {
+			cmds[i] = roomRegisterScript.Eval(ctx, pipe, bc.roomKeys(room),
+				expiry.UnixMilli(), bc.local.uid, bc.presenceTTL.Milliseconds(),
+			)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, room := range rooms {
+		registered, err := cmds[i].Int()
+		if err != nil {
+			return err
+		}
+		bc.markRoom(room, registered == 1)
+	}
+
+	// the node may have left the rooms meanwhile
+	for _, room := range bc.registeredRooms() {
+		if _, ok := bc.local.getOccupants(room); !ok {
+			bc.onRoomDeleted(room)
+		}
+	}
+	return nil
+}
+
+// releaseRooms unregisters the rooms of the node when it is closed.
+func (bc *redisBroadcastRemoteV9) releaseRooms() {
+	for _, room := range bc.registeredRooms() {
+		bc.onRoomDeleted(room)
+	}
+}
@@ -0,0 +1,146 @@
+package socketio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+func (bc *redisBroadcastRemoteV9) fetchSockets(ctx context.Context, room string, filter DataFilter) ([]SocketInfo, error) {
+	if bc.nodeCompatible {
+		return bc.nodeFetchSockets(ctx, room, filter)
+	}
+
+	req := fetchSocketsRequest{
+		RequestType: fetchSocketsReqType,
+		RequestID:   newV4UUID(),
+		Room:        room,
+		Filter:      filter,
+	}
+
+	numSub, err := bc.getNumSub(ctx, bc.reqChannel)
+	if err != nil {
+		return bc.local.fetchSockets(room, filter), err
+	}
+
+	state := newClusterRequest(numSub, 0)
+	err = bc.request(ctx, req.RequestID, state, &req)
+	if err != nil && !errors.Is(err, ErrRequestTimeout) {
+		return bc.local.fetchSockets(room, filter), err
+	}
+
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+
+	// the nodes not knowing the filters answer every socket
+	sockets := make([]SocketInfo, 0, len(state.details))
+	for _, socket := range state.details {
+		if filter.match(socket.Data) {
+			sockets = append(sockets, socket)
+		}
+	}
+	return sockets, err
+}
+
+func (bc *redisBroadcastRemoteV9) sendWhere(room string, filter DataFilter, event string, args ...interface{}) {
+	if bc.nodeCompatible {
+		// FIXME: review this concurrent
+		go bc.nodeSendWhere(room, filter, event, args...)
+		return
+	}
+
+	if filter == nil {
+		filter = DataFilter{}
+	}
+
+	// FIXME: review this concurrent
+	go bc.publishMessage(room, filter, event, args...)
+}
+
+// nodeFetchSockets fetches the sockets of the room like the node adapter
+// does, which knows nothing about the filter applied on the answers.
+func (bc *redisBroadcastRemoteV9) nodeFetchSockets(ctx context.Context, room string, filter DataFilter) ([]SocketInfo, error) {
+	opts := &nodeBroadcastOptions{
+		Rooms:  []string{},
+		Except: []string{},
+	}
+	if room != "" {
+		opts.Rooms = append(opts.Rooms, room)
+	}
+
+	req, err := bc.nodeRequest(ctx, nodeRequest{
+		Type: nodeRemoteFetchReqType,
+		Opts: opts,
+	})
+	if err != nil && !errors.Is(err, ErrRequestTimeout) {
+		return bc.local.fetchSockets(room, filter), err
+	}
+
+	req.mutex.Lock()
+	defer req.mutex.Unlock()
+
+	sockets := make([]SocketInfo, 0, len(req.details))
+	for _, socket := range req.details {
+		if filter.match(socket.Data) {
+			sockets = append(sockets, socket)
+		}
+	}
+	return sockets, err
+}
+
+// nodeSendWhere broadcasts to the room of the id of every matching socket, as
+// the node adapter does not filter the broadcasts.
+func (bc *redisBroadcastRemoteV9) nodeSendWhere(room string, filter DataFilter, event string, args ...interface{}) {
+	ctx, cancel := bc.requestContext(context.Background())
+	defer cancel()
+
+	sockets, _ := bc.nodeFetchSockets(ctx, room, filter)
+	for _, socket := range sockets {
+		if _, ok := bc.nodeSocket(socket.ID); ok {
+			continue // sent by the local broadcast
+		}
+		bc.publishNodeMessage(socket.ID, event, args...)
+	}
+}
+
+// nodeSocketInfo converts the details of a socket answered to a fetch request
+func nodeSocketInfo(socket interface{}) (SocketInfo, bool) {
+	details, ok := socket.(nodeSocketDetails)
+	if !ok {
+		b, err := json.Marshal(socket)
+		if err != nil {
+			return SocketInfo{}, false
+		}
+		if err := json.Unmarshal(b, &details); err != nil {
+			return SocketInfo{}, false
+		}
+	}
+
+	// the local answers hold the values of the sockets, the others are
+	// decoded
+	var data map[string]interface{}
+	switch values := details.Data.(type) {
+	case SocketValues:
+		data = values
+	case map[string]interface{}:
+		data = values
+	}
+	return SocketInfo{
+		ID:    details.ID,
+		Rooms: details.Rooms,
+		Data:  data,
+	}, details.ID != ""
+}
+
+type fetchSocketsRequest struct {
+	RequestType string
+	RequestID   string
+	Room        string
+	Filter      DataFilter
+}
+
+type fetchSocketsResponse struct {
+	RequestType string
+	RequestID   string
+	Sockets     []SocketInfo
+}
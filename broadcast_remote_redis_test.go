@@ -0,0 +1,478 @@
+package socketio
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os/exec"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	redis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// redisTestServer is a redis server started for a test, a redis-server
+// process when it is installed and an in-process miniredis otherwise.
+type redisTestServer struct {
+	t    *testing.T
+	port int
+	cmd  *exec.Cmd
+	mini *miniredis.Miniredis
+}
+
+func newRedisTestServer(t *testing.T) *redisTestServer {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := l.Addr().(*net.TCPAddr).Port
+	require.NoError(t, l.Close())
+
+	srv := &redisTestServer{t: t, port: port}
+	srv.start()
+	t.Cleanup(srv.stop)
+
+	return srv
+}
+
+func (s *redisTestServer) addr() string {
+	return fmt.Sprintf("127.0.0.1:%d", s.port)
+}
+
+// start starts the server, without the data of a previous run.
+func (s *redisTestServer) start() {
+	if _, err := exec.LookPath("redis-server"); err != nil {
+		s.mini = miniredis.NewMiniRedis()
+		require.NoError(s.t, s.mini.StartAddr(s.addr()))
+		return
+	}
+
+	s.cmd = exec.Command("redis-server",
+		"--port", strconv.Itoa(s.port),
+		"--bind", "127.0.0.1",
+		"--save", "",
+		"--appendonly", "no",
+	)
+	require.NoError(s.t, s.cmd.Start())
+
+	require.Eventually(s.t, func() bool {
+		conn, err := net.Dial("tcp", s.addr())
+		if err != nil {
+			return false
+		}
+		_ = conn.Close()
+		return true
+	}, 5*time.Second, 10*time.Millisecond, "redis-server did not start")
+}
+
+func (s *redisTestServer) stop() {
+	if s.mini != nil {
+		s.mini.Close()
+		s.mini = nil
+	}
+	if s.cmd == nil {
+		return
+	}
+	_ = s.cmd.Process.Kill()
+	_ = s.cmd.Wait()
+	s.cmd = nil
+}
+
+func newRedisTestRemote(nsp string) *redisBroadcastRemoteV9 {
+	return &redisBroadcastRemoteV9{
+		local:    newBroadcastLocal(nsp),
+		requests: make(map[string]*clusterRequest),
+	}
+}
+
+func TestClusterRequestAnswers(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	bc := newRedisTestRemote("")
+	req := newClusterRequest(10, 0)
+	bc.addRequest("id", req)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			res, err := json.Marshal(roomLenResponse{
+				RequestType: roomLenReqType,
+				RequestID:   "id",
+				Connections: 2,
+			})
+			if err != nil {
+				errs <- err
+				return
+			}
+			bc.onResponse(res)
+		}()
+	}
+	wg.Wait()
+
+	close(errs)
+	for err := range errs {
+		must.NoError(err)
+	}
+
+	must.NoError(req.wait(context.Background()))
+	should.Equal(20, req.connections)
+
+	// late answers are ignored
+	bc.onResponse([]byte(`{"RequestType":"0","RequestID":"id","Connections":1}`))
+	should.Equal(20, req.connections)
+
+	// malformed answers do not panic
+	bc.onResponse([]byte(`{"RequestType":"0"}`))
+	bc.onResponse([]byte(`{"RequestType":"2","RequestID":"id","Rooms":[1]}`))
+}
+
+func TestClusterRequestTimeout(t *testing.T) {
+	should := assert.New(t)
+
+	bc := newRedisTestRemote("")
+	req := newClusterRequest(3, 0)
+	bc.addRequest("id", req)
+
+	bc.onResponse([]byte(`{"RequestType":"2","RequestID":"id","Rooms":["a","b"]}`))
+	bc.onResponse([]byte(`{"RequestType":"2","RequestID":"id","Rooms":["b","c"]}`))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := req.wait(ctx)
+	should.ErrorIs(err, ErrRequestTimeout)
+	should.Contains(err.Error(), "2 of 3")
+	should.Len(req.rooms, 3)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	should.ErrorIs(newClusterRequest(1, 0).wait(ctx), context.Canceled)
+
+	should.NoError(newClusterRequest(1, 1).wait(context.Background()))
+}
+
+func TestRedisOnMessageMalformed(t *testing.T) {
+	should := assert.New(t)
+
+	bc := newRedisTestRemote("")
+	bc.key = "socket.io##uid"
+
+	should.Error(bc.onMessage(bc.key, []byte(`not json`)))
+	should.Error(bc.onMessage(bc.key, []byte(`{"opts":[],"args":[]}`)))
+	should.Error(bc.onMessage(bc.key, []byte(`{"opts":[1,"event"],"args":[]}`)))
+	should.Error(bc.onMessage(bc.key, []byte(`{"opts":[2,"","event",{}],"args":[]}`)))
+	should.Error(bc.onMessage(bc.key, []byte(`{"opts":[1,"","event","filter"],"args":[]}`)))
+}
+
+func TestRedisOnFilteredMessage(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	bc := newRedisTestRemote("")
+	red := newBusTestConn("red")
+	red.Data().Set("team", "red")
+	blue := newBusTestConn("blue")
+	bc.local.join("room", red)
+	bc.local.join("room", blue)
+
+	// the version comes first, for the nodes not knowing the filters to
+	// reject the broadcast rather than sending it to every connection
+	must.NoError(bc.onMessage("socket.io##other", []byte(`{"opts":[1,"room","event",{"team":"red"}],"args":["payload"]}`)))
+	select {
+	case got := <-red.events:
+		should.Equal([]interface{}{"event", "payload"}, got)
+	case <-time.After(time.Second):
+		t.Fatal("red did not receive the broadcast")
+	}
+	select {
+	case got := <-blue.events:
+		t.Fatalf("unexpected event %v", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestRedisAdapterReconnect(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	redisSrv := newRedisTestServer(t)
+
+	servers := make([]*Server, 2)
+	for i := range servers {
+		servers[i] = NewServer(nil)
+		ok, err := servers[i].Adapter(&RedisAdapterConfig{
+			Addr:              redisSrv.addr(),
+			ReconnectDelay:    10 * time.Millisecond,
+			ReconnectDelayMax: 100 * time.Millisecond,
+		})
+		must.NoError(err)
+		must.True(ok)
+
+		servers[i].OnConnect("/", func(Conn, map[string]interface{}) error {
+			return nil
+		})
+		defer servers[i].Close()
+
+		must.Equal(AdapterHealthy, servers[i].AdapterHealth("/"))
+	}
+
+	c := newBusTestConn("c")
+	must.True(servers[1].JoinRoom("/", "room", c))
+
+	expectBroadcast := func(event string) {
+		t.Helper()
+
+		// the subscription of the other node may not be active yet
+		must.Eventually(func() bool {
+			must.True(servers[0].BroadcastToRoom("/", "room", event))
+			for {
+				select {
+				case got := <-c.events:
+					// skip the late deliveries of the previous attempts
+					if got[0] == event {
+						return true
+					}
+				case <-time.After(50 * time.Millisecond):
+					return false
+				}
+			}
+		}, 5*time.Second, time.Millisecond, "%s was not delivered", event)
+	}
+
+	expectBroadcast("before")
+
+	// a malformed message does not stop the subscription
+	cli := redis.NewClient(&redis.Options{Addr: redisSrv.addr()})
+	must.NoError(cli.Publish(context.Background(), "socket.io##other", "{").Err())
+	must.NoError(cli.Close())
+	expectBroadcast("after malformed")
+
+	redisSrv.stop()
+	for _, srv := range servers {
+		srv := srv
+		must.Eventually(func() bool {
+			return srv.AdapterHealth("/") == AdapterReconnecting
+		}, 5*time.Second, 10*time.Millisecond)
+	}
+
+	redisSrv.start()
+	for _, srv := range servers {
+		srv := srv
+		must.Eventually(func() bool {
+			return srv.AdapterHealth("/") == AdapterHealthy
+		}, 5*time.Second, 10*time.Millisecond)
+	}
+	expectBroadcast("after reconnect")
+
+	n, err := servers[0].RoomLenContext(context.Background(), "/", "room")
+	must.NoError(err)
+	should.Equal(1, n)
+
+	must.NoError(servers[0].Close())
+	should.Equal(AdapterClosed, servers[0].AdapterHealth("/"))
+}
+
+func TestRedisAdapterSharedClient(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	redisSrv := newRedisTestServer(t)
+
+	cli := redis.NewClient(&redis.Options{Addr: redisSrv.addr()})
+	defer cli.Close()
+
+	srv := NewServer(nil)
+	ok, err := srv.Adapter(&RedisAdapterConfig{Client: cli})
+	must.NoError(err)
+	must.True(ok)
+
+	srv.OnConnect("/", func(Conn, map[string]interface{}) error {
+		return nil
+	})
+	must.True(srv.JoinRoom("/", "room", newBusTestConn("c")))
+
+	n, err := srv.RoomLenContext(context.Background(), "/", "room")
+	must.NoError(err)
+	should.Equal(1, n)
+
+	// the subscription has its own connection, the pool of the client is left
+	// to the application, which can still use it after the server is closed
+	must.NoError(srv.Close())
+	should.NoError(cli.Ping(context.Background()).Err())
+}
+
+func TestRedisAdapterPresence(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	redisSrv := newRedisTestServer(t)
+
+	servers := make([]*Server, 2)
+	recorders := make([]presenceRecorder, len(servers))
+	for i := range servers {
+		servers[i] = NewServer(nil)
+		ok, err := servers[i].Adapter(&RedisAdapterConfig{
+			Addr:        redisSrv.addr(),
+			PresenceTTL: 300 * time.Millisecond,
+		})
+		must.NoError(err)
+		must.True(ok)
+
+		servers[i].OnConnect("/", func(Conn, map[string]interface{}) error {
+			return nil
+		})
+		servers[i].OnPresence("/", recorders[i].handle)
+		defer servers[i].Close()
+	}
+
+	c1 := newBusTestConn("c1")
+	c2 := newBusTestConn("c2")
+	must.True(servers[0].BindUser("/", "alice", c1))
+	must.True(servers[1].BindUser("/", "alice", c2))
+
+	sockets := servers[1].UserSockets("/", "alice")
+	sort.Strings(sockets)
+	should.Equal([]string{"c1", "c2"}, sockets)
+
+	must.True(servers[1].UnbindUser("/", c2))
+	should.Equal([]string{"c1"}, servers[1].UserSockets("/", "alice"))
+
+	// the bindings are refreshed beyond the TTL
+	time.Sleep(500 * time.Millisecond)
+	should.True(servers[1].IsOnline("/", "alice"))
+
+	// a node which crashed stops refreshing its bindings, which expire
+	crashed := servers[0].getNamespaceHandler("/").broadcast.(*broadcastRemote).remote.(*redisBroadcastRemoteV9)
+	close(crashed.quit)
+
+	must.Eventually(func() bool {
+		return !servers[1].IsOnline("/", "alice")
+	}, 2*time.Second, 10*time.Millisecond)
+
+	must.Eventually(func() bool {
+		events := recorders[1].get()
+		return len(events) == 2 && events[1] == "alice offline"
+	}, 2*time.Second, 10*time.Millisecond)
+	should.Equal([]string{"alice online", "alice offline"}, recorders[1].get())
+}
+
+func TestRedisAdapterRoomData(t *testing.T) {
+	must := require.New(t)
+
+	redisSrv := newRedisTestServer(t)
+
+	servers := make([]*Server, 2)
+	for i := range servers {
+		servers[i] = NewServer(nil)
+		ok, err := servers[i].Adapter(&RedisAdapterConfig{Addr: redisSrv.addr()})
+		must.NoError(err)
+		must.True(ok)
+
+		servers[i].OnConnect("/", func(Conn, map[string]interface{}) error {
+			return nil
+		})
+		defer servers[i].Close()
+	}
+
+	testRoomData(t, servers)
+}
+
+func TestRedisAdapterSocketData(t *testing.T) {
+	for _, nodeCompatible := range []bool{false, true} {
+		t.Run(fmt.Sprintf("node compatible %v", nodeCompatible), func(t *testing.T) {
+			must := require.New(t)
+
+			redisSrv := newRedisTestServer(t)
+
+			servers := make([]*Server, 2)
+			for i := range servers {
+				servers[i] = NewServer(nil)
+				ok, err := servers[i].Adapter(&RedisAdapterConfig{
+					Addr:           redisSrv.addr(),
+					NodeCompatible: nodeCompatible,
+				})
+				must.NoError(err)
+				must.True(ok)
+
+				servers[i].OnConnect("/", func(Conn, map[string]interface{}) error {
+					return nil
+				})
+				defer servers[i].Close()
+			}
+
+			testSocketData(t, servers)
+		})
+	}
+}
+
+func TestRedisAdapterRoomRegistrations(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	redisSrv := newRedisTestServer(t)
+
+	servers := make([]*Server, 2)
+	nodes := make([]*redisBroadcastRemoteV9, len(servers))
+	for i := range servers {
+		servers[i] = NewServer(nil)
+		ok, err := servers[i].Adapter(&RedisAdapterConfig{Addr: redisSrv.addr()})
+		must.NoError(err)
+		must.True(ok)
+
+		servers[i].OnConnect("/", func(Conn, map[string]interface{}) error {
+			return nil
+		})
+		defer servers[i].Close()
+
+		nodes[i] = servers[i].getNamespaceHandler("/").broadcast.(*broadcastRemote).remote.(*redisBroadcastRemoteV9)
+	}
+
+	cli := redis.NewClient(&redis.Options{Addr: redisSrv.addr()})
+	defer cli.Close()
+	ctx := context.Background()
+	registrations := func(room string) []string {
+		uids, err := cli.ZRange(ctx, nodes[0].roomKeys(room)[1], 0, -1).Result()
+		must.NoError(err)
+		sort.Strings(uids)
+		return uids
+	}
+
+	c1 := newBusTestConn("c1")
+	c2 := newBusTestConn("c2")
+	must.True(servers[0].JoinRoom("/", c1.id, c1))
+	must.True(servers[0].JoinRoom("/", "room", c1))
+	must.True(servers[1].JoinRoom("/", "room", c2))
+
+	// the rooms without data are not registered
+	must.NoError(nodes[0].refreshRooms(ctx))
+	keys, err := cli.Keys(ctx, "*").Result()
+	must.NoError(err)
+	should.Empty(keys)
+
+	// the node setting the data registers, the others at their refresh
+	must.NoError(servers[0].SetRoomData("/", "room", "data"))
+	should.Equal([]string{nodes[0].local.uid}, registrations("room"))
+
+	must.NoError(nodes[1].refreshRooms(ctx))
+	expected := []string{nodes[0].local.uid, nodes[1].local.uid}
+	sort.Strings(expected)
+	should.Equal(expected, registrations("room"))
+
+	// the data live while a registered node has connections in the room
+	must.True(servers[0].LeaveRoom("/", "room", c1))
+	should.Equal([]string{nodes[1].local.uid}, registrations("room"))
+	var data string
+	_, err = servers[0].RoomData("/", "room", &data)
+	must.NoError(err)
+	should.Equal("data", data)
+}
@@ -1,5 +1,14 @@
 package socketio
 
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
 // RedisAdapterConfig is configuration to create new adapter
 type RedisAdapterConfig struct {
 	Addr     string
@@ -7,17 +16,92 @@ type RedisAdapterConfig struct {
 	Network  string
 	Password string
 	DB       int
+
+	// Username authenticates with a redis 6 ACL user.
+	Username string
+	// TLSConfig enables TLS for the connections to redis.
+	TLSConfig *tls.Config
+
+	// Addrs is the seed list of Cluster nodes, or the Sentinel nodes when
+	// MasterName is set. Addr is used when it is empty.
+	Addrs []string
+	// MasterName is the name of the master monitored by Sentinel.
+	MasterName       string
+	SentinelUsername string
+	SentinelPassword string
+
+	// Client is used instead of a client created from the options above, to
+	// share the pool of the application. The adapter never closes it. The
+	// subscriptions always use their own connection, separate from the pool.
+	Client redis.UniversalClient
+
+	// RequestsTimeout bounds the time waiting for the other nodes to answer
+	// room queries. Defaults to 5 seconds.
+	RequestsTimeout time.Duration
+
+	// NodeCompatible uses the channels and message formats of the Node
+	// @socket.io/redis-adapter, so that Go and Node servers sharing the same
+	// prefix broadcast to each other's sockets.
+	NodeCompatible bool
+
+	// ReconnectDelay is the delay before resubscribing after the subscription
+	// to redis was lost, doubled after every failed attempt up to
+	// ReconnectDelayMax. Defaults to 100 milliseconds and 5 seconds.
+	ReconnectDelay    time.Duration
+	ReconnectDelayMax time.Duration
+
+	// PresenceTTL is the time after which the users bound to the sockets of a
+	// node which stopped refreshing them, e.g. crashed, go offline. The rooms
+	// of such a node, with their data, expire likewise. Defaults to 30 seconds.
+	PresenceTTL time.Duration
 }
 
 func (cfg *RedisAdapterConfig) getAddr() string {
 	return cfg.Addr
 }
 
+func (cfg *RedisAdapterConfig) getAddrs() []string {
+	if len(cfg.Addrs) > 0 {
+		return cfg.Addrs
+	}
+	return []string{cfg.getAddr()}
+}
+
+// universalOptions gives the options of a single node, Sentinel or Cluster
+// client, depending on MasterName and the number of Addrs.
+func (cfg *RedisAdapterConfig) universalOptions() *redis.UniversalOptions {
+	opts := &redis.UniversalOptions{
+		Addrs:            cfg.getAddrs(),
+		DB:               cfg.DB,
+		Username:         cfg.Username,
+		Password:         cfg.Password,
+		SentinelUsername: cfg.SentinelUsername,
+		SentinelPassword: cfg.SentinelPassword,
+		TLSConfig:        cfg.TLSConfig,
+		MasterName:       cfg.MasterName,
+	}
+
+	if network := cfg.Network; network != "" && network != "tcp" {
+		opts.Dialer = func(ctx context.Context, _, addr string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, addr)
+		}
+	}
+
+	return opts
+}
+
 func defaultConfig() *RedisAdapterConfig {
 	return &RedisAdapterConfig{
-		Addr:    "127.0.0.1:6379",
-		Prefix:  "socket.io",
-		Network: "tcp",
+		Addr:            "127.0.0.1:6379",
+		Prefix:          "socket.io",
+		Network:         "tcp",
+		RequestsTimeout: 5 * time.Second,
+
+		ReconnectDelay:    100 * time.Millisecond,
+		ReconnectDelayMax: 5 * time.Second,
+
+		PresenceTTL: 30 * time.Second,
 	}
 }
 
@@ -29,6 +113,10 @@ func GetOptions(opts *RedisAdapterConfig) *RedisAdapterConfig {
 			options.Addr = opts.Addr
 		}
 
+		options.Addrs = opts.Addrs
+		options.MasterName = opts.MasterName
+		options.Client = opts.Client
+
 		if opts.Prefix != "" {
 			options.Prefix = opts.Prefix
 		}
@@ -44,7 +132,44 @@ func GetOptions(opts *RedisAdapterConfig) *RedisAdapterConfig {
 		if len(opts.Password) > 0 {
 			options.Password = opts.Password
 		}
+
+		options.Username = opts.Username
+		options.TLSConfig = opts.TLSConfig
+		options.SentinelUsername = opts.SentinelUsername
+		options.SentinelPassword = opts.SentinelPassword
+
+		if opts.RequestsTimeout > 0 {
+			options.RequestsTimeout = opts.RequestsTimeout
+		}
+
+		options.NodeCompatible = opts.NodeCompatible
+
+		if opts.ReconnectDelay > 0 {
+			options.ReconnectDelay = opts.ReconnectDelay
+		}
+
+		if opts.ReconnectDelayMax > 0 {
+			options.ReconnectDelayMax = opts.ReconnectDelayMax
+		}
+
+		if opts.PresenceTTL > 0 {
+			options.PresenceTTL = opts.PresenceTTL
+		}
 	}
 
 	return options
 }
+
+// BusAdapterConfig is configuration to create new in-process bus adapter
+type BusAdapterConfig struct {
+	// Bus is shared by all the servers which should behave as one cluster.
+	Bus *Bus
+	// Latency delays every message delivered to the other nodes.
+	Latency time.Duration
+	// LossRate is the probability, between 0 and 1, that a broadcast or clear
+	// message is dropped for a given node. Room queries are never dropped.
+	LossRate float64
+	// Seed initializes the source used to decide which messages are lost, so
+	// that a test run can be reproduced.
+	Seed int64
+}
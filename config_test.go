@@ -0,0 +1,50 @@
+package socketio
+
+import (
+	"crypto/tls"
+	"testing"
+
+	redis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisAdapterConfigUniversalOptions(t *testing.T) {
+	should := assert.New(t)
+
+	opts := GetOptions(nil).universalOptions()
+	should.Equal([]string{"127.0.0.1:6379"}, opts.Addrs)
+	should.Nil(opts.Dialer)
+
+	tlsConfig := &tls.Config{ServerName: "redis"}
+	cfg := GetOptions(&RedisAdapterConfig{
+		Addrs:     []string{"10.0.0.1:6379", "10.0.0.2:6379"},
+		Username:  "user",
+		Password:  "secret",
+		TLSConfig: tlsConfig,
+	})
+	opts = cfg.universalOptions()
+	should.Equal([]string{"10.0.0.1:6379", "10.0.0.2:6379"}, opts.Addrs)
+	should.Equal("user", opts.Username)
+	should.Equal("secret", opts.Password)
+	should.Same(tlsConfig, opts.TLSConfig)
+
+	cli := redis.NewUniversalClient(opts)
+	_, ok := cli.(*redis.ClusterClient)
+	should.True(ok, "several addresses use a cluster client")
+	should.NoError(cli.Close())
+
+	opts = GetOptions(&RedisAdapterConfig{
+		Addrs:            []string{"10.0.0.1:26379"},
+		MasterName:       "mymaster",
+		SentinelPassword: "sentinel",
+	}).universalOptions()
+	should.Equal("mymaster", opts.MasterName)
+	should.Equal("sentinel", opts.SentinelPassword)
+
+	opts = GetOptions(&RedisAdapterConfig{
+		Addr:    "/tmp/redis.sock",
+		Network: "unix",
+	}).universalOptions()
+	should.Equal([]string{"/tmp/redis.sock"}, opts.Addrs)
+	should.NotNil(opts.Dialer)
+}
@@ -67,8 +67,15 @@ func (c *conn) Close() error {
 		// for each namespace, leave all rooms, and call the disconnect handler.
 		c.namespaceConns.Range(func(ns string, nc *namespaceConn) {
 			nc.LeaveAll()
+			nc.unbindUser()
+			nc.closeStreams()
 
-			if nh, _ := c.handlers.Get(ns); nh != nil && nh.onDisconnect != nil {
+			nh, _ := c.handlers.Get(ns)
+			if nh == nil {
+				return
+			}
+			nh.removeSocket(nc)
+			if nh.onDisconnect != nil {
 				nh.onDisconnect(nc, clientDisconnectMsg, nil)
 			}
 		})
@@ -127,12 +134,17 @@ func (c *conn) serveWrite() {
 		case <-c.quitChan:
 			return
 		case pkg := <-c.writeChan:
+			encode := c.encoder.Encode
+			if pkg.NoCompress {
+				encode = c.encoder.EncodeUncompressed
+			}
+
 			if len(pkg.Args) > 0 {
-				if err := c.encoder.Encode(pkg.Header, pkg.Args...); err != nil {
+				if err := encode(pkg.Header, pkg.Args...); err != nil {
 					c.onError(pkg.Header.Namespace, err)
 				}
 			} else {
-				if err := c.encoder.Encode(pkg.Header, pkg.Data); err != nil {
+				if err := encode(pkg.Header, pkg.Data); err != nil {
 					c.onError(pkg.Header.Namespace, err)
 				}
 			}
@@ -181,17 +193,20 @@ func (c *conn) serveRead() {
 }
 
 func (c *conn) write(header parser.Header, args ...reflect.Value) {
-	data := make([]interface{}, len(args))
-
-	for i := range data {
-		data[i] = args[i].Interface()
-	}
+	c.writePayload(parser.Payload{
+		Header: header,
+		Data:   interfaces(args),
+	})
+}
 
-	pkg := parser.Payload{
+func (c *conn) writeWithArgs(header parser.Header, args ...reflect.Value) {
+	c.writePayload(parser.Payload{
 		Header: header,
-		Data:   data,
-	}
+		Args:   interfaces(args),
+	})
+}
 
+func (c *conn) writePayload(pkg parser.Payload) {
 	select {
 	case <-c.quitChan:
 		return
@@ -199,23 +214,13 @@ func (c *conn) write(header parser.Header, args ...reflect.Value) {
 	}
 }
 
-func (c *conn) writeWithArgs(header parser.Header, args ...reflect.Value) {
+func interfaces(args []reflect.Value) []interface{} {
 	data := make([]interface{}, len(args))
 
 	for i := range data {
 		data[i] = args[i].Interface()
 	}
-
-	pkg := parser.Payload{
-		Header: header,
-		Args:   data,
-	}
-
-	select {
-	case <-c.quitChan:
-		return
-	case c.writeChan <- pkg:
-	}
+	return data
 }
 
 func (c *conn) onError(namespace string, err error) {
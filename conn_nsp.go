@@ -2,6 +2,7 @@ package socketio
 
 import (
 	"context"
+	"io"
 	"reflect"
 	"sync"
 	"sync/atomic"
@@ -23,6 +24,23 @@ type Namespace interface {
 	Namespace() string
 	Emit(eventName string, v ...interface{})
 
+	// Compress returns an emitter of the connection whose events are
+	// compressed or not by the transport, such as with the permessage-deflate
+	// extension of the websocket transport. It is used with false for the
+	// payloads which are already compressed.
+	Compress(compress bool) Emitter
+
+	// SendStream sends the data of r to the handler of eventName on the
+	// other side, registered as func(Conn, io.ReadCloser). The data are sent
+	// in chunks, the next ones only once the previous ones were read, along
+	// with the other events. Either side can cancel the stream: the sender
+	// with the context of SendStreamContext, the receiver by closing the
+	// reader before its end. Only the peers using this package read the
+	// streams, SendStream blocking against the others until the connection
+	// closes or the context is done.
+	SendStream(eventName string, r io.Reader) error
+	SendStreamContext(ctx context.Context, eventName string, r io.Reader) error
+
 	Join(room string)
 	Leave(room string)
 	LeaveAll()
@@ -37,15 +55,22 @@ type namespaceConn struct {
 
 	namespace string
 	context   context.Context
+	data      *SocketData
 
 	ack sync.Map
+
+	outStreams sync.Map
+	inStreams  sync.Map
 }
 
+var _ DataHolder = &namespaceConn{}
+
 func newNamespaceConn(conn *conn, namespace string, broadcast Broadcaster) *namespaceConn {
 	return &namespaceConn{
 		conn:      conn,
 		namespace: namespace,
 		broadcast: broadcast,
+		data:      newSocketData(),
 	}
 }
 
@@ -57,6 +82,11 @@ func (nc *namespaceConn) Context() context.Context {
 	return nc.context
 }
 
+// Data gives the data of the connection, safe for concurrent use.
+func (nc *namespaceConn) Data() *SocketData {
+	return nc.data
+}
+
 func (nc *namespaceConn) Namespace() string {
 	return nc.namespace
 }
@@ -73,6 +103,13 @@ func (nc *namespaceConn) LeaveAll() {
 	nc.broadcast.LeaveAll(nc)
 }
 
+// unbindUser removes the binding of the connection to its user, if any
+func (nc *namespaceConn) unbindUser() {
+	if presence, ok := nc.broadcast.(presenceTracker); ok {
+		presence.unbindUser(nc)
+	}
+}
+
 func (nc *namespaceConn) Rooms() []string {
 	return nc.broadcast.Rooms(nc)
 }
@@ -99,6 +136,14 @@ func (nc *namespaceConn) nextPkgID() uint64 {
 }
 
 func (nc *namespaceConn) Emit(eventName string, v ...interface{}) {
+	nc.emit(true, eventName, v...)
+}
+
+func (nc *namespaceConn) Compress(compress bool) Emitter {
+	return compressEmitter{nc: nc, compress: compress}
+}
+
+func (nc *namespaceConn) emit(compress bool, eventName string, v ...interface{}) {
 	header := parser.Header{
 		Type: parser.Event,
 	}
@@ -130,5 +175,25 @@ func (nc *namespaceConn) Emit(eventName string, v ...interface{}) {
 		args[i] = reflect.ValueOf(v[i-1])
 	}
 
-	nc.conn.write(header, args...)
+	nc.conn.writePayload(parser.Payload{
+		Header:     header,
+		Data:       interfaces(args),
+		NoCompress: !compress,
+	})
+}
+
+// Emitter emits events.
+type Emitter interface {
+	Emit(eventName string, v ...interface{})
+}
+
+// compressEmitter emits the events of a connection, overriding the
+// compression of the transport
+type compressEmitter struct {
+	nc       *namespaceConn
+	compress bool
+}
+
+func (e compressEmitter) Emit(eventName string, v ...interface{}) {
+	e.nc.emit(e.compress, eventName, v...)
 }
@@ -1,6 +1,7 @@
 package socketio
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 
@@ -51,10 +52,24 @@ func (c *conn) eventPacketHandler(event string, header parser.Header) error {
 		return nil
 	}
 
+	if isStreamEvent(event) {
+		return c.streamPacketHandler(conn, handler, event, header)
+	}
+
 	args, err := c.decoder.DecodeArgs(handler.getEventTypes(event))
 	if err != nil {
-		c.onError(header.Namespace, err)
-		return errDecodeArgs
+		var argsErr *parser.ArgsError
+		if !errors.As(err, &argsErr) {
+			c.onError(header.Namespace, err)
+			return errDecodeArgs
+		}
+		c.rejectEvent(header, newDecodeValidationError(event, argsErr))
+		return nil
+	}
+
+	if validationErr := validateArgs(event, args); validationErr != nil {
+		c.rejectEvent(header, validationErr)
+		return nil
 	}
 
 	ret, err := handler.dispatchEvent(conn, event, args...)
@@ -71,6 +86,17 @@ func (c *conn) eventPacketHandler(event string, header parser.Header) error {
 	return nil
 }
 
+// rejectEvent reports the invalid arguments of an event to OnError, and to
+// the client when it asked for an ack, without dispatching the event
+func (c *conn) rejectEvent(header parser.Header, err *ValidationError) {
+	c.onError(header.Namespace, err)
+
+	if header.NeedAck {
+		header.Type = parser.Ack
+		c.write(header, reflect.ValueOf(err))
+	}
+}
+
 func (c *conn) connectPacketHandler(header parser.Header) error {
 	args, err := c.decoder.DecodeArgs(defaultHeaderType)
 	if err != nil {
@@ -91,8 +117,11 @@ func (c *conn) connectPacketHandler(header parser.Header) error {
 		conn.Join(c.ID())
 	}
 
+	// the connection is found by the handlers of its own events from OnConnect
+	handler.addSocket(conn)
 	_, err = handler.dispatch(conn, header, args...)
 	if err != nil {
+		handler.removeSocket(conn)
 		c.onError(header.Namespace, err)
 		return errHandleDispatch
 	}
@@ -118,6 +147,8 @@ func (c *conn) disconnectPacketHandler(header parser.Header) error {
 	}
 
 	conn.LeaveAll()
+	conn.unbindUser()
+	conn.closeStreams()
 
 	c.namespaceConns.Delete(header.Namespace)
 
@@ -125,6 +156,7 @@ func (c *conn) disconnectPacketHandler(header parser.Header) error {
 	if !ok {
 		return nil
 	}
+	handler.removeSocket(conn)
 
 	_, err = handler.dispatch(conn, header, args...)
 	if err != nil {
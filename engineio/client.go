@@ -1,7 +1,7 @@
 package engineio
 
 import (
-	"fmt"
+	"bytes"
 	"io"
 	"net"
 	"net/http"
@@ -15,6 +15,11 @@ import (
 	"github.com/vchitai/go-socket.io/v4/engineio/transport"
 )
 
+// ErrPayloadTooLarge is returned when closing the writer of a message larger
+// than the max payload of the server, the message being dropped. It is given
+// to the ErrorHandler of the server for the clients sending such payloads.
+var ErrPayloadTooLarge = transport.ErrPayloadTooLarge
+
 // Pauser is connection which can be paused and resumes.
 type Pauser interface {
 	Pause()
@@ -70,10 +75,18 @@ func (c *client) NextReader() (session.FrameType, io.ReadCloser, error) {
 		}
 
 		switch pt {
-		case packet.PONG:
+		case packet.PING:
+			_ = r.Close()
+
+			// the server checks the connection is alive, and pings again
+			// after the ping interval
+			if err = c.pong(); err != nil {
+				return 0, nil, err
+			}
 			if err = c.conn.SetReadDeadline(time.Now().Add(c.params.PingInterval + c.params.PingTimeout)); err != nil {
 				return 0, nil, err
 			}
+			continue
 
 		case packet.CLOSE:
 			_ = c.Close()
@@ -87,8 +100,67 @@ func (c *client) NextReader() (session.FrameType, io.ReadCloser, error) {
 	}
 }
 
+func (c *client) pong() error {
+	w, err := c.conn.NextWriter(frame.String, packet.PONG)
+	if err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// NextWriter returns the writer of a message. The messages larger than the
+// max payload of the server are dropped, closing the writer returns
+// ErrPayloadTooLarge.
 func (c *client) NextWriter(typ session.FrameType) (io.WriteCloser, error) {
-	return c.conn.NextWriter(frame.Type(typ), packet.MESSAGE)
+	if c.params.MaxPayload <= 0 {
+		return c.conn.NextWriter(frame.Type(typ), packet.MESSAGE)
+	}
+
+	return &messageWriter{
+		conn:       c.conn,
+		ft:         frame.Type(typ),
+		maxPayload: c.params.MaxPayload,
+		compress:   true,
+	}, nil
+}
+
+// messageWriter buffers a message, so that it is only sent when it fits in
+// the max payload
+type messageWriter struct {
+	conn       transport.Conn
+	ft         frame.Type
+	maxPayload int
+	compress   bool
+	buf        bytes.Buffer
+}
+
+// SetCompress disables or enables the compression of the message by the
+// transport.
+func (w *messageWriter) SetCompress(compress bool) {
+	w.compress = compress
+}
+
+func (w *messageWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *messageWriter) Close() error {
+	if w.buf.Len() > w.maxPayload {
+		return ErrPayloadTooLarge
+	}
+
+	cw, err := w.conn.NextWriter(w.ft, packet.MESSAGE)
+	if err != nil {
+		return err
+	}
+	if c, ok := cw.(transport.Compressor); ok && !w.compress {
+		c.SetCompress(false)
+	}
+	if _, err = w.buf.WriteTo(cw); err != nil {
+		_ = cw.Close()
+		return err
+	}
+	return cw.Close()
 }
 
 func (c *client) URL() url.URL {
@@ -106,30 +178,3 @@ func (c *client) RemoteAddr() net.Addr {
 func (c *client) RemoteHeader() http.Header {
 	return c.conn.RemoteHeader()
 }
-
-func (c *client) serve() {
-	defer func() {
-		_ = c.conn.Close()
-	}()
-
-	for {
-		select {
-		case <-c.close:
-			return
-		case <-time.After(c.params.PingInterval):
-		}
-
-		w, err := c.conn.NextWriter(frame.String, packet.PING)
-		if err != nil {
-			return
-		}
-
-		if err := w.Close(); err != nil {
-			return
-		}
-
-		if err = c.conn.SetWriteDeadline(time.Now().Add(c.params.PingInterval + c.params.PingTimeout)); err != nil {
-			fmt.Printf("set writer's deadline error,msg:%s\n", err.Error())
-		}
-	}
-}
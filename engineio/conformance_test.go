@@ -0,0 +1,650 @@
+package engineio
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vchitai/go-socket.io/v4/engineio/session"
+	"github.com/vchitai/go-socket.io/v4/engineio/transport"
+	"github.com/vchitai/go-socket.io/v4/engineio/transport/polling"
+)
+
+// The conformance tests check the server speaks the Engine.IO v4 protocol to
+// raw HTTP and websocket clients, as the reference test suite of the protocol
+// does.
+
+const (
+	conformancePingInterval = 300 * time.Millisecond
+	conformancePingTimeout  = 200 * time.Millisecond
+)
+
+type conformanceHandshake struct {
+	SID          string   `json:"sid"`
+	Upgrades     []string `json:"upgrades"`
+	PingInterval int      `json:"pingInterval"`
+	PingTimeout  int      `json:"pingTimeout"`
+	MaxPayload   int      `json:"maxPayload"`
+}
+
+func newConformanceServer(t *testing.T) (*Server, string) {
+	return newConformanceServerOptions(t, &Options{})
+}
+
+// newConformanceServerOptions serves a server of opts, using the ping
+// interval and timeout of the conformance tests
+func newConformanceServerOptions(t *testing.T, opts *Options) (*Server, string) {
+	opts.PingInterval = conformancePingInterval
+	opts.PingTimeout = conformancePingTimeout
+
+	svr := NewServer(opts)
+	httpSvr := httptest.NewServer(svr)
+
+	t.Cleanup(func() {
+		_ = svr.Close()
+		httpSvr.CloseClientConnections()
+		httpSvr.Close()
+	})
+	return svr, httpSvr.URL
+}
+
+func conformanceRequest(t *testing.T, method, url, body string) (int, string) {
+	status, respBody, err := doConformanceRequest(method, url, body)
+	require.NoError(t, err)
+	return status, respBody
+}
+
+// doConformanceRequest sends a request outside of the test goroutine
+func doConformanceRequest(method, url, body string) (int, string, error) {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	if method == http.MethodPost {
+		req.Header.Set("Content-Type", "text/plain;charset=UTF-8")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, "", err
+	}
+	return resp.StatusCode, string(b), nil
+}
+
+func pollingHandshake(t *testing.T, url string) conformanceHandshake {
+	status, body := conformanceRequest(t, http.MethodGet, url+"/?EIO=4&transport=polling", "")
+	require.Equal(t, http.StatusOK, status, body)
+	require.True(t, strings.HasPrefix(body, "0"), body)
+
+	var handshake conformanceHandshake
+	require.NoError(t, json.Unmarshal([]byte(body[1:]), &handshake))
+	return handshake
+}
+
+func pollingURL(url, sid string) string {
+	return url + "/?EIO=4&transport=polling&sid=" + sid
+}
+
+func websocketURL(url, sid string) string {
+	u := strings.Replace(url, "http", "ws", 1) + "/?EIO=4&transport=websocket"
+	if sid != "" {
+		u += "&sid=" + sid
+	}
+	return u
+}
+
+func acceptConn(t *testing.T, svr *Server) Conn {
+	conns := make(chan Conn, 1)
+	go func() {
+		conn, err := svr.Accept()
+		if err == nil {
+			conns <- conn
+		}
+	}()
+
+	select {
+	case conn := <-conns:
+		return conn
+	case <-time.After(time.Second):
+		t.Fatal("no connection accepted")
+		return nil
+	}
+}
+
+func readMessage(t *testing.T, conn Conn) (session.FrameType, string) {
+	ft, r, err := conn.NextReader()
+	require.NoError(t, err)
+
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	return ft, string(b)
+}
+
+func writeMessage(t *testing.T, conn Conn, ft session.FrameType, data string) {
+	require.NoError(t, <-writeMessageAsync(conn, ft, data))
+}
+
+// writeMessageAsync writes a message in another goroutine, such as while the
+// test polls it
+func writeMessageAsync(conn Conn, ft session.FrameType, data string) <-chan error {
+	errs := make(chan error, 1)
+	go func() {
+		w, err := conn.NextWriter(ft)
+		if err != nil {
+			errs <- err
+			return
+		}
+
+		if _, err = io.WriteString(w, data); err != nil {
+			_ = w.Close()
+			errs <- err
+			return
+		}
+		errs <- w.Close()
+	}()
+	return errs
+}
+
+func TestConformancePollingHandshake(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	_, url := newConformanceServer(t)
+
+	resp, err := http.Get(url + "/?EIO=4&transport=polling")
+	must.NoError(err)
+	body, err := io.ReadAll(resp.Body)
+	must.NoError(err)
+	must.NoError(resp.Body.Close())
+
+	must.Equal(http.StatusOK, resp.StatusCode)
+	should.Equal("text/plain; charset=UTF-8", resp.Header.Get("Content-Type"))
+	must.Equal(byte('0'), body[0])
+
+	var handshake conformanceHandshake
+	must.NoError(json.Unmarshal(body[1:], &handshake))
+	should.NotEmpty(handshake.SID)
+	should.Equal([]string{"websocket"}, handshake.Upgrades)
+	should.Equal(300, handshake.PingInterval)
+	should.Equal(200, handshake.PingTimeout)
+	should.Equal(1000000, handshake.MaxPayload)
+}
+
+func TestConformanceWebsocketHandshake(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	_, url := newConformanceServer(t)
+
+	ws, _, err := websocket.DefaultDialer.Dial(websocketURL(url, ""), nil)
+	must.NoError(err)
+	defer ws.Close()
+
+	typ, data, err := ws.ReadMessage()
+	must.NoError(err)
+	must.Equal(websocket.TextMessage, typ)
+	must.Equal(byte('0'), data[0])
+
+	var handshake conformanceHandshake
+	must.NoError(json.Unmarshal(data[1:], &handshake))
+	should.NotEmpty(handshake.SID)
+	should.Empty(handshake.Upgrades)
+	should.Equal(1000000, handshake.MaxPayload)
+}
+
+func TestConformanceBadRequests(t *testing.T) {
+	_, url := newConformanceServer(t)
+	handshake := pollingHandshake(t, url)
+
+	tests := []struct {
+		name   string
+		method string
+		query  string
+		body   string
+	}{
+		{"missing version", http.MethodGet, "/?transport=polling", `{"code":5,"message":"Unsupported protocol version"}`},
+		{"version 3", http.MethodGet, "/?EIO=3&transport=polling", `{"code":5,"message":"Unsupported protocol version"}`},
+		{"version 5", http.MethodGet, "/?EIO=5&transport=polling", `{"code":5,"message":"Unsupported protocol version"}`},
+		{"unknown transport", http.MethodGet, "/?EIO=4&transport=unknown", `{"code":0,"message":"Transport unknown"}`},
+		{"unknown sid", http.MethodGet, "/?EIO=4&transport=polling&sid=unknown", `{"code":1,"message":"Session ID unknown"}`},
+		{"version 3 with a sid", http.MethodGet, "/?EIO=3&transport=polling&sid=" + handshake.SID, `{"code":5,"message":"Unsupported protocol version"}`},
+		{"handshake method", http.MethodPost, "/?EIO=4&transport=polling", `{"code":2,"message":"Bad handshake method"}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			status, body := conformanceRequest(t, test.method, url+test.query, "")
+			assert.Equal(t, http.StatusBadRequest, status)
+			assert.JSONEq(t, test.body, body)
+		})
+	}
+}
+
+func TestConformancePollingMessages(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	svr, url := newConformanceServer(t)
+	handshake := pollingHandshake(t, url)
+	conn := acceptConn(t, svr)
+	defer conn.Close()
+
+	// the packets of a payload are separated by a record separator, the
+	// binary ones being in base64 with a "b" prefix
+	type response struct {
+		body string
+		err  error
+	}
+	posted := make(chan response, 1)
+	go func() {
+		_, body, err := doConformanceRequest(http.MethodPost, pollingURL(url, handshake.SID), "4hello\x1ebAQID\x1e4world")
+		posted <- response{body, err}
+	}()
+
+	ft, data := readMessage(t, conn)
+	should.Equal(session.TEXT, ft)
+	should.Equal("hello", data)
+
+	ft, data = readMessage(t, conn)
+	should.Equal(session.BINARY, ft)
+	should.Equal("\x01\x02\x03", data)
+
+	ft, data = readMessage(t, conn)
+	should.Equal(session.TEXT, ft)
+	should.Equal("world", data)
+
+	res := <-posted
+	must.NoError(res.err)
+	should.Equal("ok", res.body)
+
+	written := writeMessageAsync(conn, session.TEXT, "hi")
+	status, body := conformanceRequest(t, http.MethodGet, pollingURL(url, handshake.SID), "")
+	must.Equal(http.StatusOK, status)
+	should.Equal("4hi", body)
+	must.NoError(<-written)
+
+	written = writeMessageAsync(conn, session.BINARY, "\x01\x02\x03")
+	status, body = conformanceRequest(t, http.MethodGet, pollingURL(url, handshake.SID), "")
+	must.Equal(http.StatusOK, status)
+	should.Equal("bAQID", body)
+	must.NoError(<-written)
+}
+
+func TestConformanceWebsocketMessages(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	svr, url := newConformanceServer(t)
+
+	ws, _, err := websocket.DefaultDialer.Dial(websocketURL(url, ""), nil)
+	must.NoError(err)
+	defer ws.Close()
+
+	_, _, err = ws.ReadMessage()
+	must.NoError(err)
+	conn := acceptConn(t, svr)
+	defer conn.Close()
+
+	// the binary packets are sent as binary frames, without packet type
+	must.NoError(ws.WriteMessage(websocket.TextMessage, []byte("4hello")))
+	must.NoError(ws.WriteMessage(websocket.BinaryMessage, []byte{1, 2, 3}))
+
+	ft, data := readMessage(t, conn)
+	should.Equal(session.TEXT, ft)
+	should.Equal("hello", data)
+
+	ft, data = readMessage(t, conn)
+	should.Equal(session.BINARY, ft)
+	should.Equal("\x01\x02\x03", data)
+
+	writeMessage(t, conn, session.TEXT, "hi")
+	typ, msg, err := ws.ReadMessage()
+	must.NoError(err)
+	should.Equal(websocket.TextMessage, typ)
+	should.Equal("4hi", string(msg))
+
+	writeMessage(t, conn, session.BINARY, "\x01\x02\x03")
+	typ, msg, err = ws.ReadMessage()
+	must.NoError(err)
+	should.Equal(websocket.BinaryMessage, typ)
+	should.Equal([]byte{1, 2, 3}, msg)
+}
+
+func TestConformancePollingHeartbeat(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	svr, url := newConformanceServer(t)
+	handshake := pollingHandshake(t, url)
+	conn := acceptConn(t, svr)
+	defer conn.Close()
+
+	go func() {
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	// the server pings, the client answers
+	for i := 0; i < 2; i++ {
+		start := time.Now()
+		status, body := conformanceRequest(t, http.MethodGet, pollingURL(url, handshake.SID), "")
+		must.Equal(http.StatusOK, status)
+		should.Equal("2", body)
+		should.Less(time.Since(start), conformancePingInterval+conformancePingTimeout)
+
+		status, _ = conformanceRequest(t, http.MethodPost, pollingURL(url, handshake.SID), "3")
+		must.Equal(http.StatusOK, status)
+	}
+
+	// the session is closed when the pong is late
+	status, body := conformanceRequest(t, http.MethodGet, pollingURL(url, handshake.SID), "")
+	must.Equal(http.StatusOK, status)
+	should.Equal("2", body)
+
+	select {
+	case <-conn.Done():
+	case <-time.After(2 * conformancePingTimeout):
+		t.Fatal("session not closed")
+	}
+}
+
+func TestConformanceWebsocketHeartbeat(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	svr, url := newConformanceServer(t)
+
+	ws, _, err := websocket.DefaultDialer.Dial(websocketURL(url, ""), nil)
+	must.NoError(err)
+	defer ws.Close()
+
+	_, _, err = ws.ReadMessage()
+	must.NoError(err)
+	conn := acceptConn(t, svr)
+	defer conn.Close()
+
+	go func() {
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < 2; i++ {
+		_, msg, err := ws.ReadMessage()
+		must.NoError(err)
+		should.Equal("2", string(msg))
+
+		must.NoError(ws.WriteMessage(websocket.TextMessage, []byte("3")))
+	}
+
+	// without pong the server closes the connection
+	_, msg, err := ws.ReadMessage()
+	must.NoError(err)
+	should.Equal("2", string(msg))
+
+	must.NoError(ws.SetReadDeadline(time.Now().Add(2 * conformancePingTimeout)))
+	_, _, err = ws.ReadMessage()
+	should.True(websocket.IsUnexpectedCloseError(err), err)
+}
+
+func TestConformanceUpgrade(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	svr, url := newConformanceServer(t)
+	handshake := pollingHandshake(t, url)
+	conn := acceptConn(t, svr)
+	defer conn.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial(websocketURL(url, handshake.SID), nil)
+	must.NoError(err)
+	defer ws.Close()
+
+	must.NoError(ws.WriteMessage(websocket.TextMessage, []byte("2probe")))
+	_, msg, err := ws.ReadMessage()
+	must.NoError(err)
+	should.Equal("3probe", string(msg))
+
+	// the pending poll is answered with a noop
+	status, body := conformanceRequest(t, http.MethodGet, pollingURL(url, handshake.SID), "")
+	must.Equal(http.StatusOK, status)
+	should.Equal("6", body)
+
+	must.NoError(ws.WriteMessage(websocket.TextMessage, []byte("5")))
+
+	must.NoError(ws.WriteMessage(websocket.TextMessage, []byte("4hello")))
+	_, data := readMessage(t, conn)
+	should.Equal("hello", data)
+
+	writeMessage(t, conn, session.TEXT, "hi")
+	_, msg, err = ws.ReadMessage()
+	must.NoError(err)
+	should.Equal("4hi", string(msg))
+
+	// the session does not go back to polling
+	status, _ = conformanceRequest(t, http.MethodGet, pollingURL(url, handshake.SID), "")
+	should.Equal(http.StatusBadRequest, status)
+}
+
+func TestConformanceUpgradeTimeout(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	events := make(chan session.UpgradeEvent, 4)
+	svr, url := newConformanceServerOptions(t, &Options{
+		UpgradeTimeout: 100 * time.Millisecond,
+		UpgradeHandler: func(_ Conn, event session.UpgradeEvent) {
+			events <- event
+		},
+	})
+	handshake := pollingHandshake(t, url)
+	conn := acceptConn(t, svr)
+	defer conn.Close()
+
+	// the probe is answered, but the upgrade packet is late
+	ws, _, err := websocket.DefaultDialer.Dial(websocketURL(url, handshake.SID), nil)
+	must.NoError(err)
+	defer ws.Close()
+
+	must.NoError(ws.WriteMessage(websocket.TextMessage, []byte("2probe")))
+	_, msg, err := ws.ReadMessage()
+	must.NoError(err)
+	should.Equal("3probe", string(msg))
+
+	should.Equal(session.UpgradeEvent{Type: session.UpgradeStart, From: "polling", To: "websocket"}, <-events)
+
+	select {
+	case event := <-events:
+		should.Equal(session.UpgradeFailure, event.Type)
+		should.ErrorIs(event.Err, session.ErrUpgradeTimeout)
+	case <-time.After(time.Second):
+		t.Fatal("upgrade not timed out")
+	}
+
+	// the session keeps polling
+	must.NoError(ws.SetReadDeadline(time.Now().Add(time.Second)))
+	_, _, err = ws.ReadMessage()
+	should.Error(err)
+
+	written := writeMessageAsync(conn, session.TEXT, "hi")
+	status, body := conformanceRequest(t, http.MethodGet, pollingURL(url, handshake.SID), "")
+	must.Equal(http.StatusOK, status)
+	should.Equal("4hi", body)
+	must.NoError(<-written)
+
+	// the next upgrade succeeds
+	ws, _, err = websocket.DefaultDialer.Dial(websocketURL(url, handshake.SID), nil)
+	must.NoError(err)
+	defer ws.Close()
+
+	must.NoError(ws.WriteMessage(websocket.TextMessage, []byte("2probe")))
+	_, _, err = ws.ReadMessage()
+	must.NoError(err)
+	must.NoError(ws.WriteMessage(websocket.TextMessage, []byte("5")))
+
+	should.Equal(session.UpgradeStart, (<-events).Type)
+	should.Equal(session.UpgradeEvent{Type: session.UpgradeSuccess, From: "polling", To: "websocket"}, <-events)
+}
+
+func TestConformanceUpgradesNotAllowed(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	allowUpgrades := false
+	svr, url := newConformanceServerOptions(t, &Options{AllowUpgrades: &allowUpgrades})
+	handshake := pollingHandshake(t, url)
+	conn := acceptConn(t, svr)
+	defer conn.Close()
+
+	should.Empty(handshake.Upgrades)
+
+	_, resp, err := websocket.DefaultDialer.Dial(websocketURL(url, handshake.SID), nil)
+	must.Error(err)
+	should.Equal(http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestConformanceClose(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	svr, url := newConformanceServer(t)
+	handshake := pollingHandshake(t, url)
+	conn := acceptConn(t, svr)
+
+	errs := make(chan error, 1)
+	go func() {
+		_, _, err := conn.NextReader()
+		errs <- err
+	}()
+
+	status, _ := conformanceRequest(t, http.MethodPost, pollingURL(url, handshake.SID), "1")
+	must.Equal(http.StatusOK, status)
+	should.Equal(io.EOF, <-errs)
+}
+
+func TestConformanceClientPong(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	svr, url := newConformanceServer(t)
+
+	dialer := Dialer{
+		Transports: []transport.Transport{polling.Default},
+	}
+	client, err := dialer.Dial(url, nil)
+	must.NoError(err)
+	defer client.Close()
+
+	conn := acceptConn(t, svr)
+	defer conn.Close()
+
+	// the client answers the pings while reading
+	go func() {
+		for {
+			if _, _, err := client.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+	go func() {
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	time.Sleep(3 * (conformancePingInterval + conformancePingTimeout))
+
+	select {
+	case <-conn.Done():
+		t.Fatal("session closed")
+	default:
+	}
+
+	// the messages larger than the max payload are not sent
+	w, err := client.NextWriter(session.TEXT)
+	must.NoError(err)
+	_, err = w.Write(make([]byte, 1000001))
+	must.NoError(err)
+	should.ErrorIs(w.Close(), ErrPayloadTooLarge)
+}
+
+func TestConformanceMaxPayload(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	errs := make(chan error, 2)
+	svr, url := newConformanceServerOptions(t, &Options{
+		MaxPayload: 100,
+		ErrorHandler: func(_ Conn, err error) {
+			errs <- err
+		},
+	})
+
+	readUntilClosed := func(conn Conn) {
+		go func() {
+			for {
+				if _, _, err := conn.NextReader(); err != nil {
+					return
+				}
+			}
+		}()
+	}
+	waitClosed := func(conn Conn) {
+		select {
+		case err := <-errs:
+			should.ErrorIs(err, ErrPayloadTooLarge)
+		case <-time.After(time.Second):
+			t.Fatal("error not handled")
+		}
+		select {
+		case <-conn.Done():
+		case <-time.After(time.Second):
+			t.Fatal("session not closed")
+		}
+	}
+
+	// the polling requests are rejected with a 413
+	handshake := pollingHandshake(t, url)
+	must.Equal(100, handshake.MaxPayload)
+	conn := acceptConn(t, svr)
+	readUntilClosed(conn)
+
+	status, _ := conformanceRequest(t, http.MethodPost, pollingURL(url, handshake.SID), "4"+strings.Repeat("a", 100))
+	should.Equal(http.StatusRequestEntityTooLarge, status)
+	waitClosed(conn)
+
+	// the websocket messages close the connection
+	ws, _, err := websocket.DefaultDialer.Dial(websocketURL(url, ""), nil)
+	must.NoError(err)
+	defer ws.Close()
+	_, _, err = ws.ReadMessage()
+	must.NoError(err)
+	conn = acceptConn(t, svr)
+	readUntilClosed(conn)
+
+	must.NoError(ws.WriteMessage(websocket.TextMessage, []byte("4hello")))
+	must.NoError(ws.WriteMessage(websocket.TextMessage, []byte("4"+strings.Repeat("a", 100))))
+	waitClosed(conn)
+}
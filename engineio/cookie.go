@@ -0,0 +1,114 @@
+package engineio
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+// defaultCookieName is the name of the cookie of the sessions by default.
+const defaultCookieName = "io"
+
+// CookieOptions is the cookie holding the id of the sessions, set on the
+// response of their handshake, such as for the affinity of a load balancer.
+type CookieOptions struct {
+	// Name is the name of the cookie, "io" by default.
+	Name string
+	// Path is the path of the cookie, "/" by default.
+	Path string
+	// Domain is the domain of the cookie, the host of the requests when
+	// empty.
+	Domain string
+	// HTTPOnly hides the cookie from the scripts, which it does when nil.
+	HTTPOnly *bool
+	// SameSite is the SameSite attribute of the cookie, lax by default.
+	SameSite http.SameSite
+	// Secure only sends the cookie over HTTPS.
+	Secure bool
+	// MaxAge is how long the cookie is kept, until the browser closes when
+	// zero.
+	MaxAge time.Duration
+}
+
+// httpCookie returns the cookie with its defaults, its value being set for
+// each session.
+func (c *CookieOptions) httpCookie() *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     c.Name,
+		Path:     c.Path,
+		Domain:   c.Domain,
+		MaxAge:   int(c.MaxAge / time.Second),
+		Secure:   c.Secure,
+		HttpOnly: c.HTTPOnly == nil || *c.HTTPOnly,
+		SameSite: c.SameSite,
+	}
+	if cookie.Name == "" {
+		cookie.Name = defaultCookieName
+	}
+	if cookie.Path == "" {
+		cookie.Path = "/"
+	}
+	if cookie.SameSite == 0 {
+		cookie.SameSite = http.SameSiteLaxMode
+	}
+	return cookie
+}
+
+// setCookie sets the cookie holding the id of a new session, when the server
+// has a cookie.
+func (s *Server) setCookie(w http.ResponseWriter, sid string) {
+	if s.cookie == nil {
+		return
+	}
+
+	cookie := *s.cookie
+	cookie.Value = sid
+	http.SetCookie(w, &cookie)
+}
+
+// cookieWriter sets the cookie of a new session on the handshake responses
+// written once the connection is hijacked, such as the websocket ones, which
+// are answered by the transports before the session is created. The error
+// responses are written without hijacking, and without the cookie.
+type cookieWriter struct {
+	http.ResponseWriter
+	setCookie func()
+	hijacked  bool
+}
+
+// acceptWriter returns the writer accepting the connection of the new session
+// sid, which sets its cookie on hijack when the connection can be hijacked.
+func (s *Server) acceptWriter(w http.ResponseWriter, sid string) (http.ResponseWriter, *cookieWriter) {
+	if s.cookie == nil {
+		return w, nil
+	}
+	if _, ok := w.(http.Hijacker); !ok {
+		return w, nil
+	}
+
+	cw := &cookieWriter{
+		ResponseWriter: w,
+		setCookie: func() {
+			s.setCookie(w, sid)
+		},
+	}
+	return cw, cw
+}
+
+func (w *cookieWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("engineio: hijacking not supported")
+	}
+
+	w.setCookie()
+	w.hijacked = true
+	return hijacker.Hijack()
+}
+
+// cookieSet tells whether the cookie was set on the hijacked connection.
+func (w *cookieWriter) cookieSet() bool {
+	return w != nil && w.hijacked
+}
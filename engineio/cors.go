@@ -0,0 +1,134 @@
+package engineio
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vchitai/go-socket.io/v4/engineio/transport"
+)
+
+var defaultCORSMethods = []string{http.MethodGet, http.MethodPost, http.MethodOptions}
+
+// CORS is the cross-origin configuration of the server, applied to the
+// requests of every transport. The requests from an origin which is not
+// allowed are rejected with a 403, the preflight requests being answered by
+// the server.
+type CORS struct {
+	// Origins are the allowed origins, "*" allowing any origin. Any origin
+	// is allowed when no origin is configured.
+	Origins []string
+	// OriginRegexp allows the origins it matches.
+	OriginRegexp *regexp.Regexp
+	// OriginFunc allows the origins of the requests it returns true for.
+	OriginFunc func(origin string, r *http.Request) bool
+
+	// Methods are the methods allowed by the preflight requests, GET, POST
+	// and OPTIONS by default.
+	Methods []string
+	// AllowedHeaders are the headers allowed by the preflight requests, the
+	// requested headers being allowed by default.
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers exposed to the clients.
+	ExposedHeaders []string
+	// Credentials allows the requests with credentials, the origin of the
+	// requests being allowed rather than any origin. It requires the allowed
+	// origins to be listed, matched or checked by OriginFunc, "*" being
+	// ignored: no cross-origin request is allowed without them.
+	Credentials bool
+	// MaxAge is the duration the preflight requests are cached for.
+	MaxAge time.Duration
+}
+
+// anyOrigin tells whether any origin is allowed.
+func (c *CORS) anyOrigin() bool {
+	if len(c.Origins) == 0 && c.OriginRegexp == nil && c.OriginFunc == nil {
+		return true
+	}
+	for _, origin := range c.Origins {
+		if origin == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *CORS) allowed(origin string, r *http.Request) bool {
+	// with the credentials, only the origins given explicitly are allowed,
+	// reflecting any origin would let any site use them
+	if c.anyOrigin() && !c.Credentials {
+		return true
+	}
+	for _, o := range c.Origins {
+		if strings.EqualFold(o, origin) {
+			return true
+		}
+	}
+	if c.OriginRegexp != nil && c.OriginRegexp.MatchString(origin) {
+		return true
+	}
+	return c.OriginFunc != nil && c.OriginFunc(origin, r)
+}
+
+// check returns the Forbidden error of a request from an origin which is not
+// allowed.
+func (c *CORS) check(r *http.Request) *Error {
+	origin := r.Header.Get("Origin")
+	if origin == "" || c.allowed(origin, r) {
+		return nil
+	}
+	return NewError(Forbidden, map[string]any{"origin": origin})
+}
+
+// serve sets the CORS headers of a request from an allowed origin. It returns
+// false when the request was answered, being a preflight request, and else r
+// marked as coming from an allowed origin.
+func (c *CORS) serve(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	header := w.Header()
+	header.Add("Vary", "Origin")
+
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		// not a cross-origin request from a browser
+		return transport.AllowOrigin(r), true
+	}
+
+	if c.anyOrigin() && !c.Credentials {
+		header.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		header.Set("Access-Control-Allow-Origin", origin)
+	}
+	if c.Credentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+	if len(c.ExposedHeaders) > 0 {
+		header.Set("Access-Control-Expose-Headers", strings.Join(c.ExposedHeaders, ", "))
+	}
+
+	if r.Method != http.MethodOptions {
+		return transport.AllowOrigin(r), true
+	}
+
+	// preflight request
+	methods := c.Methods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+	header.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+
+	if len(c.AllowedHeaders) > 0 {
+		header.Set("Access-Control-Allow-Headers", strings.Join(c.AllowedHeaders, ", "))
+	} else if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+		header.Add("Vary", "Access-Control-Request-Headers")
+		header.Set("Access-Control-Allow-Headers", requested)
+	}
+
+	if c.MaxAge > 0 {
+		header.Set("Access-Control-Max-Age", strconv.Itoa(int(c.MaxAge/time.Second)))
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil, false
+}
@@ -0,0 +1,145 @@
+package engineio
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func corsRequest(t *testing.T, method, url, origin string, header http.Header) *http.Response {
+	req, err := http.NewRequest(method, url, nil)
+	require.NoError(t, err)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	return resp
+}
+
+func TestCORS(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	_, url := newConformanceServerOptions(t, &Options{
+		CORS: &CORS{
+			Origins:      []string{"https://a.example"},
+			OriginRegexp: regexp.MustCompile(`^https://[a-z]+\.b\.example$`),
+			OriginFunc: func(origin string, _ *http.Request) bool {
+				return origin == "https://c.example"
+			},
+			ExposedHeaders: []string{"X-Exposed"},
+			Credentials:    true,
+			MaxAge:         time.Hour,
+		},
+	})
+	handshakeURL := url + "/?EIO=4&transport=polling"
+
+	// preflight requests are answered by the server
+	resp := corsRequest(t, http.MethodOptions, handshakeURL, "https://a.example", http.Header{
+		"Access-Control-Request-Method":  {"POST"},
+		"Access-Control-Request-Headers": {"Content-Type"},
+	})
+	should.Equal(http.StatusNoContent, resp.StatusCode)
+	should.Equal("https://a.example", resp.Header.Get("Access-Control-Allow-Origin"))
+	should.Equal("true", resp.Header.Get("Access-Control-Allow-Credentials"))
+	should.Equal("GET, POST, OPTIONS", resp.Header.Get("Access-Control-Allow-Methods"))
+	should.Equal("Content-Type", resp.Header.Get("Access-Control-Allow-Headers"))
+	should.Equal("3600", resp.Header.Get("Access-Control-Max-Age"))
+
+	// the origins are allowed by the list, the regexp or the func
+	for _, origin := range []string{"https://a.example", "https://x.b.example", "https://c.example"} {
+		resp = corsRequest(t, http.MethodGet, handshakeURL, origin, nil)
+		should.Equal(http.StatusOK, resp.StatusCode, origin)
+		should.Equal(origin, resp.Header.Get("Access-Control-Allow-Origin"))
+		should.Equal("X-Exposed", resp.Header.Get("Access-Control-Expose-Headers"))
+		should.Contains(resp.Header.Values("Vary"), "Origin")
+	}
+
+	// the other origins are rejected
+	for _, method := range []string{http.MethodOptions, http.MethodGet, http.MethodPost} {
+		resp = corsRequest(t, method, handshakeURL, "https://d.example", nil)
+		should.Equal(http.StatusForbidden, resp.StatusCode, method)
+		should.Equal("application/json", resp.Header.Get("Content-Type"), method)
+		should.Empty(resp.Header.Get("Access-Control-Allow-Origin"))
+	}
+
+	// the requests without origin are not cross-origin
+	resp = corsRequest(t, http.MethodGet, handshakeURL, "", nil)
+	should.Equal(http.StatusOK, resp.StatusCode)
+	should.Empty(resp.Header.Get("Access-Control-Allow-Origin"))
+
+	// the websocket origins are checked the same way
+	ws, _, err := websocket.DefaultDialer.Dial(websocketURL(url, ""), http.Header{"Origin": {"https://x.b.example"}})
+	must.NoError(err)
+	must.NoError(ws.Close())
+
+	_, resp, err = websocket.DefaultDialer.Dial(websocketURL(url, ""), http.Header{"Origin": {"https://d.example"}})
+	must.Error(err)
+	should.Equal(http.StatusForbidden, resp.StatusCode)
+}
+
+func TestCORSAnyOrigin(t *testing.T) {
+	should := assert.New(t)
+
+	_, url := newConformanceServerOptions(t, &Options{
+		CORS: &CORS{
+			Methods:        []string{http.MethodGet, http.MethodPost},
+			AllowedHeaders: []string{"Content-Type", "Authorization"},
+		},
+	})
+
+	resp := corsRequest(t, http.MethodOptions, url+"/?EIO=4&transport=polling", "https://a.example", http.Header{
+		"Access-Control-Request-Method":  {"POST"},
+		"Access-Control-Request-Headers": {"X-Other"},
+	})
+	should.Equal(http.StatusNoContent, resp.StatusCode)
+	should.Equal("*", resp.Header.Get("Access-Control-Allow-Origin"))
+	should.Empty(resp.Header.Get("Access-Control-Allow-Credentials"))
+	should.Equal("GET, POST", resp.Header.Get("Access-Control-Allow-Methods"))
+	should.Equal("Content-Type, Authorization", resp.Header.Get("Access-Control-Allow-Headers"))
+	should.Empty(resp.Header.Get("Access-Control-Max-Age"))
+
+	resp = corsRequest(t, http.MethodGet, url+"/?EIO=4&transport=polling", "https://a.example", nil)
+	should.Equal(http.StatusOK, resp.StatusCode)
+	should.Equal("*", resp.Header.Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORSCredentialsAnyOrigin(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	_, url := newConformanceServerOptions(t, &Options{
+		CORS: &CORS{Origins: []string{"*", "https://a.example"}, Credentials: true},
+	})
+	handshakeURL := url + "/?EIO=4&transport=polling"
+
+	// the origins are not reflected with the credentials, only the listed
+	// ones being allowed
+	resp := corsRequest(t, http.MethodGet, handshakeURL, "https://evil.example", nil)
+	should.Equal(http.StatusForbidden, resp.StatusCode)
+	should.Empty(resp.Header.Get("Access-Control-Allow-Origin"))
+	should.Empty(resp.Header.Get("Access-Control-Allow-Credentials"))
+
+	resp = corsRequest(t, http.MethodGet, handshakeURL, "https://a.example", nil)
+	should.Equal(http.StatusOK, resp.StatusCode)
+	should.Equal("https://a.example", resp.Header.Get("Access-Control-Allow-Origin"))
+	should.Equal("true", resp.Header.Get("Access-Control-Allow-Credentials"))
+
+	resp = corsRequest(t, http.MethodGet, handshakeURL, "", nil)
+	should.Equal(http.StatusOK, resp.StatusCode)
+
+	_, resp, err := websocket.DefaultDialer.Dial(websocketURL(url, ""), http.Header{"Origin": {"https://evil.example"}})
+	must.Error(err)
+	should.Equal(http.StatusForbidden, resp.StatusCode)
+}
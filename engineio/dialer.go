@@ -5,6 +5,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/vchitai/go-socket.io/v4/engineio/packet"
 	"github.com/vchitai/go-socket.io/v4/engineio/transport"
@@ -23,7 +24,7 @@ func (d *Dialer) Dial(urlStr string, requestHeader http.Header) (Conn, error) {
 	}
 
 	query := u.Query()
-	query.Set("EIO", "3")
+	query.Set("EIO", protocolVersion)
 	u.RawQuery = query.Encode()
 
 	var conn transport.Conn
@@ -70,16 +71,18 @@ func (d *Dialer) Dial(urlStr string, requestHeader http.Header) (Conn, error) {
 		if err != nil {
 			continue
 		}
-		ret := &client{
+		// the server pings within the ping interval, and closes the
+		// connection when the pong is late
+		if err = conn.SetReadDeadline(time.Now().Add(params.PingInterval + params.PingTimeout)); err != nil {
+			continue
+		}
+
+		return &client{
 			conn:      conn,
 			params:    params,
 			transport: t.Name(),
 			close:     make(chan struct{}),
-		}
-
-		go ret.serve()
-
-		return ret, nil
+		}, nil
 	}
 
 	return nil, err
@@ -0,0 +1,129 @@
+package engineio
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/websocket"
+)
+
+// ErrorCode is the code of an error answered to a request, given to the
+// clients in the JSON body of the response.
+type ErrorCode int
+
+// The error codes of the Engine.IO protocol.
+const (
+	UnknownTransport ErrorCode = iota
+	UnknownSID
+	BadHandshakeMethod
+	BadRequest
+	Forbidden
+	UnsupportedProtocolVersion
+)
+
+var errorMessages = map[ErrorCode]string{
+	UnknownTransport:           "Transport unknown",
+	UnknownSID:                 "Session ID unknown",
+	BadHandshakeMethod:         "Bad handshake method",
+	BadRequest:                 "Bad request",
+	Forbidden:                  "Forbidden",
+	UnsupportedProtocolVersion: "Unsupported protocol version",
+}
+
+func (c ErrorCode) String() string {
+	if msg, ok := errorMessages[c]; ok {
+		return msg
+	}
+	return "Unknown error"
+}
+
+// Error is the error of a rejected request, answered with its status and a
+// JSON body of its code and message. A RequestChecker returns an Error to
+// choose how a request is rejected, the other errors rejecting it as
+// Forbidden.
+type Error struct {
+	Code ErrorCode
+	// Status is the HTTP status of the response, 403 for Forbidden and 400
+	// for the other codes by default.
+	Status int
+	// Message is the message of the response, the message of the code by
+	// default.
+	Message string
+	// Context holds the details of the error given to the connection error
+	// hook, which are not sent to the client.
+	Context map[string]any
+}
+
+// NewError returns the error of code with its default status and message.
+func NewError(code ErrorCode, ctx map[string]any) *Error {
+	return &Error{Code: code, Context: ctx}
+}
+
+func (e *Error) Error() string {
+	return e.message()
+}
+
+func (e *Error) message() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	return e.Code.String()
+}
+
+func (e *Error) status() int {
+	if e.Status != 0 {
+		return e.Status
+	}
+	if e.Code == Forbidden {
+		return http.StatusForbidden
+	}
+	return http.StatusBadRequest
+}
+
+// checkerError returns the error answered to a request rejected by the
+// request checker with err.
+func checkerError(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return NewError(Forbidden, map[string]any{"message": err.Error()})
+}
+
+// connectionError gives err of a rejected request to the connection error
+// hook.
+func (s *Server) connectionError(r *http.Request, err *Error) {
+	if s.onConnectionError != nil {
+		s.onConnectionError(r, int(err.Code), err.message(), err.Context)
+	}
+}
+
+// abort answers a request with err, given to the connection error hook.
+func (s *Server) abort(w http.ResponseWriter, r *http.Request, err *Error) {
+	s.connectionError(r, err)
+
+	body, _ := json.Marshal(struct {
+		Code    ErrorCode `json:"code"`
+		Message string    `json:"message"`
+	}{err.Code, err.message()})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(err.status())
+	_, _ = w.Write(body)
+}
+
+// acceptError answers a request whose connection was not accepted by its
+// transport with err. The websocket handshake errors are answered by the
+// websocket library, and only given to the connection error hook.
+func (s *Server) acceptError(w http.ResponseWriter, r *http.Request, err error) {
+	var handshakeErr websocket.HandshakeError
+	if errors.As(err, &handshakeErr) {
+		s.connectionError(r, NewError(BadRequest, map[string]any{
+			"name":    "TRANSPORT_HANDSHAKE_ERROR",
+			"message": err.Error(),
+		}))
+		return
+	}
+	s.abort(w, r, NewError(BadRequest, map[string]any{"message": err.Error()}))
+}
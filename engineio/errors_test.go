@@ -0,0 +1,97 @@
+package engineio
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type connectionError struct {
+	code int
+	msg  string
+	ctx  map[string]any
+}
+
+func TestRequestCheckerError(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	svr, url := newConformanceServerOptions(t, &Options{
+		RequestChecker: func(r *http.Request) (http.Header, error) {
+			switch r.URL.Query().Get("user") {
+			case "banned":
+				return nil, errors.New("banned user")
+			case "unknown":
+				return nil, &Error{
+					Code:    BadRequest,
+					Status:  http.StatusUnauthorized,
+					Message: "Unknown user",
+					Context: map[string]any{"user": "unknown"},
+				}
+			}
+			return nil, nil
+		},
+	})
+
+	errs := make(chan connectionError, 1)
+	svr.OnConnectionError(func(_ *http.Request, code int, msg string, ctx map[string]any) {
+		errs <- connectionError{code, msg, ctx}
+	})
+
+	// the other errors are forbidden, their message given to the hook only
+	status, body := conformanceRequest(t, http.MethodGet, url+"/?EIO=4&transport=polling&user=banned", "")
+	should.Equal(http.StatusForbidden, status)
+	should.JSONEq(`{"code":4,"message":"Forbidden"}`, body)
+	must.Equal(connectionError{4, "Forbidden", map[string]any{"message": "banned user"}}, <-errs)
+
+	// the errors of the checker give the response
+	status, body = conformanceRequest(t, http.MethodGet, url+"/?EIO=4&transport=polling&user=unknown", "")
+	should.Equal(http.StatusUnauthorized, status)
+	should.JSONEq(`{"code":3,"message":"Unknown user"}`, body)
+	must.Equal(connectionError{3, "Unknown user", map[string]any{"user": "unknown"}}, <-errs)
+
+	// the other requests are answered by the server
+	status, body = conformanceRequest(t, http.MethodGet, url+"/?EIO=4&transport=unknown", "")
+	should.Equal(http.StatusBadRequest, status)
+	should.JSONEq(`{"code":0,"message":"Transport unknown"}`, body)
+	must.Equal(connectionError{0, "Transport unknown", map[string]any{"transport": "unknown"}}, <-errs)
+
+	status, _ = conformanceRequest(t, http.MethodGet, url+"/?EIO=4&transport=polling&user=known", "")
+	should.Equal(http.StatusOK, status)
+	should.Empty(errs)
+}
+
+func TestConnectionError(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	svr, url := newConformanceServerOptions(t, &Options{
+		CORS: &CORS{Origins: []string{"https://a.example"}},
+	})
+
+	errs := make(chan connectionError, 1)
+	svr.OnConnectionError(func(_ *http.Request, code int, msg string, ctx map[string]any) {
+		errs <- connectionError{code, msg, ctx}
+	})
+
+	status, _ := conformanceRequest(t, http.MethodGet, url+"/?EIO=4&transport=polling&sid=unknown", "")
+	should.Equal(http.StatusBadRequest, status)
+	should.Equal(connectionError{1, "Session ID unknown", map[string]any{"sid": "unknown"}}, <-errs)
+
+	resp := corsRequest(t, http.MethodGet, url+"/?EIO=4&transport=polling", "https://b.example", nil)
+	should.Equal(http.StatusForbidden, resp.StatusCode)
+	should.Equal(connectionError{4, "Forbidden", map[string]any{"origin": "https://b.example"}}, <-errs)
+
+	// the websocket handshake errors are answered by the websocket library
+	status, body := conformanceRequest(t, http.MethodGet, url+"/?EIO=4&transport=websocket", "")
+	should.Equal(http.StatusBadRequest, status)
+	should.NotContains(body, `"code"`)
+
+	err := <-errs
+	should.Equal(3, err.code)
+	should.Equal("TRANSPORT_HANDSHAKE_ERROR", err.ctx["name"])
+	must.Contains(err.ctx, "message")
+}
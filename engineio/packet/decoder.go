@@ -26,6 +26,10 @@ func (e *Decoder) NextReader() (frame.Type, Type, io.ReadCloser, error) {
 	if err != nil {
 		return 0, 0, nil, err
 	}
+	if ft == frame.Binary {
+		return ft, MESSAGE, r, nil
+	}
+
 	var b [1]byte
 	if _, err := io.ReadFull(r, b[:]); err != nil {
 		_ = r.Close()
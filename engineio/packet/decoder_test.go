@@ -29,7 +29,7 @@ var tests = []struct {
 	{[]Packet{
 		{frame.Binary, MESSAGE, []byte("hello 你好")},
 	}, []Frame{
-		{frame.Binary, []byte{0x04, 'h', 'e', 'l', 'l', 'o', ' ', 0xe4, 0xbd, 0xa0, 0xe5, 0xa5, 0xbd}},
+		{frame.Binary, []byte{'h', 'e', 'l', 'l', 'o', ' ', 0xe4, 0xbd, 0xa0, 0xe5, 0xa5, 0xbd}},
 	},
 	},
 	{[]Packet{
@@ -39,7 +39,7 @@ var tests = []struct {
 		{frame.String, PING, []byte("probe")},
 	}, []Frame{
 		{frame.String, []byte("0")},
-		{frame.Binary, []byte{0x04, 'h', 'e', 'l', 'l', 'o', '\n'}},
+		{frame.Binary, []byte{'h', 'e', 'l', 'l', 'o', '\n'}},
 		{frame.String, []byte("4你好\n")},
 		{frame.String, []byte("2probe")},
 	},
@@ -49,7 +49,7 @@ var tests = []struct {
 		{frame.String, MESSAGE, []byte("hello")},
 		{frame.String, CLOSE, []byte{}},
 	}, []Frame{
-		{frame.Binary, []byte{4, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}},
+		{frame.Binary, []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}},
 		{frame.String, []byte("4hello")},
 		{frame.String, []byte("1")},
 	},
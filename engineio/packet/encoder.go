@@ -27,12 +27,12 @@ func (e *Encoder) NextWriter(ft frame.Type, pt Type) (io.WriteCloser, error) {
 		return nil, err
 	}
 
-	var b [1]byte
-	if ft == frame.String {
-		b[0] = pt.StringByte()
-	} else {
-		b[0] = pt.StringByte() + 'b'
+	// binary frames hold the data of a message packet only
+	if ft == frame.Binary {
+		return w, nil
 	}
+
+	b := [1]byte{pt.StringByte()}
 	if _, err := w.Write(b[:]); err != nil {
 		_ = w.Close()
 		return nil, err
@@ -16,7 +16,7 @@ type byteReader interface {
 }
 
 type readerFeeder interface {
-	getReader() (io.Reader, bool, error)
+	getReader() (io.Reader, error)
 	putReader(error) error
 }
 
@@ -26,14 +26,13 @@ type decoder struct {
 	rawReader   byteReader
 	feeder      readerFeeder
 
-	ft            frame.Type
-	pt            packet.Type
-	supportBinary bool
+	ft frame.Type
+	pt packet.Type
 }
 
 func (d *decoder) NextReader() (frame.Type, packet.Type, io.ReadCloser, error) {
 	if d.rawReader == nil {
-		r, supportBinary, err := d.feeder.getReader()
+		r, err := d.feeder.getReader()
 		if err != nil {
 			return 0, 0, nil, err
 		}
@@ -41,7 +40,7 @@ func (d *decoder) NextReader() (frame.Type, packet.Type, io.ReadCloser, error) {
 		if !ok {
 			br = bufio.NewReader(r)
 		}
-		if err := d.setNextReader(br, supportBinary); err != nil {
+		if err := d.setNextReader(br); err != nil {
 			return 0, 0, nil, d.sendError(err)
 		}
 	}
@@ -60,7 +59,7 @@ func (d *decoder) Close() error {
 	if _, err := io.Copy(io.Discard, d); err != nil {
 		return d.sendError(err)
 	}
-	err := d.setNextReader(d.rawReader, d.supportBinary)
+	err := d.setNextReader(d.rawReader)
 	if err != nil {
 		if err != io.EOF {
 			return d.sendError(err)
@@ -73,15 +72,10 @@ func (d *decoder) Close() error {
 	return err
 }
 
-func (d *decoder) setNextReader(r byteReader, supportBinary bool) error {
-	var read func(byteReader) (frame.Type, packet.Type, error)
-	if supportBinary {
-		read = d.binaryRead
-	} else {
-		read = d.textRead
-	}
-
-	ft, pt, err := read(r)
+// setNextReader starts reading the next packet of the payload, the packets
+// being separated by the record separator.
+func (d *decoder) setNextReader(r byteReader) error {
+	ft, pt, err := d.readHeader(r)
 	if err != nil {
 		return err
 	}
@@ -90,7 +84,6 @@ func (d *decoder) setNextReader(r byteReader, supportBinary bool) error {
 	d.pt = pt
 	d.rawReader = r
 	d.limitReader = newDelimReader(separator, r)
-	d.supportBinary = supportBinary
 	if ft == frame.Binary {
 		d.b64Reader = base64.NewDecoder(base64.StdEncoding, d.limitReader)
 	} else {
@@ -106,40 +99,20 @@ func (d *decoder) sendError(err error) error {
 	return err
 }
 
-func (d *decoder) textRead(r byteReader) (frame.Type, packet.Type, error) {
-	ft := frame.String
+// readHeader reads the type of a packet, binary packets being sent as the
+// base64 of a message whose type is implied.
+func (d *decoder) readHeader(r byteReader) (frame.Type, packet.Type, error) {
 	b, err := r.ReadByte()
 	if err != nil {
 		return 0, 0, err
 	}
 
 	if b == 'b' {
-		ft = frame.Binary
-		b, err = r.ReadByte()
-		if err != nil {
-			return 0, 0, err
-		}
-	}
-
-	pt := packet.ByteToPacketType(b, frame.String)
-	return ft, pt, nil
-}
-
-func (d *decoder) binaryRead(r byteReader) (frame.Type, packet.Type, error) {
-	b, err := r.ReadByte()
-	if err != nil {
-		return 0, 0, err
+		return frame.Binary, packet.MESSAGE, nil
 	}
-	if b > 1 {
+	if b < '0' || b > '6' {
 		return 0, 0, errInvalidPayload
 	}
-	ft := frame.ByteToFrameType(b)
-
-	b, err = r.ReadByte()
-	if err != nil {
-		return 0, 0, err
-	}
-	pt := packet.ByteToPacketType(b, ft)
 
-	return ft, pt, nil
+	return frame.String, packet.ByteToPacketType(b, frame.String), nil
 }
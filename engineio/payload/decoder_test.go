@@ -19,17 +19,16 @@ func (r fakeReader) Read(p []byte) (int, error) {
 }
 
 type fakeReaderFeeder struct {
-	data          []byte
-	supportBinary bool
-	returnError   error
-	sendError     error
-	getCounter    int
-	putCounter    int
+	data        []byte
+	returnError error
+	sendError   error
+	getCounter  int
+	putCounter  int
 }
 
-func (f *fakeReaderFeeder) getReader() (io.Reader, bool, error) {
+func (f *fakeReaderFeeder) getReader() (io.Reader, error) {
 	f.getCounter++
-	return fakeReader{bytes.NewReader(f.data)}, f.supportBinary, f.returnError
+	return fakeReader{bytes.NewReader(f.data)}, f.returnError
 }
 
 func (f *fakeReaderFeeder) putReader(err error) error {
@@ -44,8 +43,7 @@ func TestDecoder(t *testing.T) {
 
 	for _, test := range tests {
 		feeder := fakeReaderFeeder{
-			data:          test.data,
-			supportBinary: test.supportBinary,
+			data: test.data,
 		}
 		d := decoder{
 			feeder: &feeder,
@@ -80,8 +78,7 @@ func TestDecoderNextReaderError(t *testing.T) {
 	assert := assert.New(t)
 
 	feeder := fakeReaderFeeder{
-		data:          []byte{0x00, 0x01, 0xff, '0'},
-		supportBinary: true,
+		data: []byte("0"),
 	}
 	d := decoder{
 		feeder: &feeder,
@@ -93,35 +90,56 @@ func TestDecoderNextReaderError(t *testing.T) {
 	assert.Equal(targetErr, err)
 }
 
-func BenchmarkStringDecoder(b *testing.B) {
+func TestDecoderLongPacket(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	long := bytes.Repeat([]byte("0123456789"), 1000)
 	feeder := fakeReaderFeeder{
-		data:          []byte("8:4你好\n6:2probe"),
-		supportBinary: false,
+		data: append(append([]byte("4"), long...), "\x1e4end"...),
 	}
 	d := decoder{
 		feeder: &feeder,
 	}
-	buf := make([]byte, 10)
 
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		for j := 0; j < 2; j++ {
-			_, _, r, err := d.NextReader()
-			require.NoError(b, err)
+	// the packets are read with a buffer smaller than them
+	for _, want := range [][]byte{long, []byte("end")} {
+		_, _, r, err := d.NextReader()
+		must.NoError(err)
+
+		var got []byte
+		buf := make([]byte, 7)
+		for {
+			n, err := r.Read(buf)
+			got = append(got, buf[:n]...)
+			if err == io.EOF {
+				break
+			}
+			must.NoError(err)
+		}
+		must.NoError(r.Close())
+		should.Equal(want, got)
+	}
+}
 
-			_, err = r.Read(buf)
-			require.NoError(b, err)
+func TestDecoderInvalidPacketType(t *testing.T) {
+	should := assert.New(t)
 
-			err = r.Close()
-			require.NoError(b, err)
-		}
+	feeder := fakeReaderFeeder{
+		data: []byte("9hello"),
+	}
+	d := decoder{
+		feeder: &feeder,
 	}
+
+	_, _, _, err := d.NextReader()
+	should.Equal(errInvalidPayload, err)
+	should.Equal(errInvalidPayload, feeder.sendError)
 }
 
-func BenchmarkB64Decoder(b *testing.B) {
+func BenchmarkStringDecoder(b *testing.B) {
 	feeder := fakeReaderFeeder{
-		data:          []byte("10:b4aGVsbG8K6:2probe"),
-		supportBinary: false,
+		data: []byte("4你好\n\x1e2probe"),
 	}
 	d := decoder{
 		feeder: &feeder,
@@ -143,14 +161,9 @@ func BenchmarkB64Decoder(b *testing.B) {
 	}
 }
 
-func BenchmarkBinaryDecoder(b *testing.B) {
+func BenchmarkB64Decoder(b *testing.B) {
 	feeder := fakeReaderFeeder{
-		data: []byte{
-			0x01, 0x07, 0xff, 0x04, 'h', 'e', 'l', 'l', 'o', '\n',
-			0x00, 0x08, 0xff, '4', 0xe4, 0xbd, 0xa0, 0xe5, 0xa5, 0xbd, '\n',
-			0x00, 0x06, 0xff, '2', 'p', 'r', 'o', 'b', 'e',
-		},
-		supportBinary: true,
+		data: []byte("baGVsbG8K\x1e2probe"),
 	}
 	d := decoder{
 		feeder: &feeder,
@@ -159,7 +172,7 @@ func BenchmarkBinaryDecoder(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		for j := 0; j < 3; j++ {
+		for j := 0; j < 2; j++ {
 			_, _, r, err := d.NextReader()
 			require.NoError(b, err)
 
@@ -6,27 +6,45 @@ import (
 	"io"
 )
 
+// delimReader reads a packet up to the delimiter ending it, or the end of
+// the payload.
 type delimReader struct {
-	delim byte
-	r     byteReader
+	delim   byte
+	r       byteReader
+	pending []byte
+	done    bool
 }
 
-func (rd *delimReader) Read(p []byte) (n int, err error) {
+func (rd *delimReader) Read(p []byte) (int, error) {
 	if p == nil {
 		return 0, fmt.Errorf("nil bytes")
 	}
-	b, err := rd.r.ReadBytes(rd.delim)
-	switch {
-	case errors.Is(err, io.EOF):
-		goto done
-	case err != nil:
-		return 0, err
-	}
-	if n := len(b); n > 0 && b[n-1] == separator {
-		b = b[:n-1]
+
+	if len(rd.pending) == 0 {
+		if rd.done {
+			return 0, io.EOF
+		}
+
+		b, err := rd.r.ReadBytes(rd.delim)
+		switch {
+		case errors.Is(err, io.EOF):
+			rd.done = true
+		case err != nil:
+			return 0, err
+		default:
+			rd.done = true
+			b = b[:len(b)-1]
+		}
+		rd.pending = b
+
+		if len(b) == 0 {
+			return 0, io.EOF
+		}
 	}
-done:
-	return copy(p, b), err
+
+	n := copy(p, rd.pending)
+	rd.pending = rd.pending[n:]
+	return n, nil
 }
 
 func newDelimReader(delim byte, r byteReader) io.Reader {
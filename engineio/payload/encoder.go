@@ -15,8 +15,7 @@ type writerFeeder interface {
 }
 
 type encoder struct {
-	supportBinary bool
-	feeder        writerFeeder
+	feeder writerFeeder
 
 	ft         frame.Type
 	pt         packet.Type
@@ -86,10 +85,7 @@ func (e *encoder) writeTextHeader() error {
 	return e.header.WriteByte(e.pt.StringByte())
 }
 
+// writeB64Header starts a binary message, whose type is implied
 func (e *encoder) writeB64Header() error {
-	err := e.header.WriteByte(e.pt.StringByte())
-	if err == nil {
-		err = e.header.WriteByte('b')
-	}
-	return err
+	return e.header.WriteByte('b')
 }
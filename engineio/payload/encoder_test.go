@@ -42,10 +42,14 @@ func TestEncoder(t *testing.T) {
 	}
 
 	for _, test := range tests {
+		// a payload is flushed out for each packet
+		if len(test.packets) != 1 {
+			continue
+		}
+
 		buf.Reset()
 		e := encoder{
-			supportBinary: test.supportBinary,
-			feeder:        f,
+			feeder: f,
 		}
 
 		for _, packet := range test.packets {
@@ -68,8 +72,7 @@ func TestEncoderBeginError(t *testing.T) {
 		w: buf,
 	}
 	e := encoder{
-		supportBinary: true,
-		feeder:        f,
+		feeder: f,
 	}
 
 	buf.Reset()
@@ -99,8 +102,7 @@ func TestEncoderEndError(t *testing.T) {
 		},
 	}
 	e := encoder{
-		supportBinary: true,
-		feeder:        f,
+		feeder: f,
 	}
 
 	targetErr := errors.New("error")
@@ -117,20 +119,8 @@ func TestEncoderEndError(t *testing.T) {
 func TestEncoderNOOP(t *testing.T) {
 	assert := assert.New(t)
 
-	tests := []struct {
-		supportBinary bool
-		data          []byte
-	}{
-		{true, []byte{0x00, 0x01, 0xff, '6'}},
-		{false, []byte("1:6")},
-	}
-
-	for _, test := range tests {
-		e := encoder{
-			supportBinary: test.supportBinary,
-		}
-		assert.Equal(test.data, e.NOOP())
-	}
+	e := encoder{}
+	assert.Equal([]byte("6"), e.NOOP())
 
 	// NOOP should be thread-safe
 	var wg sync.WaitGroup
@@ -138,13 +128,11 @@ func TestEncoderNOOP(t *testing.T) {
 	wg.Add(max)
 
 	for i := 0; i < max; i++ {
-		go func(i int) {
+		go func() {
 			defer wg.Done()
-			e := encoder{
-				supportBinary: i&0x1 == 0,
-			}
+			e := encoder{}
 			e.NOOP()
-		}(i)
+		}()
 	}
 
 	wg.Wait()
@@ -158,7 +146,6 @@ func BenchmarkStringEncoder(b *testing.B) {
 		{frame.String, packet.PING, []byte("probe")},
 	}
 	e := encoder{
-		supportBinary: false,
 		feeder: &fakeWriterFeeder{
 			w: io.Discard,
 		},
@@ -197,7 +184,6 @@ func BenchmarkB64Encoder(b *testing.B) {
 		{frame.Binary, packet.PING, []byte("probe")},
 	}
 	e := encoder{
-		supportBinary: false,
 		feeder: &fakeWriterFeeder{
 			w: io.Discard,
 		},
@@ -229,46 +215,3 @@ func BenchmarkB64Encoder(b *testing.B) {
 		}
 	}
 }
-
-func BenchmarkBinaryEncoder(b *testing.B) {
-	must := require.New(b)
-
-	packets := []Packet{
-		{frame.String, packet.OPEN, []byte{}},
-		{frame.Binary, packet.MESSAGE, []byte("你好\n")},
-		{frame.String, packet.PING, []byte("probe")},
-	}
-	e := encoder{
-		supportBinary: true,
-		feeder: &fakeWriterFeeder{
-			w: io.Discard,
-		},
-	}
-
-	// warm up for memory allocation
-	for _, p := range packets {
-		f, err := e.NextWriter(p.ft, p.pt)
-		must.Nil(err)
-
-		_, err = f.Write(p.data)
-		must.Nil(err)
-
-		err = f.Close()
-		must.Nil(err)
-	}
-
-	b.ResetTimer()
-
-	for i := 0; i < b.N; i++ {
-		for _, p := range packets {
-			f, err := e.NextWriter(p.ft, p.pt)
-			require.NoError(b, err)
-
-			_, err = f.Write(p.data)
-			require.NoError(b, err)
-
-			err = f.Close()
-			require.NoError(b, err)
-		}
-	}
-}
@@ -28,6 +28,11 @@ func (e *OpError) Error() string {
 	return fmt.Sprintf("%s: %s", e.Op, e.Err.Error())
 }
 
+// Unwrap returns the error of the operation.
+func (e *OpError) Unwrap() error {
+	return e.Err
+}
+
 // Temporary returns true if error can retry.
 func (e *OpError) Temporary() bool {
 	if oe, ok := e.Err.(Error); ok {
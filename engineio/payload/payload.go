@@ -12,12 +12,9 @@ import (
 	"github.com/vchitai/go-socket.io/v4/logger"
 )
 
-type readArg struct {
-	r             io.Reader
-	supportBinary bool
-}
-
-// Payload does encode or decode to payload protocol.
+// Payload does encode or decode to the payload protocol of Engine.IO v4, the
+// packets of a payload being separated by a record separator and the binary
+// packets being sent in base64 with a "b" prefix.
 type Payload struct {
 	close     chan struct{}
 	closeOnce sync.Once
@@ -25,7 +22,7 @@ type Payload struct {
 
 	pauser *pauser
 
-	readerChan   chan readArg
+	readerChan   chan io.Reader
 	feeding      int32
 	readError    chan error
 	readDeadline atomic.Value
@@ -39,11 +36,11 @@ type Payload struct {
 }
 
 // New returns a new payload.
-func New(supportBinary bool) *Payload {
+func New() *Payload {
 	ret := &Payload{
 		close:      make(chan struct{}),
 		pauser:     newPauser(),
-		readerChan: make(chan readArg),
+		readerChan: make(chan io.Reader),
 		readError:  make(chan error),
 		writerChan: make(chan io.Writer),
 		writeError: make(chan error),
@@ -51,7 +48,6 @@ func New(supportBinary bool) *Payload {
 	ret.readDeadline.Store(time.Time{})
 	ret.decoder.feeder = ret
 	ret.writeDeadline.Store(time.Time{})
-	ret.encoder.supportBinary = supportBinary
 	ret.encoder.feeder = ret
 	return ret
 }
@@ -63,7 +59,7 @@ func New(supportBinary bool) *Payload {
 // If having Pause-ed when FeedIn, it returns ErrPaused.
 // If NextReader has timeout, it returns ErrTimeout.
 // If read error while FeedIn, it returns read error.
-func (p *Payload) FeedIn(r io.Reader, supportBinary bool) error {
+func (p *Payload) FeedIn(r io.Reader) error {
 	select {
 	case <-p.close:
 		return p.load()
@@ -97,10 +93,7 @@ func (p *Payload) FeedIn(r io.Reader, supportBinary bool) error {
 			ll.V(1).Info("Payload read timeout")
 			continue
 
-		case p.readerChan <- readArg{
-			r:             r,
-			supportBinary: supportBinary,
-		}:
+		case p.readerChan <- r:
 			ll.V(1).Info("Payload received")
 		}
 		break
@@ -113,6 +106,10 @@ func (p *Payload) FeedIn(r io.Reader, supportBinary bool) error {
 		}
 
 		select {
+		case <-p.close:
+			ll.V(1).Info("Payload closed")
+			return p.load()
+
 		case <-after:
 			// it may be changed during wait, need check again
 			ll.V(1).Info("Payload read timeout")
@@ -299,32 +296,32 @@ func (p *Payload) writeTimeout() (<-chan time.Time, bool) {
 	return time.After(wait), true
 }
 
-func (p *Payload) getReader() (io.Reader, bool, error) {
+func (p *Payload) getReader() (io.Reader, error) {
 	select {
 	case <-p.close:
-		return nil, false, p.load()
+		return nil, p.load()
 	default:
 	}
 
 	if ok := p.pauser.Working(); !ok {
-		return nil, false, newOpError("payload", errPaused)
+		return nil, newOpError("payload", errPaused)
 	}
 	p.pauser.Done()
 
 	for {
 		after, ok := p.readTimeout()
 		if !ok {
-			return nil, false, p.Store("read", errTimeout)
+			return nil, p.Store("read", errTimeout)
 		}
 		select {
 		case <-p.close:
-			return nil, false, p.load()
+			return nil, p.load()
 		case <-p.pauser.PausedTrigger():
-			return nil, false, newOpError("payload", errPaused)
+			return nil, newOpError("payload", errPaused)
 		case <-after:
 			continue
-		case arg := <-p.readerChan:
-			return arg.r, arg.supportBinary, nil
+		case r := <-p.readerChan:
+			return r, nil
 		}
 	}
 }
@@ -18,7 +18,7 @@ func TestPayloadFeedIn(t *testing.T) {
 	should := assert.New(t)
 	must := require.New(t)
 
-	p := New(true)
+	p := New()
 	p.Pause()
 	p.Resume()
 
@@ -33,7 +33,7 @@ func TestPayloadFeedIn(t *testing.T) {
 				continue
 			}
 			r := bytes.NewReader(test.data)
-			err := p.FeedIn(r, test.supportBinary)
+			err := p.FeedIn(r)
 			must.Nil(err)
 		}
 	}()
@@ -67,12 +67,11 @@ func TestPayloadFeedIn(t *testing.T) {
 	wg.Wait()
 }
 
-func TestPayloadFlushOutText(t *testing.T) {
+func TestPayloadFlushOut(t *testing.T) {
 	should := assert.New(t)
 	must := require.New(t)
 
-	var supportBinary bool
-	p := New(supportBinary)
+	p := New()
 	p.Pause()
 	p.Resume()
 
@@ -90,9 +89,6 @@ func TestPayloadFlushOutText(t *testing.T) {
 			if len(test.packets) != 1 {
 				continue
 			}
-			if test.supportBinary != supportBinary {
-				continue
-			}
 			buf := bytes.NewBuffer(nil)
 			err := p.FlushOut(buf)
 			must.Nil(err)
@@ -104,9 +100,6 @@ func TestPayloadFlushOutText(t *testing.T) {
 		if len(test.packets) != 1 {
 			continue
 		}
-		if test.supportBinary != supportBinary {
-			continue
-		}
 		err := p.SetWriteDeadline(time.Now().Add(time.Second / 10))
 		require.NoError(t, err)
 
@@ -127,73 +120,10 @@ func TestPayloadFlushOutText(t *testing.T) {
 	wg.Wait()
 }
 
-func TestPayloadFlushOutBinary(t *testing.T) {
-	should := assert.New(t)
-	must := require.New(t)
-
-	var supportBinary bool
-	p := New(supportBinary)
-	p.Pause()
-	p.Resume()
-
-	var wg sync.WaitGroup
-	wg.Add(1)
-
-	go func() {
-		defer wg.Done()
-
-		should := assert.New(t)
-		must := require.New(t)
-
-		for _, test := range tests {
-			if len(test.packets) != 1 {
-				continue
-			}
-			if test.supportBinary != supportBinary {
-				continue
-			}
-
-			buf := bytes.NewBuffer(nil)
-			err := p.FlushOut(buf)
-			must.Nil(err)
-			should.Equal(test.data, buf.Bytes())
-		}
-	}()
-
-	for _, test := range tests {
-		if len(test.packets) != 1 {
-			continue
-		}
-		if test.supportBinary != supportBinary {
-			continue
-		}
-
-		err := p.SetWriteDeadline(time.Now().Add(time.Second / 10))
-		must.NoError(err)
-
-		w, err := p.NextWriter(test.packets[0].ft, test.packets[0].pt)
-		must.Nil(err)
-
-		_, err = w.Write(test.packets[0].data)
-		must.Nil(err)
-
-		err = w.Close()
-		must.Nil(err)
-	}
-
-	err := p.SetWriteDeadline(time.Now().Add(time.Second / 10))
-	must.NoError(err)
-
-	_, err = p.NextWriter(frame.Binary, packet.OPEN)
-	should.Equal("write: timeout", err.Error())
-
-	wg.Wait()
-}
-
 func TestPayloadWaitNextClose(t *testing.T) {
 	should := assert.New(t)
 
-	p := New(true)
+	p := New()
 
 	var wg sync.WaitGroup
 
@@ -231,7 +161,7 @@ func TestPayloadWaitNextClose(t *testing.T) {
 	_, err = p.NextWriter(frame.Binary, packet.OPEN)
 	should.Equal(io.EOF, err)
 
-	err = p.FeedIn(bytes.NewReader([]byte("1:0")), false)
+	err = p.FeedIn(bytes.NewReader([]byte("0")))
 	should.Equal(io.EOF, err)
 
 	err = p.FlushOut(io.Discard)
@@ -241,7 +171,7 @@ func TestPayloadWaitNextClose(t *testing.T) {
 func TestPayloadWaitInOutClose(t *testing.T) {
 	should := assert.New(t)
 
-	p := New(true)
+	p := New()
 
 	var wg sync.WaitGroup
 
@@ -251,7 +181,7 @@ func TestPayloadWaitInOutClose(t *testing.T) {
 
 		should := assert.New(t)
 
-		err := p.FeedIn(bytes.NewReader([]byte("1:0")), false)
+		err := p.FeedIn(bytes.NewReader([]byte("0")))
 		should.Equal(io.EOF, err)
 	}()
 
@@ -277,7 +207,7 @@ func TestPayloadWaitInOutClose(t *testing.T) {
 	_, err = p.NextWriter(frame.Binary, packet.OPEN)
 	should.Equal(io.EOF, err)
 
-	err = p.FeedIn(bytes.NewReader([]byte("1:0")), false)
+	err = p.FeedIn(bytes.NewReader([]byte("0")))
 	should.Equal(io.EOF, err)
 
 	err = p.FlushOut(io.Discard)
@@ -287,7 +217,7 @@ func TestPayloadWaitInOutClose(t *testing.T) {
 func TestPayloadPauseClose(t *testing.T) {
 	should := assert.New(t)
 
-	p := New(true)
+	p := New()
 	p.Pause()
 
 	err := p.Close()
@@ -299,7 +229,7 @@ func TestPayloadPauseClose(t *testing.T) {
 	_, err = p.NextWriter(frame.Binary, packet.OPEN)
 	should.Equal(io.EOF, err)
 
-	err = p.FeedIn(bytes.NewReader([]byte("1:0")), false)
+	err = p.FeedIn(bytes.NewReader([]byte("0")))
 	should.Equal(io.EOF, err)
 
 	err = p.FlushOut(io.Discard)
@@ -309,7 +239,7 @@ func TestPayloadPauseClose(t *testing.T) {
 func TestPayloadNextPause(t *testing.T) {
 	should := assert.New(t)
 
-	p := New(true)
+	p := New()
 
 	var wg sync.WaitGroup
 	wg.Add(1)
@@ -355,7 +285,7 @@ func TestPayloadNextPause(t *testing.T) {
 	should.True(ok)
 	should.True(op.Temporary())
 
-	err = p.FeedIn(bytes.NewReader([]byte("1:0")), false)
+	err = p.FeedIn(bytes.NewReader([]byte("0")))
 	op, ok = err.(Error)
 	should.True(ok)
 	should.True(op.Temporary())
@@ -363,13 +293,13 @@ func TestPayloadNextPause(t *testing.T) {
 	b := bytes.NewBuffer(nil)
 	err = p.FlushOut(b)
 	should.Nil(err)
-	should.Equal([]byte{0x0, 0x1, 0xff, '6'}, b.Bytes())
+	should.Equal([]byte("6"), b.Bytes())
 }
 
 func TestPayloadInOutPause(t *testing.T) {
 	should := assert.New(t)
 
-	p := New(true)
+	p := New()
 
 	var wg sync.WaitGroup
 
@@ -378,7 +308,7 @@ func TestPayloadInOutPause(t *testing.T) {
 		defer wg.Done()
 
 		must := require.New(t)
-		err := p.FeedIn(bytes.NewReader([]byte("1:0")), false)
+		err := p.FeedIn(bytes.NewReader([]byte("0")))
 		must.Nil(err)
 	}()
 
@@ -392,7 +322,7 @@ func TestPayloadInOutPause(t *testing.T) {
 		b := bytes.NewBuffer(nil)
 		err := p.FlushOut(b)
 		must.Nil(err)
-		should.Equal([]byte{0x0, 0x1, 0xff, '6'}, b.Bytes())
+		should.Equal([]byte("6"), b.Bytes())
 	}()
 
 	go func() {
@@ -429,7 +359,7 @@ func TestPayloadInOutPause(t *testing.T) {
 	should.True(ok)
 	should.True(op.Temporary())
 
-	err = p.FeedIn(bytes.NewReader([]byte("1:0")), false)
+	err = p.FeedIn(bytes.NewReader([]byte("0")))
 	op, ok = err.(Error)
 	should.True(ok)
 	should.True(op.Temporary())
@@ -437,13 +367,13 @@ func TestPayloadInOutPause(t *testing.T) {
 	b := bytes.NewBuffer(nil)
 	err = p.FlushOut(b)
 	should.Nil(err)
-	should.Equal([]byte{0x0, 0x1, 0xff, '6'}, b.Bytes())
+	should.Equal([]byte("6"), b.Bytes())
 }
 
 func TestPayloadNextClosePause(t *testing.T) {
 	should := assert.New(t)
 
-	p := New(true)
+	p := New()
 
 	var wg sync.WaitGroup
 
@@ -452,7 +382,7 @@ func TestPayloadNextClosePause(t *testing.T) {
 		defer wg.Done()
 
 		must := require.New(t)
-		err := p.FeedIn(bytes.NewReader([]byte("1:0")), false)
+		err := p.FeedIn(bytes.NewReader([]byte("0")))
 		must.Nil(err)
 	}()
 
@@ -526,7 +456,7 @@ func TestPayloadNextClosePause(t *testing.T) {
 	should.True(ok)
 	should.True(op.Temporary())
 
-	err = p.FeedIn(bytes.NewReader([]byte("1:0")), false)
+	err = p.FeedIn(bytes.NewReader([]byte("0")))
 	op, ok = err.(Error)
 	should.True(ok)
 	should.True(op.Temporary())
@@ -534,5 +464,5 @@ func TestPayloadNextClosePause(t *testing.T) {
 	b := bytes.NewBuffer(nil)
 	err = p.FlushOut(b)
 	should.Nil(err)
-	should.Equal([]byte{0x0, 0x1, 0xff, '6'}, b.Bytes())
+	should.Equal([]byte("6"), b.Bytes())
 }
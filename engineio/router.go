@@ -0,0 +1,105 @@
+package engineio
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+
+	"github.com/vchitai/go-socket.io/v4/engineio/session"
+	"github.com/vchitai/go-socket.io/v4/logger"
+)
+
+// routedHeader marks the requests forwarded by a Router, which are always
+// served by the node receiving them to prevent forwarding loops.
+const routedHeader = "X-Engineio-Routed"
+
+// Router is an http.Handler routing engine.io requests to the node owning
+// their session, so that several nodes can serve the same clients behind a
+// load balancer without sticky sessions.
+//
+// Every node runs a Router in front of its own server, configured with
+// session.NodeIDGenerator. Handshakes and requests of the sessions of the node
+// are served locally, requests of the sessions of the peers are forwarded to
+// them with a reverse proxy, including websocket upgrades.
+type Router struct {
+	nodeID string
+	local  http.Handler
+	peers  map[string]*httputil.ReverseProxy
+}
+
+// NewRouter returns a router for the node nodeID serving its sessions with
+// local. peers maps the id of the other nodes to their base url.
+func NewRouter(nodeID string, local http.Handler, peers map[string]string) (*Router, error) {
+	if err := checkNodeID(nodeID); err != nil {
+		return nil, err
+	}
+
+	r := &Router{
+		nodeID: nodeID,
+		local:  local,
+		peers:  make(map[string]*httputil.ReverseProxy, len(peers)),
+	}
+
+	for peerID, peerURL := range peers {
+		if err := checkNodeID(peerID); err != nil {
+			return nil, err
+		}
+
+		target, err := url.Parse(peerURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid url of node %s: %w", peerID, err)
+		}
+
+		r.peers[peerID] = newPeerProxy(peerID, target)
+	}
+
+	return r, nil
+}
+
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if proxy, ok := r.peer(req); ok {
+		req.Header.Set(routedHeader, r.nodeID)
+		proxy.ServeHTTP(w, req)
+		return
+	}
+
+	r.local.ServeHTTP(w, req)
+}
+
+// peer gives the proxy to the node owning the session of req, if it is not
+// owned by this node.
+func (r *Router) peer(req *http.Request) (*httputil.ReverseProxy, bool) {
+	if req.Header.Get(routedHeader) != "" {
+		return nil, false
+	}
+
+	nodeID, ok := session.ParseNodeID(req.URL.Query().Get("sid"))
+	if !ok || nodeID == r.nodeID {
+		return nil, false
+	}
+
+	// unknown nodes are left to the local server, which rejects the sid
+	proxy, ok := r.peers[nodeID]
+	return proxy, ok
+}
+
+func newPeerProxy(peerID string, target *url.URL) *httputil.ReverseProxy {
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		ll := logger.GetLogger("engineio.router")
+		ll.Error(err, "forward to node:", "node", peerID)
+
+		http.Error(w, fmt.Sprintf("node %s unavailable", peerID), http.StatusBadGateway)
+	}
+
+	return proxy
+}
+
+func checkNodeID(nodeID string) error {
+	if nodeID == "" || strings.Contains(nodeID, session.NodeIDSeparator) {
+		return fmt.Errorf("invalid node id: %q", nodeID)
+	}
+	return nil
+}
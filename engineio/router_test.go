@@ -0,0 +1,156 @@
+package engineio
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vchitai/go-socket.io/v4/engineio/session"
+)
+
+func TestNodeIDGenerator(t *testing.T) {
+	should := assert.New(t)
+
+	g := &session.NodeIDGenerator{NodeID: "node1"}
+	sid := g.NewID()
+	should.Equal("node1.1", sid)
+
+	nodeID, ok := session.ParseNodeID(sid)
+	should.True(ok)
+	should.Equal("node1", nodeID)
+
+	_, ok = session.ParseNodeID("1")
+	should.False(ok)
+	_, ok = session.ParseNodeID(".1")
+	should.False(ok)
+}
+
+func TestRouter(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	nodeHandler := func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, name)
+		})
+	}
+
+	nodeB := httptest.NewServer(nodeHandler("b"))
+	defer nodeB.Close()
+
+	down := httptest.NewServer(nil)
+	down.Close()
+
+	router, err := NewRouter("a", nodeHandler("a"), map[string]string{
+		"b":    nodeB.URL,
+		"down": down.URL,
+	})
+	must.NoError(err)
+
+	nodeA := httptest.NewServer(router)
+	defer nodeA.Close()
+
+	tests := []struct {
+		query  string
+		header string
+		code   int
+		node   string
+	}{
+		{"transport=polling", "", http.StatusOK, "a"},
+		{"transport=polling&sid=a.1", "", http.StatusOK, "a"},
+		{"transport=polling&sid=b.1", "", http.StatusOK, "b"},
+		{"transport=polling&sid=b.1", "b", http.StatusOK, "a"},
+		{"transport=polling&sid=unknown.1", "", http.StatusOK, "a"},
+		{"transport=polling&sid=1", "", http.StatusOK, "a"},
+		{"transport=polling&sid=down.1", "", http.StatusBadGateway, "node down unavailable\n"},
+	}
+
+	for _, test := range tests {
+		req, err := http.NewRequest(http.MethodGet, nodeA.URL+"/?"+test.query, nil)
+		must.NoError(err)
+		if test.header != "" {
+			req.Header.Set(routedHeader, test.header)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		must.NoError(err)
+
+		body, err := io.ReadAll(resp.Body)
+		must.NoError(err)
+		must.NoError(resp.Body.Close())
+
+		should.Equal(test.code, resp.StatusCode, test.query)
+		should.Equal(test.node, string(body), test.query)
+	}
+}
+
+func TestRouterInvalidNodeID(t *testing.T) {
+	_, err := NewRouter("a.b", http.NotFoundHandler(), nil)
+	assert.Error(t, err)
+
+	_, err = NewRouter("a", http.NotFoundHandler(), map[string]string{"": "http://127.0.0.1"})
+	assert.Error(t, err)
+
+	_, err = NewRouter("a", http.NotFoundHandler(), map[string]string{"b": "://"})
+	assert.Error(t, err)
+}
+
+func TestRouterPollingSession(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	newNode := func(nodeID string) *Server {
+		return NewServer(&Options{
+			SessionIDGenerator: &session.NodeIDGenerator{NodeID: nodeID},
+		})
+	}
+
+	engineA, engineB := newNode("a"), newNode("b")
+	defer engineA.Close()
+	defer engineB.Close()
+
+	nodeB := httptest.NewServer(engineB)
+	defer nodeB.Close()
+
+	routerA, err := NewRouter("a", engineA, map[string]string{"b": nodeB.URL})
+	must.NoError(err)
+
+	nodeA := httptest.NewServer(routerA)
+	defer nodeA.Close()
+
+	// the handshake reaches node b, the next poll reaches node a
+	resp, err := http.Get(nodeB.URL + "/?EIO=4&transport=polling")
+	must.NoError(err)
+	body, err := io.ReadAll(resp.Body)
+	must.NoError(err)
+	must.NoError(resp.Body.Close())
+	must.Equal(http.StatusOK, resp.StatusCode, string(body))
+	should.Contains(string(body), `"sid":"b.1"`)
+
+	go func() {
+		conn, err := engineB.Accept()
+		if err != nil {
+			return
+		}
+
+		w, err := conn.NextWriter(session.TEXT)
+		if err != nil {
+			return
+		}
+		_, _ = io.WriteString(w, "from b")
+		_ = w.Close()
+	}()
+
+	resp, err = http.Get(nodeA.URL + "/?EIO=4&transport=polling&sid=b.1")
+	must.NoError(err)
+	body, err = io.ReadAll(resp.Body)
+	must.NoError(err)
+	must.NoError(resp.Body.Close())
+
+	should.Equal(http.StatusOK, resp.StatusCode)
+	should.Contains(string(body), "from b")
+}
@@ -2,32 +2,47 @@ package engineio
 
 import (
 	"context"
-	"fmt"
 	"io"
 	"net"
 	"net/http"
 	"sync"
 	"time"
 
-	"github.com/gorilla/websocket"
 	"github.com/vchitai/go-socket.io/v4/logger"
 
 	"github.com/vchitai/go-socket.io/v4/engineio/session"
 	"github.com/vchitai/go-socket.io/v4/engineio/transport"
 )
 
+// protocolVersion is the version of the Engine.IO protocol implemented by the
+// server, which the clients give in the EIO query parameter of every request.
+const protocolVersion = "4"
+
 // Server is instance of server
 type Server struct {
 	pingInterval time.Duration
 	pingTimeout  time.Duration
+	maxPayload   int
+
+	upgradeTimeout time.Duration
+	allowUpgrades  bool
 
 	transports *transport.Manager
 	sessions   *session.Manager
 
+	cors           *CORS
+	cookie         *http.Cookie
 	requestChecker CheckerFunc
 	connInitiator  ConnInitiatorFunc
+	errorHandler   ErrorHandlerFunc
+	upgradeHandler UpgradeHandlerFunc
+
+	onConnectionError func(r *http.Request, code int, msg string, ctx map[string]any)
+	onInitialHeaders  func(headers http.Header, r *http.Request)
+	onHeaders         func(headers http.Header, r *http.Request)
 
 	connChan  chan Conn
+	done      chan struct{}
 	closeOnce sync.Once
 }
 
@@ -37,28 +52,60 @@ func NewServer(opts *Options) *Server {
 		transports:     transport.NewManager(opts.getTransport()),
 		pingInterval:   opts.getPingInterval(),
 		pingTimeout:    opts.getPingTimeout(),
+		maxPayload:     opts.getMaxPayload(),
+		upgradeTimeout: opts.getUpgradeTimeout(),
+		allowUpgrades:  opts.getAllowUpgrades(),
+		cors:           opts.getCORS(),
+		cookie:         opts.getCookie(),
 		requestChecker: opts.getRequestChecker(),
 		connInitiator:  opts.getConnInitiator(),
+		errorHandler:   opts.getErrorHandler(),
+		upgradeHandler: opts.getUpgradeHandler(),
 		sessions:       session.NewManager(opts.getSessionIDGenerator()),
 		connChan:       make(chan Conn, 1),
+		done:           make(chan struct{}),
 	}
 }
 
 // Close closes server.
 func (s *Server) Close() error {
 	s.closeOnce.Do(func() {
-		close(s.connChan)
+		close(s.done)
 	})
 	return nil
 }
 
 // Accept accepts a connection.
 func (s *Server) Accept() (Conn, error) {
-	c := <-s.connChan
-	if c == nil {
+	select {
+	case c := <-s.connChan:
+		return c, nil
+	case <-s.done:
 		return nil, io.EOF
 	}
-	return c, nil
+}
+
+// OnConnectionError sets the hook called with the requests rejected by the
+// server, with the code and message of their error and its context: unknown
+// transports and sessions, rejected origins and requests of the request
+// checker, and the transport handshakes and upgrades which fail. It must be
+// set before the server serves requests.
+func (s *Server) OnConnectionError(f func(r *http.Request, code int, msg string, ctx map[string]any)) {
+	s.onConnectionError = f
+}
+
+// OnInitialHeaders sets the hook called with the headers of the handshake
+// responses, before they are written. It must be set before the server
+// serves requests.
+func (s *Server) OnInitialHeaders(f func(headers http.Header, r *http.Request)) {
+	s.onInitialHeaders = f
+}
+
+// OnHeaders sets the hook called with the headers of every response of the
+// sessions, the handshake included, before they are written. It must be set
+// before the server serves requests.
+func (s *Server) OnHeaders(f func(headers http.Header, r *http.Request)) {
+	s.onHeaders = f
 }
 
 func (s *Server) Addr() net.Addr {
@@ -66,18 +113,35 @@ func (s *Server) Addr() net.Addr {
 }
 
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.cors != nil {
+		if err := s.cors.check(r); err != nil {
+			s.abort(w, r, err)
+			return
+		}
+
+		var ok bool
+		if r, ok = s.cors.serve(w, r); !ok {
+			return
+		}
+	}
+
 	query := r.URL.Query()
 
 	reqTransport := query.Get("transport")
 	srvTransport, ok := s.transports.Get(reqTransport)
 	if !ok || srvTransport == nil {
-		http.Error(w, fmt.Sprintf("invalid transport: %s", srvTransport), http.StatusBadRequest)
+		s.abort(w, r, NewError(UnknownTransport, map[string]any{"transport": reqTransport}))
+		return
+	}
+
+	if version := query.Get("EIO"); version != protocolVersion {
+		s.abort(w, r, NewError(UnsupportedProtocolVersion, map[string]any{"protocol": version}))
 		return
 	}
 
 	header, err := s.requestChecker(r)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("request checker err: %s", err.Error()), http.StatusBadGateway)
+		s.abort(w, r, checkerError(err))
 		return
 	}
 
@@ -90,48 +154,127 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// if we can't find session in current session pool, let's create this. behaviour for new connections
 	if !ok || reqSession == nil {
 		if sid != "" {
-			http.Error(w, fmt.Sprintf("invalid sid value: %s", sid), http.StatusBadRequest)
+			s.abort(w, r, NewError(UnknownSID, map[string]any{"sid": sid}))
 			return
 		}
 
-		transportConn, err := srvTransport.Accept(w, r)
+		if !handshakeMethod(r.Method) {
+			s.abort(w, r, NewError(BadHandshakeMethod, map[string]any{"method": r.Method}))
+			return
+		}
+
+		// the id is known before accepting the connection, so that the
+		// handshake responses written on accept set its cookie
+		sid = s.sessions.NewID()
+		s.setHeaders(w, r, true)
+
+		acceptW, cw := s.acceptWriter(w, sid)
+		transportConn, err := srvTransport.Accept(acceptW, r)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("transport accept err: %s", err.Error()), http.StatusBadGateway)
+			s.acceptError(w, r, err)
+			return
+		}
+
+		// the connection may give the session it upgrades once accepted
+		if joiner, ok := transportConn.(transport.SessionJoiner); ok && joiner.SessionID() != "" {
+			s.joinSession(w, r, reqTransport, joiner.SessionID(), transportConn)
 			return
 		}
 
-		reqSession, err = s.newSession(r.Context(), transportConn, reqTransport)
+		reqSession, err = s.newSession(r.Context(), transportConn, sid, reqTransport)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("create new session err: %s", err.Error()), http.StatusBadRequest)
+			s.abort(w, r, NewError(BadRequest, map[string]any{"message": err.Error()}))
 			return
 		}
+		if !cw.cookieSet() {
+			s.setCookie(w, sid)
+		}
 
 		s.connInitiator(r, reqSession)
+	} else {
+		s.setHeaders(w, r, false)
 	}
 
 	// try upgrade current connection
-	if reqSession.Transport() != reqTransport {
+	if current := reqSession.Transport(); current != reqTransport {
+		if !s.canUpgrade(current, reqTransport) {
+			s.abort(w, r, NewError(BadRequest, map[string]any{"message": "invalid upgrade", "from": current, "to": reqTransport}))
+			return
+		}
+
 		transportConn, err := srvTransport.Accept(w, r)
 		if err != nil {
-			// don't call http.Error() for HandshakeErrors because
-			// they get handled by the websocket library internally.
-			if _, ok := err.(websocket.HandshakeError); !ok {
-				http.Error(w, err.Error(), http.StatusBadGateway)
-			}
+			s.acceptError(w, r, err)
 			return
 		}
 
-		reqSession.Upgrade(reqTransport, transportConn)
-
-		if handler, ok := transportConn.(http.Handler); ok {
-			handler.ServeHTTP(w, r)
-		}
+		s.upgrade(w, r, reqSession, reqTransport, transportConn)
 		return
 	}
 
 	reqSession.ServeHTTP(w, r)
 }
 
+// handshakeMethod tells whether a session can be opened by a request of
+// method. The preflight requests of the handshake are answered by the
+// transports when the server has no CORS, and the sessions of HTTP/3 open
+// with an extended CONNECT.
+func handshakeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodOptions, http.MethodConnect:
+		return true
+	}
+	return false
+}
+
+// joinSession upgrades the session of sid to an accepted connection, which is
+// closed when the session cannot be upgraded.
+func (s *Server) joinSession(w http.ResponseWriter, r *http.Request, reqTransport, sid string, conn transport.Conn) {
+	reqSession, ok := s.sessions.Get(sid)
+	if !ok || reqSession == nil {
+		s.connectionError(r, NewError(UnknownSID, map[string]any{"sid": sid}))
+		_ = conn.Close()
+		return
+	}
+	if current := reqSession.Transport(); !s.canUpgrade(current, reqTransport) {
+		s.connectionError(r, NewError(BadRequest, map[string]any{"message": "invalid upgrade", "from": current, "to": reqTransport}))
+		_ = conn.Close()
+		return
+	}
+
+	s.upgrade(w, r, reqSession, reqTransport, conn)
+}
+
+func (s *Server) upgrade(w http.ResponseWriter, r *http.Request, reqSession *session.Session, reqTransport string, conn transport.Conn) {
+	reqSession.Upgrade(reqTransport, conn)
+
+	if handler, ok := conn.(http.Handler); ok {
+		handler.ServeHTTP(w, r)
+	}
+}
+
+// setHeaders calls the hooks of the headers of a response, the initial one
+// for a handshake response.
+func (s *Server) setHeaders(w http.ResponseWriter, r *http.Request, handshake bool) {
+	if handshake && s.onInitialHeaders != nil {
+		s.onInitialHeaders(w.Header(), r)
+	}
+	if s.onHeaders != nil {
+		s.onHeaders(w.Header(), r)
+	}
+}
+
+// canUpgrade tells whether a session can be upgraded from its transport to
+// another one, the sessions never going back to a previous transport.
+func (s *Server) canUpgrade(from, to string) bool {
+	for _, name := range s.upgradesFrom(from) {
+		if name == to {
+			return true
+		}
+	}
+	return false
+}
+
 // Count counts connected
 func (s *Server) Count() int {
 	return s.sessions.Count()
@@ -142,31 +285,52 @@ func (s *Server) Remove(sid string) {
 	s.sessions.Remove(sid)
 }
 
-func (s *Server) newSession(_ context.Context, conn transport.Conn, reqTransport string) (*session.Session, error) {
+// upgradesFrom returns the transports the sessions of a transport can upgrade
+// to, none when the upgrades are not allowed.
+func (s *Server) upgradesFrom(name string) []string {
+	if !s.allowUpgrades {
+		return []string{}
+	}
+	return s.transports.UpgradeFrom(name)
+}
+
+func (s *Server) newSession(_ context.Context, conn transport.Conn, sid, reqTransport string) (*session.Session, error) {
 	params := transport.ConnParameters{
 		PingInterval: s.pingInterval,
 		PingTimeout:  s.pingTimeout,
-		MaxPayload:   1e6,
-		Upgrades:     s.transports.UpgradeFrom(reqTransport),
+		MaxPayload:   s.maxPayload,
+		Upgrades:     s.upgradesFrom(reqTransport),
 	}
 
-	sid := s.sessions.NewID()
 	newSession, err := session.New(conn, sid, reqTransport, params)
 	if err != nil {
 		return nil, err
 	}
+	newSession.SetErrorHandler(func(err error) {
+		s.errorHandler(newSession, err)
+	})
+	newSession.SetUpgradeTimeout(s.upgradeTimeout)
+	newSession.SetUpgradeHandler(func(event session.UpgradeEvent) {
+		s.upgradeHandler(newSession, event)
+	})
+
+	// the session is known before the client receives its id
+	s.sessions.Add(newSession)
 
 	go func(newSession *session.Session) {
 		var ll = logger.GetLogger("engineio.server")
-		if err = newSession.InitSession(); err != nil {
+		if err := newSession.InitSession(); err != nil {
 			ll.Error(err, "init new session:")
+			s.sessions.Remove(newSession.ID())
 
 			return
 		}
 
-		s.sessions.Add(newSession)
-
-		s.connChan <- newSession
+		select {
+		case s.connChan <- newSession:
+		case <-s.done:
+			_ = newSession.Close()
+		}
 	}(newSession)
 
 	return newSession, nil
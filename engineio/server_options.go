@@ -16,11 +16,36 @@ type Options struct {
 	PingTimeout  time.Duration
 	PingInterval time.Duration
 
+	// MaxPayload is the max size in bytes of the payloads read from the
+	// clients, the POST requests of the polling transport and the messages
+	// of the other transports. It is 1MB by default.
+	MaxPayload int
+
+	// UpgradeTimeout is the timeout of the upgrades of the sessions, from
+	// the probe of the new transport until its upgrade packet. It is 10
+	// seconds by default.
+	UpgradeTimeout time.Duration
+	// AllowUpgrades allows the sessions to upgrade their transport. The
+	// upgrades are allowed when it is nil.
+	AllowUpgrades *bool
+
 	Transports         []transport.Transport
 	SessionIDGenerator session.IDGenerator
 
+	// CORS is the cross-origin configuration of the server. The origins are
+	// checked by the transports when it is nil.
+	CORS *CORS
+
+	// Cookie is the cookie holding the id of the sessions, set on their
+	// handshake response once they are created. The websocket handshake is
+	// answered before the session is created, and sets it too. No cookie is
+	// set when it is nil.
+	Cookie *CookieOptions
+
 	RequestChecker CheckerFunc
 	ConnInitiator  ConnInitiatorFunc
+	ErrorHandler   ErrorHandlerFunc
+	UpgradeHandler UpgradeHandlerFunc
 }
 
 func (c *Options) getRequestChecker() CheckerFunc {
@@ -37,6 +62,57 @@ func (c *Options) getConnInitiator() ConnInitiatorFunc {
 	return defaultInitiator
 }
 
+func (c *Options) getCORS() *CORS {
+	if c != nil {
+		return c.CORS
+	}
+	return nil
+}
+
+// getCookie returns the cookie with its defaults, its value being set for
+// each session.
+func (c *Options) getCookie() *http.Cookie {
+	if c == nil || c.Cookie == nil {
+		return nil
+	}
+	return c.Cookie.httpCookie()
+}
+
+func (c *Options) getErrorHandler() ErrorHandlerFunc {
+	if c != nil && c.ErrorHandler != nil {
+		return c.ErrorHandler
+	}
+	return defaultErrorHandler
+}
+
+func (c *Options) getUpgradeHandler() UpgradeHandlerFunc {
+	if c != nil && c.UpgradeHandler != nil {
+		return c.UpgradeHandler
+	}
+	return defaultUpgradeHandler
+}
+
+func (c *Options) getUpgradeTimeout() time.Duration {
+	if c != nil && c.UpgradeTimeout > 0 {
+		return c.UpgradeTimeout
+	}
+	return 10 * time.Second
+}
+
+func (c *Options) getAllowUpgrades() bool {
+	if c != nil && c.AllowUpgrades != nil {
+		return *c.AllowUpgrades
+	}
+	return true
+}
+
+func (c *Options) getMaxPayload() int {
+	if c != nil && c.MaxPayload > 0 {
+		return c.MaxPayload
+	}
+	return 1e6
+}
+
 func (c *Options) getPingTimeout() time.Duration {
 	if c != nil && c.PingTimeout != 0 {
 		return c.PingTimeout
@@ -73,3 +149,7 @@ func defaultChecker(*http.Request) (http.Header, error) {
 }
 
 func defaultInitiator(*http.Request, Conn) {}
+
+func defaultErrorHandler(Conn, error) {}
+
+func defaultUpgradeHandler(Conn, session.UpgradeEvent) {}
@@ -9,7 +9,9 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
+	gorilla "github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -232,7 +234,7 @@ func TestEngineUpgrade(t *testing.T) {
 	must.Nil(err)
 
 	query := u.Query()
-	query.Set("EIO", "3")
+	query.Set("EIO", "4")
 	u.RawQuery = query.Encode()
 
 	p, err := polling.Default.Dial(u, nil)
@@ -323,3 +325,133 @@ func TestEngineUpgrade(t *testing.T) {
 
 	must.Nil(ws.Close())
 }
+
+func TestServerCookie(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	_, url := newConformanceServerOptions(t, &Options{
+		Cookie: &CookieOptions{
+			Secure: true,
+			MaxAge: time.Minute,
+			Domain: "example.com",
+		},
+	})
+
+	// the polling handshake sets the cookie of the session, HttpOnly by
+	// default
+	resp, err := http.Get(url + "/?EIO=4&transport=polling")
+	must.NoError(err)
+	body, err := io.ReadAll(resp.Body)
+	must.NoError(err)
+	must.NoError(resp.Body.Close())
+
+	params, err := transport.ReadConnParameters(strings.NewReader(string(body[1:])))
+	must.NoError(err)
+
+	cookies := resp.Cookies()
+	must.Len(cookies, 1)
+	should.Equal("io", cookies[0].Name)
+	should.Equal(params.SID, cookies[0].Value)
+	should.Equal("/", cookies[0].Path)
+	should.Equal("example.com", cookies[0].Domain)
+	should.Equal(60, cookies[0].MaxAge)
+	should.True(cookies[0].HttpOnly)
+	should.True(cookies[0].Secure)
+	should.Equal(http.SameSiteLaxMode, cookies[0].SameSite)
+
+	// the next requests of the session do not
+	resp, err = http.Post(pollingURL(url, params.SID), "text/plain;charset=UTF-8", strings.NewReader("1"))
+	must.NoError(err)
+	must.NoError(resp.Body.Close())
+	should.Empty(resp.Cookies())
+
+	// the websocket handshake sets it too
+	ws, resp, err := gorilla.DefaultDialer.Dial(websocketURL(url, ""), nil)
+	must.NoError(err)
+	defer ws.Close()
+	_, msg, err := ws.ReadMessage()
+	must.NoError(err)
+
+	params, err = transport.ReadConnParameters(strings.NewReader(string(msg[1:])))
+	must.NoError(err)
+	cookies = resp.Cookies()
+	must.Len(cookies, 1)
+	should.Equal(params.SID, cookies[0].Value)
+
+	// the handshakes failing create no session, and set no cookie
+	resp, err = http.Get(url + "/?EIO=4&transport=websocket")
+	must.NoError(err)
+	must.NoError(resp.Body.Close())
+	should.Equal(http.StatusBadRequest, resp.StatusCode)
+	should.Empty(resp.Cookies())
+
+	resp, err = http.Get(url + "/?EIO=4&transport=polling&sid=unknown")
+	must.NoError(err)
+	must.NoError(resp.Body.Close())
+	should.Equal(http.StatusBadRequest, resp.StatusCode)
+	should.Empty(resp.Cookies())
+
+	// the cookie can be readable from the scripts
+	httpOnly := false
+	_, url = newConformanceServerOptions(t, &Options{
+		Cookie: &CookieOptions{Name: "sid", Path: "/io", SameSite: http.SameSiteStrictMode, HTTPOnly: &httpOnly},
+	})
+
+	resp, err = http.Get(url + "/?EIO=4&transport=polling")
+	must.NoError(err)
+	must.NoError(resp.Body.Close())
+
+	cookies = resp.Cookies()
+	must.Len(cookies, 1)
+	should.Equal("sid", cookies[0].Name)
+	should.Equal("/io", cookies[0].Path)
+	should.Equal(http.SameSiteStrictMode, cookies[0].SameSite)
+	should.False(cookies[0].HttpOnly)
+}
+
+func TestServerHeaders(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	svr, url := newConformanceServer(t)
+	svr.OnInitialHeaders(func(headers http.Header, r *http.Request) {
+		headers.Set("X-Initial", r.URL.Query().Get("transport"))
+	})
+	svr.OnHeaders(func(headers http.Header, r *http.Request) {
+		headers.Set("X-Every", r.Method)
+	})
+
+	// the polling handshake has both headers
+	resp, err := http.Get(url + "/?EIO=4&transport=polling")
+	must.NoError(err)
+	body, err := io.ReadAll(resp.Body)
+	must.NoError(err)
+	must.NoError(resp.Body.Close())
+	should.Equal("polling", resp.Header.Get("X-Initial"))
+	should.Equal(http.MethodGet, resp.Header.Get("X-Every"))
+
+	params, err := transport.ReadConnParameters(strings.NewReader(string(body[1:])))
+	must.NoError(err)
+
+	// the next requests of the session have the headers of every response
+	resp, err = http.Post(pollingURL(url, params.SID), "text/plain;charset=UTF-8", strings.NewReader("1"))
+	must.NoError(err)
+	must.NoError(resp.Body.Close())
+	should.Empty(resp.Header.Get("X-Initial"))
+	should.Equal(http.MethodPost, resp.Header.Get("X-Every"))
+
+	// the rejected requests have none
+	resp, err = http.Get(url + "/?EIO=4&transport=unknown")
+	must.NoError(err)
+	must.NoError(resp.Body.Close())
+	should.Empty(resp.Header.Get("X-Initial"))
+	should.Empty(resp.Header.Get("X-Every"))
+
+	// the websocket handshake has both headers
+	ws, resp, err := gorilla.DefaultDialer.Dial(websocketURL(url, ""), nil)
+	must.NoError(err)
+	defer ws.Close()
+	should.Equal("websocket", resp.Header.Get("X-Initial"))
+	should.Equal(http.MethodGet, resp.Header.Get("X-Every"))
+}
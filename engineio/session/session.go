@@ -1,6 +1,7 @@
 package session
 
 import (
+	"errors"
 	"io"
 	"net"
 	"net/http"
@@ -26,7 +27,11 @@ type Session struct {
 	params    transport.ConnParameters
 	transport string
 
-	context interface{}
+	context   interface{}
+	onError   func(err error)
+	onUpgrade func(event UpgradeEvent)
+
+	upgradeTimeout time.Duration
 
 	readDeadline *time.Timer
 	readDdlLock  sync.Mutex
@@ -48,6 +53,7 @@ func New(conn transport.Conn, sid, transport string, params transport.ConnParame
 		conn:      conn,
 		params:    params,
 	}
+	ses.setMaxPayload(conn)
 
 	if err := ses.resetDeadlines(); err != nil {
 		// If we cannot reset the deadlines, close the session
@@ -90,6 +96,26 @@ func (s *Session) Context() interface{} {
 	return s.context
 }
 
+// SetErrorHandler sets the handler called with the errors of the client
+// breaking the protocol, such as transport.ErrPayloadTooLarge, before the
+// session is closed.
+func (s *Session) SetErrorHandler(handler func(err error)) {
+	s.onError = handler
+}
+
+// SetUpgradeTimeout sets the timeout of the upgrades of the session, from the
+// probe of the new connection until its upgrade packet. It is the ping
+// timeout by default.
+func (s *Session) SetUpgradeTimeout(timeout time.Duration) {
+	s.upgradeTimeout = timeout
+}
+
+// SetUpgradeHandler sets the handler called with the events of the upgrades
+// of the session.
+func (s *Session) SetUpgradeHandler(handler func(event UpgradeEvent)) {
+	s.onUpgrade = handler
+}
+
 func (s *Session) ID() string {
 	return s.params.SID
 }
@@ -118,12 +144,13 @@ func (s *Session) NextReader() (FrameType, io.ReadCloser, error) {
 	for {
 		ft, pt, r, err := s.nextReader()
 		if err != nil {
+			if errors.Is(err, transport.ErrPayloadTooLarge) && s.onError != nil {
+				s.onError(err)
+			}
 			_ = s.Close()
 			return 0, nil, err
 		}
 
-		s.releasePongReadline()
-
 		// if the packet is message type, delegate for above
 		if pt == packet.MESSAGE {
 			// Caller must Close the ReadCloser to unlock the connection's
@@ -138,42 +165,23 @@ func (s *Session) NextReader() (FrameType, io.ReadCloser, error) {
 			}()
 
 			switch pt {
-			case packet.PING:
-				// Respond to a ping with a pong.
-				err := func() error {
-					w, err := s.nextWriter(ft, packet.PONG)
-					if err != nil {
-						return err
-					}
-					defer func() {
-						_ = w.Close() // unlocks the wrapped connection's FrameWriter
-					}()
-
-					// echo the pong
-					_, err = io.Copy(w, r)
-					return err
-				}()
-
-				if err != nil {
-					// If we cannot pong back close the connection
-					_ = s.Close()
-					return err
-				}
+			case packet.PONG:
+				// the client answered the last ping
+				s.releasePongReadline()
 
 			case packet.CLOSE:
-				_ = s.Close()
 				return io.EOF
 
-			case packet.PONG:
-
-			case packet.MESSAGE:
-
 			default:
-				// Unknown packet type. Close reader and try again.
+				// The pings are sent by the server only, ignore the other
+				// packets and try again.
 			}
 			return nil
 		}()
 		if err != nil {
+			// the client closed the session, which is closed once the packet
+			// is read so that the transport answers the request sending it
+			_ = s.Close()
 			return 0, nil, err
 		}
 	}
@@ -258,8 +266,6 @@ func (s *Session) doHealthCheck() {
 		case <-time.After(s.params.PingInterval):
 		}
 
-		s.setPongReadline(time.Now().Add(s.params.PingInterval + s.params.PingTimeout))
-
 		s.upgradeLocker.RLock()
 		conn := s.conn
 		s.upgradeLocker.RUnlock()
@@ -277,6 +283,10 @@ func (s *Session) doHealthCheck() {
 			return
 		}
 
+		// the client has to answer with a pong within the ping timeout, the
+		// deadline being set before the pong can be read
+		s.setPongReadline(time.Now().Add(s.params.PingTimeout))
+
 		if err := w.Close(); err != nil {
 			ll.Error(err, "failed to close ping writer")
 			_ = conn.Close()
@@ -291,14 +301,17 @@ func (s *Session) nextReader() (frame.Type, packet.Type, io.ReadCloser, error) {
 		conn := s.conn
 		s.upgradeLocker.RUnlock()
 
-		// Expect the next read will come in the ping timeout
-		_ = conn.SetReadDeadline(time.Now().Add(s.params.PingTimeout))
+		// Expect the next read before the pong of the next ping is late
+		_ = conn.SetReadDeadline(time.Now().Add(s.params.PingInterval + s.params.PingTimeout))
 
 		ft, pt, r, err := conn.NextReader()
 		if err != nil {
 			if op, ok := err.(payload.Error); ok && op.Temporary() {
 				continue
 			}
+			if s.replaced(conn) {
+				continue
+			}
 			return 0, 0, nil, err
 		}
 		return ft, pt, r, nil
@@ -316,11 +329,15 @@ func (s *Session) nextWriter(ft frame.Type, pt packet.Type) (io.WriteCloser, err
 			if op, ok := err.(payload.Error); ok && op.Temporary() {
 				continue
 			}
+			if s.replaced(conn) {
+				continue
+			}
 			return nil, err
 		}
 
-		// Set the deadline for writing operation
-		_ = conn.SetWriteDeadline(time.Now().Add(s.params.PingTimeout))
+		// Expect the next write to be flushed before the pong of the next
+		// ping is late
+		_ = conn.SetWriteDeadline(time.Now().Add(s.params.PingInterval + s.params.PingTimeout))
 
 		// Caller must Close the WriteCloser to unlock the connection's
 		// FrameWriter when finished writing.
@@ -328,11 +345,28 @@ func (s *Session) nextWriter(ft frame.Type, pt packet.Type) (io.WriteCloser, err
 	}
 }
 
+// replaced tells whether conn was closed by an upgrade replacing it, the
+// reads and writes being retried with the new connection.
+func (s *Session) replaced(conn transport.Conn) bool {
+	s.upgradeLocker.RLock()
+	defer s.upgradeLocker.RUnlock()
+
+	return s.conn != conn
+}
+
+// setMaxPayload limits the size of the payloads read by conn to the max
+// payload of the session
+func (s *Session) setMaxPayload(conn transport.Conn) {
+	if l, ok := conn.(transport.PayloadLimiter); ok && s.params.MaxPayload > 0 {
+		l.SetMaxPayload(s.params.MaxPayload)
+	}
+}
+
 func (s *Session) resetDeadlines() error {
 	s.upgradeLocker.RLock()
 	defer s.upgradeLocker.RUnlock()
 
-	deadline := time.Now().Add(s.params.PingTimeout)
+	deadline := time.Now().Add(s.params.PingInterval + s.params.PingTimeout)
 
 	err := s.conn.SetReadDeadline(deadline)
 	if err != nil {
@@ -343,22 +377,40 @@ func (s *Session) resetDeadlines() error {
 }
 
 func (s *Session) upgrading(t string, conn transport.Conn) {
-	// Read a ping from the client.
-	err := conn.SetReadDeadline(time.Now().Add(s.params.PingTimeout))
-	if err != nil {
+	from := s.Transport()
+	s.emitUpgrade(UpgradeEvent{Type: UpgradeStart, From: from, To: t})
+
+	if err := s.probe(t, conn); err != nil {
 		_ = conn.Close()
+		s.emitUpgrade(UpgradeEvent{Type: UpgradeFailure, From: from, To: t, Err: upgradeError(err)})
 		return
 	}
 
+	s.emitUpgrade(UpgradeEvent{Type: UpgradeSuccess, From: from, To: t})
+}
+
+// probe answers the ping probing conn then swaps it with the connection of
+// the session once the client sends the upgrade packet, the whole exchange
+// being done before the upgrade timeout.
+func (s *Session) probe(t string, conn transport.Conn) error {
+	s.setMaxPayload(conn)
+
+	deadline := time.Now().Add(s.getUpgradeTimeout())
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		return err
+	}
+	if err := conn.SetWriteDeadline(deadline); err != nil {
+		return err
+	}
+
+	// Read a ping from the client.
 	ft, pt, r, err := conn.NextReader()
 	if err != nil {
-		_ = conn.Close()
-		return
+		return err
 	}
 	if pt != packet.PING {
 		_ = r.Close()
-		_ = conn.Close()
-		return
+		return ErrUpgradeUnexpectedPacket
 	}
 
 	// Wait to close the reader until after data is read and echoed in the reply.
@@ -366,50 +418,34 @@ func (s *Session) upgrading(t string, conn transport.Conn) {
 	w, err := conn.NextWriter(ft, packet.PONG)
 	if err != nil {
 		_ = r.Close()
-		_ = conn.Close()
-		return
-	}
-
-	err = conn.SetWriteDeadline(time.Now().Add(s.params.PingTimeout))
-	if err != nil {
-		_ = w.Close()
-		_ = r.Close()
-		_ = conn.Close()
-		return
+		return err
 	}
 
 	// echo
 	if _, err = io.Copy(w, r); err != nil {
 		_ = w.Close()
 		_ = r.Close()
-		_ = conn.Close()
-		return
+		return err
 	}
 	if err = r.Close(); err != nil {
 		_ = w.Close()
-		_ = conn.Close()
-		return
+		return err
 	}
 	if err = w.Close(); err != nil {
-		_ = conn.Close()
-		return
+		return err
 	}
 
-	// Pause the old connection.
 	s.upgradeLocker.RLock()
 	old := s.conn
 	s.upgradeLocker.RUnlock()
 
+	// Pause the old connection when it buffers its packets, the others
+	// keep sending theirs until they are swapped.
 	p, ok := old.(Pauser)
-	if !ok {
-		// old transport doesn't support upgrading, close it
-		_ = conn.Close()
-		return
+	if ok {
+		p.Pause()
 	}
 
-	// if support upgrading, pause it
-	p.Pause()
-
 	// Prepare to resume the connection if upgrade fails.
 	defer func() {
 		if p != nil {
@@ -418,27 +454,18 @@ func (s *Session) upgrading(t string, conn transport.Conn) {
 	}()
 
 	// Check for upgrade packet from the client.
-	err = conn.SetReadDeadline(time.Now().Add(s.params.PingTimeout))
-	if err != nil {
-		_ = conn.Close()
-		return
-	}
-
 	_, pt, r, err = conn.NextReader()
 	if err != nil {
-		_ = conn.Close()
-		return
+		return err
 	}
 
 	if pt != packet.UPGRADE {
 		_ = r.Close()
-		_ = conn.Close()
-		return
+		return ErrUpgradeUnexpectedPacket
 	}
 
 	if err = r.Close(); err != nil {
-		_ = conn.Close()
-		return
+		return err
 	}
 
 	// Successful upgrade.
@@ -450,4 +477,18 @@ func (s *Session) upgrading(t string, conn transport.Conn) {
 	p = nil
 
 	_ = old.Close()
+	return nil
+}
+
+func (s *Session) emitUpgrade(event UpgradeEvent) {
+	if s.onUpgrade != nil {
+		s.onUpgrade(event)
+	}
+}
+
+func (s *Session) getUpgradeTimeout() time.Duration {
+	if s.upgradeTimeout > 0 {
+		return s.upgradeTimeout
+	}
+	return s.params.PingTimeout
 }
@@ -2,6 +2,7 @@ package session
 
 import (
 	"strconv"
+	"strings"
 	"sync/atomic"
 )
 
@@ -22,3 +23,31 @@ func (g *DefaultIDGenerator) NewID() string {
 	id := atomic.AddUint64(&g.ID, 1)
 	return strconv.FormatUint(id, 36)
 }
+
+// NodeIDSeparator separates the node id from the counter in the session ids
+// generated by NodeIDGenerator.
+const NodeIDSeparator = "."
+
+// NodeIDGenerator generates session ids prefixed by the id of the node, so
+// that the requests of a session can be routed to the node owning it when
+// several nodes serve the same clients, see engineio.Router.
+// NodeID must not contain NodeIDSeparator.
+type NodeIDGenerator struct {
+	NodeID string
+	ID     uint64
+}
+
+func (g *NodeIDGenerator) NewID() string {
+	id := atomic.AddUint64(&g.ID, 1)
+	return g.NodeID + NodeIDSeparator + strconv.FormatUint(id, 36)
+}
+
+// ParseNodeID gives the id of the node which generated sid with a
+// NodeIDGenerator.
+func ParseNodeID(sid string) (string, bool) {
+	nodeID, _, ok := strings.Cut(sid, NodeIDSeparator)
+	if !ok || nodeID == "" {
+		return "", false
+	}
+	return nodeID, true
+}
@@ -0,0 +1,62 @@
+package session
+
+import (
+	"errors"
+	"os"
+)
+
+var (
+	// ErrUpgradeTimeout is the reason of the upgrades which are not done
+	// within the upgrade timeout.
+	ErrUpgradeTimeout = errors.New("upgrade timeout")
+	// ErrUpgradeUnexpectedPacket is the reason of the upgrades which the
+	// client does not probe with a ping then finish with an upgrade packet.
+	ErrUpgradeUnexpectedPacket = errors.New("unexpected packet during upgrade")
+)
+
+// UpgradeEventType is the type of an upgrade event.
+type UpgradeEventType int
+
+const (
+	// UpgradeStart is emitted when a connection probes the upgrade.
+	UpgradeStart UpgradeEventType = iota
+	// UpgradeSuccess is emitted once the session uses the new connection.
+	UpgradeSuccess
+	// UpgradeFailure is emitted when the new connection is closed, the
+	// session keeping its transport.
+	UpgradeFailure
+)
+
+func (t UpgradeEventType) String() string {
+	switch t {
+	case UpgradeStart:
+		return "start"
+	case UpgradeSuccess:
+		return "success"
+	case UpgradeFailure:
+		return "failure"
+	}
+	return "unknown"
+}
+
+// UpgradeEvent is an event of the upgrade of a session from a transport to
+// another.
+type UpgradeEvent struct {
+	Type UpgradeEventType
+	From string
+	To   string
+	// Err is the reason of a failure.
+	Err error
+}
+
+// upgradeError returns the reason of an upgrade failing with err.
+func upgradeError(err error) error {
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return ErrUpgradeTimeout
+	}
+	var timeout interface{ Timeout() bool }
+	if errors.As(err, &timeout) && timeout.Timeout() {
+		return ErrUpgradeTimeout
+	}
+	return err
+}
@@ -4,3 +4,7 @@ import "errors"
 
 // ErrInvalidFrame is returned when writing invalid frame type.
 var ErrInvalidFrame = errors.New("invalid frame type")
+
+// ErrPayloadTooLarge is returned when reading a payload larger than the max
+// payload of the connection.
+var ErrPayloadTooLarge = errors.New("payload too large")
@@ -3,8 +3,17 @@ package transport
 import (
 	"net/http"
 	"net/url"
+	"sort"
 )
 
+// upgradeOrder is the order of the upgrades between the known transports,
+// the other ones being upgraded to last.
+var upgradeOrder = map[string]int{
+	"polling":      0,
+	"websocket":    1,
+	"webtransport": 2,
+}
+
 // Transport is a transport which can creates base.Conn
 type Transport interface {
 	Name() string
@@ -18,7 +27,8 @@ type Manager struct {
 	transports map[string]Transport
 }
 
-// NewManager creates a new manager.
+// NewManager creates a new manager. The transports are upgraded in their
+// given order, the known ones being sorted in their upgradeOrder.
 func NewManager(transports []Transport) *Manager {
 	tranMap := make(map[string]Transport)
 	names := make([]string, len(transports))
@@ -27,6 +37,10 @@ func NewManager(transports []Transport) *Manager {
 		tranMap[t.Name()] = t
 	}
 
+	sort.SliceStable(names, func(i, j int) bool {
+		return rank(names[i]) < rank(names[j])
+	})
+
 	return &Manager{
 		order:      names,
 		transports: tranMap,
@@ -49,3 +63,10 @@ func (m *Manager) Get(name string) (Transport, bool) {
 	t, ok := m.transports[name]
 	return t, ok
 }
+
+func rank(name string) int {
+	if r, ok := upgradeOrder[name]; ok {
+		return r
+	}
+	return len(upgradeOrder)
+}
@@ -56,3 +56,18 @@ func TestManager(t *testing.T) {
 	names = m.UpgradeFrom("not_ exist")
 	at.Nil(names)
 }
+
+func TestManagerUpgradeOrder(t *testing.T) {
+	at := assert.New(t)
+
+	m := NewManager([]Transport{
+		fakeTransport{"webtransport"},
+		fakeTransport{"custom"},
+		fakeTransport{"websocket"},
+		fakeTransport{"polling"},
+	})
+
+	at.Equal([]string{"websocket", "webtransport", "custom"}, m.UpgradeFrom("polling"))
+	at.Equal([]string{"webtransport", "custom"}, m.UpgradeFrom("websocket"))
+	at.Equal([]string{"custom"}, m.UpgradeFrom("webtransport"))
+}
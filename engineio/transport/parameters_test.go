@@ -26,7 +26,7 @@ func TestConnParameters(t *testing.T) {
 				"vCcJKmYQcIf801WDAAAB",
 				[]string{"websocket", "polling"},
 			},
-			"{\"sid\":\"vCcJKmYQcIf801WDAAAB\",\"upgrades\":[\"websocket\",\"polling\"],\"pingInterval\":10000,\"pingTimeout\":5000}\n",
+			"{\"sid\":\"vCcJKmYQcIf801WDAAAB\",\"upgrades\":[\"websocket\",\"polling\"],\"pingInterval\":10000,\"pingTimeout\":5000,\"maxPayload\":1000000}\n",
 		},
 	}
 	for _, test := range tests {
@@ -0,0 +1,129 @@
+package polling
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	encodingGzip    = "gzip"
+	encodingDeflate = "deflate"
+
+	defaultCompressionThreshold = 1024
+)
+
+var errUnsupportedEncoding = errors.New("unsupported content encoding")
+
+// HTTPCompression is the compression of the polling requests, the responses
+// being compressed in the encoding the clients accept, gzip or deflate, and
+// the compressed bodies of the POST requests being accepted.
+type HTTPCompression struct {
+	// Threshold is the min size in bytes of the compressed responses, 1024
+	// by default.
+	Threshold int
+	// Level is the compression level, as defined by compress/flate. The
+	// zero value is the default compression.
+	Level int
+}
+
+func (c *HTTPCompression) threshold() int {
+	if c.Threshold > 0 {
+		return c.Threshold
+	}
+	return defaultCompressionThreshold
+}
+
+func (c *HTTPCompression) level() int {
+	if c.Level == 0 {
+		return flate.DefaultCompression
+	}
+	return c.Level
+}
+
+// write writes data to w, compressed in the encoding accepted by the request
+// when it is larger than the threshold.
+func (c *HTTPCompression) write(w http.ResponseWriter, r *http.Request, data []byte) error {
+	w.Header().Add("Vary", "Accept-Encoding")
+
+	encoding := acceptedEncoding(r.Header.Get("Accept-Encoding"))
+	if encoding == "" || len(data) < c.threshold() {
+		_, err := w.Write(data)
+		return err
+	}
+
+	var buf bytes.Buffer
+	var cw io.WriteCloser
+	var err error
+	switch encoding {
+	case encodingGzip:
+		cw, err = gzip.NewWriterLevel(&buf, c.level())
+	default:
+		cw, err = zlib.NewWriterLevel(&buf, c.level())
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := cw.Write(data); err != nil {
+		return err
+	}
+	if err := cw.Close(); err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Encoding", encoding)
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// reader returns the decompressed body of a request.
+func (c *HTTPCompression) reader(r *http.Request) (io.Reader, error) {
+	switch strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding"))) {
+	case "", "identity":
+		return r.Body, nil
+	case encodingGzip:
+		return gzip.NewReader(r.Body)
+	case encodingDeflate:
+		return zlib.NewReader(r.Body)
+	default:
+		return nil, errUnsupportedEncoding
+	}
+}
+
+// acceptedEncoding returns the encoding of the responses accepted in an
+// Accept-Encoding header, gzip being preferred to deflate.
+func acceptedEncoding(header string) string {
+	accepted := make(map[string]bool)
+	for _, item := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(item), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		ok := true
+		if q, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+				ok = false
+			}
+		}
+		accepted[name] = ok
+	}
+
+	for _, encoding := range []string{encodingGzip, encodingDeflate} {
+		if ok, found := accepted[encoding]; found {
+			if ok {
+				return encoding
+			}
+			continue
+		}
+		if accepted["*"] {
+			return encoding
+		}
+	}
+	return ""
+}
@@ -159,18 +159,6 @@ func (c *clientConn) getOpen() {
 
 	if resp.StatusCode != http.StatusOK {
 		err = fmt.Errorf("invalid request: %s(%d)", resp.Status, resp.StatusCode)
-	}
-
-	var isSupportBinary bool
-	if err == nil {
-		mime := resp.Header.Get("Content-Type")
-		isSupportBinary, err = mimeIsSupportBinary(mime)
-		if err != nil {
-			ll.Error(err, "Check mime support binary")
-		}
-	}
-
-	if err != nil {
 		if err = c.Payload.Store("get", err); err != nil {
 			ll.Error(err, "Store get error")
 		}
@@ -181,7 +169,7 @@ func (c *clientConn) getOpen() {
 
 	c.remoteHeader.Store(resp.Header)
 
-	if err = c.Payload.FeedIn(resp.Body, isSupportBinary); err != nil {
+	if err = c.Payload.FeedIn(resp.Body); err != nil {
 		return
 	}
 }
@@ -210,18 +198,6 @@ func (c *clientConn) serveGet() {
 
 		if resp.StatusCode != http.StatusOK {
 			err = fmt.Errorf("invalid request: %s(%d)", resp.Status, resp.StatusCode)
-		}
-
-		var isSupportBinary bool
-		if err == nil {
-			mime := resp.Header.Get("Content-Type")
-			isSupportBinary, err = mimeIsSupportBinary(mime)
-			if err != nil {
-				ll.Error(err, "Check mime support binary")
-			}
-		}
-
-		if err != nil {
 			discardBody(resp.Body)
 
 			if err = c.Payload.Store("get", err); err != nil {
@@ -233,7 +209,7 @@ func (c *clientConn) serveGet() {
 			return
 		}
 
-		if err = c.Payload.FeedIn(resp.Body, isSupportBinary); err != nil {
+		if err = c.Payload.FeedIn(resp.Body); err != nil {
 			discardBody(resp.Body)
 
 			return
@@ -2,7 +2,6 @@ package polling
 
 import (
 	"bytes"
-	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -39,9 +38,7 @@ func TestDialOpen(t *testing.T) {
 			_, err := cp.WriteTo(buf)
 			must.Nil(err)
 
-			fmt.Fprintf(w, "%d", buf.Len()+1)
-
-			_, err = w.Write([]byte(":0"))
+			_, err = w.Write([]byte("0"))
 			must.Nil(err)
 
 			_, err = w.Write(buf.Bytes())
@@ -54,7 +51,7 @@ func TestDialOpen(t *testing.T) {
 			must.Equal(cp.SID, sid)
 			b, err := io.ReadAll(r.Body)
 			must.Nil(err)
-			should.Equal("6:4hello", string(b))
+			should.Equal("4hello", string(b))
 		}
 	}
 
@@ -63,9 +60,6 @@ func TestDialOpen(t *testing.T) {
 
 	u, err := url.Parse(httpSvr.URL)
 	must.Nil(err)
-	query := u.Query()
-	query.Set("b64", "1")
-	u.RawQuery = query.Encode()
 
 	cc, err := dial(nil, u, nil)
 	must.Nil(err)
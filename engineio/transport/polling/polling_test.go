@@ -144,10 +144,6 @@ func TestPollingString(t *testing.T) {
 	u, err := url.Parse(httpSvr.URL)
 	should.Nil(err)
 
-	query := u.Query()
-	query.Set("b64", "1")
-	u.RawQuery = query.Encode()
-
 	dialU := *u
 	header := make(http.Header)
 	header.Set("X-Eio-Test", "client")
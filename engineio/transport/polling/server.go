@@ -2,45 +2,40 @@ package polling
 
 import (
 	"bytes"
+	"errors"
 	"html/template"
+	"io"
 	"net"
 	"net/http"
 	"net/url"
 	"strings"
 
 	"github.com/vchitai/go-socket.io/v4/engineio/payload"
+	"github.com/vchitai/go-socket.io/v4/engineio/transport"
 	"github.com/vchitai/go-socket.io/v4/logger"
 )
 
 type serverConn struct {
 	*payload.Payload
-	transport     *Transport
-	supportBinary bool
+	transport *Transport
 
 	remoteHeader http.Header
 	localAddr    Addr
 	remoteAddr   Addr
 	url          url.URL
 	jsonp        string
+	maxPayload   int
 }
 
 func newServerConn(t *Transport, r *http.Request) *serverConn {
-	query := r.URL.Query()
-	jsonp := query.Get("j")
-	supportBinary := query.Get("b64") == ""
-	if jsonp != "" {
-		supportBinary = false
-	}
-
 	return &serverConn{
-		Payload:       payload.New(supportBinary),
-		transport:     t,
-		supportBinary: supportBinary,
-		remoteHeader:  r.Header,
-		localAddr:     Addr{r.Host},
-		remoteAddr:    Addr{r.RemoteAddr},
-		url:           *r.URL,
-		jsonp:         jsonp,
+		Payload:      payload.New(),
+		transport:    t,
+		remoteHeader: r.Header,
+		localAddr:    Addr{r.Host},
+		remoteAddr:   Addr{r.RemoteAddr},
+		url:          *r.URL,
+		jsonp:        r.URL.Query().Get("j"),
 	}
 }
 
@@ -60,11 +55,21 @@ func (c *serverConn) RemoteHeader() http.Header {
 	return c.remoteHeader
 }
 
+// SetMaxPayload limits the size of the bodies of the POST requests.
+func (c *serverConn) SetMaxPayload(n int) {
+	c.maxPayload = n
+}
+
 func (c *serverConn) SetHeaders(w http.ResponseWriter, r *http.Request) {
 	if strings.Contains(r.UserAgent(), ";MSIE") || strings.Contains(r.UserAgent(), "Trident/") {
 		w.Header().Set("X-XSS-Protection", "0")
 	}
 
+	// the CORS headers are set by the server checking the origin
+	if transport.OriginAllowed(r) {
+		return
+	}
+
 	// just in case the default behaviour gets changed, and it has to handle an origin check
 	checkOrigin := Default.CheckOrigin
 	if c.transport.CheckOrigin != nil {
@@ -96,45 +101,66 @@ func (c *serverConn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	case http.MethodGet:
 		c.SetHeaders(w, r)
 
-		if jsonp := r.URL.Query().Get("j"); jsonp != "" {
-			buf := bytes.NewBuffer(nil)
-			if err := c.Payload.FlushOut(buf); err != nil {
+		compression := c.transport.HTTPCompression
+		jsonp := r.URL.Query().Get("j")
+
+		if jsonp == "" && compression == nil {
+			w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+
+			if err := c.Payload.FlushOut(w); err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
 			}
-			w.Header().Set("Content-Type", "text/javascript; charset=UTF-8")
-			pl := template.JSEscapeString(buf.String())
-
-			_, _ = w.Write([]byte("___eio[" + jsonp + "](\""))
-			_, _ = w.Write([]byte(pl))
-			_, _ = w.Write([]byte("\");"))
+			return
+		}
 
+		// the response is buffered to be wrapped or compressed
+		buf := bytes.NewBuffer(nil)
+		if err := c.Payload.FlushOut(buf); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+		data := buf.Bytes()
+		if jsonp != "" {
+			w.Header().Set("Content-Type", "text/javascript; charset=UTF-8")
+			data = []byte("___eio[" + jsonp + "](\"" + template.JSEscapeString(buf.String()) + "\");")
+		} else {
+			w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+		}
 
-		if err := c.Payload.FlushOut(w); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+		var err error
+		if compression != nil {
+			err = compression.write(w, r, data)
+		} else {
+			_, err = w.Write(data)
+		}
+		if err != nil {
+			ll := logger.GetLogger("engineio.transport.polling")
+			ll.Error(err, "Write poll with error")
 		}
 
 	case http.MethodPost:
 		c.SetHeaders(w, r)
 
-		mime := r.Header.Get("Content-Type")
-		isSupportBinary, err := mimeIsSupportBinary(mime)
+		body, err := c.readBody(r)
+		if errors.Is(err, transport.ErrPayloadTooLarge) {
+			// the payload is closed with the error, closing the session
+			_ = c.Payload.Store("read", err)
+			_ = c.Payload.Close()
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		if err := c.Payload.FeedIn(r.Body, isSupportBinary); err != nil {
+		if err := c.Payload.FeedIn(body); err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 		ll := logger.GetLogger("engineio.transport.polling")
-		_, err = w.Write([]byte("ok"))
-		if err != nil {
+		if _, err := w.Write([]byte("ok")); err != nil {
 			ll.Error(err, "Ack Post with error")
 		}
 
@@ -142,3 +168,32 @@ func (c *serverConn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid method", http.StatusBadRequest)
 	}
 }
+
+// readBody reads the body of a POST request, decompressed when the transport
+// accepts compressed bodies. It fails with transport.ErrPayloadTooLarge when
+// the body, compressed or not, is larger than the max payload.
+func (c *serverConn) readBody(r *http.Request) (io.Reader, error) {
+	var body io.Reader = r.Body
+	if compression := c.transport.HTTPCompression; compression != nil {
+		var err error
+		if body, err = compression.reader(r); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.maxPayload <= 0 {
+		return body, nil
+	}
+	if r.ContentLength > int64(c.maxPayload) {
+		return nil, transport.ErrPayloadTooLarge
+	}
+
+	b, err := io.ReadAll(io.LimitReader(body, int64(c.maxPayload)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > c.maxPayload {
+		return nil, transport.ErrPayloadTooLarge
+	}
+	return bytes.NewReader(b), nil
+}
@@ -1,11 +1,15 @@
 package polling
 
 import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
 	"fmt"
 	"html/template"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -79,7 +83,7 @@ func TestServerJSONP(t *testing.T) {
 		bs, err := io.ReadAll(resp.Body)
 		require.NoError(t, err)
 
-		assert.Equal(t, fmt.Sprintf("___eio[jsonp_f1](\"%s\");", template.JSEscapeString("10:b4aGVsbG8=")), string(bs))
+		assert.Equal(t, fmt.Sprintf("___eio[jsonp_f1](\"%s\");", template.JSEscapeString("baGVsbG8=")), string(bs))
 	}
 	{
 		u := httpSvr.URL + "?j=jsonp_f2"
@@ -92,8 +96,130 @@ func TestServerJSONP(t *testing.T) {
 
 		bs, err := io.ReadAll(resp.Body)
 		require.NoError(t, err)
-		assert.Equal(t, "___eio[jsonp_f2](\"6:4world\");", string(bs))
+		assert.Equal(t, "___eio[jsonp_f2](\"4world\");", string(bs))
 	}
 
 	wg.Wait()
 }
+
+func TestServerCompression(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	pollingTransport := &Transport{
+		HTTPCompression: &HTTPCompression{Threshold: 10},
+	}
+
+	sc, err := pollingTransport.Accept(nil, httptest.NewRequest(http.MethodGet, "/", nil))
+	must.NoError(err)
+	defer sc.Close()
+
+	httpSvr := httptest.NewServer(sc.(http.Handler))
+	defer httpSvr.Close()
+
+	tests := []struct {
+		name     string
+		query    string
+		accept   string
+		message  string
+		encoding string
+		body     string
+	}{
+		{"xhr gzip", "", "gzip, deflate", "hello world", "gzip", "4hello world"},
+		{"xhr deflate", "", "deflate, gzip;q=0", "hello world", "deflate", "4hello world"},
+		{"xhr below threshold", "", "gzip", "hello", "", "4hello"},
+		{"xhr not accepted", "", "br", "hello world", "", "4hello world"},
+		{"jsonp gzip", "?j=0", "gzip", "hello world", "gzip", "___eio[0](\"4hello world\");"},
+		{"jsonp deflate", "?j=0", "deflate", "hello world", "deflate", "___eio[0](\"4hello world\");"},
+		{"any encoding", "", "*", "hello world", "gzip", "4hello world"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			written := make(chan error, 1)
+			go func() {
+				w, err := sc.NextWriter(frame.String, packet.MESSAGE)
+				if err != nil {
+					written <- err
+					return
+				}
+				if _, err = w.Write([]byte(test.message)); err != nil {
+					_ = w.Close()
+					written <- err
+					return
+				}
+				written <- w.Close()
+			}()
+
+			req, err := http.NewRequest(http.MethodGet, httpSvr.URL+test.query, nil)
+			must.NoError(err)
+			req.Header.Set("Accept-Encoding", test.accept)
+
+			resp, err := http.DefaultClient.Do(req)
+			must.NoError(err)
+			defer resp.Body.Close()
+
+			should.Equal(test.encoding, resp.Header.Get("Content-Encoding"))
+			should.Equal("Accept-Encoding", resp.Header.Get("Vary"))
+
+			var body io.Reader = resp.Body
+			switch test.encoding {
+			case "gzip":
+				body, err = gzip.NewReader(resp.Body)
+				must.NoError(err)
+			case "deflate":
+				body, err = zlib.NewReader(resp.Body)
+				must.NoError(err)
+			}
+
+			b, err := io.ReadAll(body)
+			must.NoError(err)
+			should.Equal(test.body, string(b))
+			must.NoError(<-written)
+		})
+	}
+
+	// the compressed POST bodies are decompressed
+	for _, encoding := range []string{"gzip", "deflate"} {
+		var buf bytes.Buffer
+		var w io.WriteCloser = gzip.NewWriter(&buf)
+		if encoding == "deflate" {
+			w = zlib.NewWriter(&buf)
+		}
+		_, err := w.Write([]byte("4compressed " + encoding))
+		must.NoError(err)
+		must.NoError(w.Close())
+
+		req, err := http.NewRequest(http.MethodPost, httpSvr.URL, &buf)
+		must.NoError(err)
+		req.Header.Set("Content-Encoding", encoding)
+
+		go func() {
+			resp, err := http.DefaultClient.Do(req)
+			if assert.NoError(t, err) {
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+				_ = resp.Body.Close()
+			}
+		}()
+		should.Equal("compressed "+encoding, readMessage(t, sc))
+	}
+
+	req, err := http.NewRequest(http.MethodPost, httpSvr.URL, strings.NewReader("4hello"))
+	must.NoError(err)
+	req.Header.Set("Content-Encoding", "br")
+	resp, err := http.DefaultClient.Do(req)
+	must.NoError(err)
+	must.NoError(resp.Body.Close())
+	should.Equal(http.StatusBadRequest, resp.StatusCode)
+}
+
+func readMessage(t *testing.T, conn transport.Conn) string {
+	_, pt, r, err := conn.NextReader()
+	require.NoError(t, err)
+	require.Equal(t, packet.MESSAGE, pt)
+
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	return string(b)
+}
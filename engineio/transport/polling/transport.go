@@ -13,6 +13,10 @@ import (
 type Transport struct {
 	Client      *http.Client
 	CheckOrigin func(r *http.Request) bool
+
+	// HTTPCompression compresses the responses and accepts compressed POST
+	// bodies when set.
+	HTTPCompression *HTTPCompression
 }
 
 // Default is the default transport.
@@ -59,15 +63,11 @@ func dial(client *http.Client, url *url.URL, requestHeader http.Header) (*client
 	for k, v := range requestHeader {
 		req.Header[k] = v
 	}
-	supportBinary := req.URL.Query().Get("b64") == ""
-	if supportBinary {
-		req.Header.Set("Content-Type", "application/octet-stream")
-	} else {
-		req.Header.Set("Content-Type", "text/plain;charset=UTF-8")
-	}
+	// payloads are always text, binary packets being sent in base64
+	req.Header.Set("Content-Type", "text/plain;charset=UTF-8")
 
 	return &clientConn{
-		Payload:    payload.New(supportBinary),
+		Payload:    payload.New(),
 		httpClient: client,
 		request:    *req,
 	}, nil
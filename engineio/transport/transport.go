@@ -33,3 +33,21 @@ type Conn interface {
 	SetReadDeadline(t time.Time) error
 	SetWriteDeadline(t time.Time) error
 }
+
+// PayloadLimiter is a connection which limits the size of the payloads it
+// reads, failing with ErrPayloadTooLarge when they are larger than n bytes.
+type PayloadLimiter interface {
+	SetMaxPayload(n int)
+}
+
+// Compressor is a frame writer which can be compressed or not by the
+// transport, overriding its compression options.
+type Compressor interface {
+	SetCompress(compress bool)
+}
+
+// SessionJoiner is a connection giving the sid of the session it upgrades
+// once accepted, instead of in the query of the request.
+type SessionJoiner interface {
+	SessionID() string
+}
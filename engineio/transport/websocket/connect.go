@@ -26,8 +26,8 @@ type conn struct {
 	closeOnce sync.Once
 }
 
-func newConn(ws *websocket.Conn, url url.URL, header http.Header) *conn {
-	w := newWrapper(ws)
+func newConn(ws *websocket.Conn, url url.URL, header http.Header, compression compression) *conn {
+	w := newWrapper(ws, compression)
 	closed := make(chan struct{})
 
 	return &conn{
@@ -56,6 +56,11 @@ func (c *conn) RemoteAddr() net.Addr {
 	return c.ws.RemoteAddr()
 }
 
+// SetMaxPayload limits the size of the messages read.
+func (c *conn) SetMaxPayload(n int) {
+	c.ws.SetReadLimit(int64(n))
+}
+
 func (c *conn) SetReadDeadline(t time.Time) error {
 	return c.ws.SetReadDeadline(t)
 }
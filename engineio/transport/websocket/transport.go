@@ -33,6 +33,14 @@ type Transport struct {
 	Proxy       func(*http.Request) (*url.URL, error)
 	NetDial     func(network, addr string) (net.Conn, error)
 	CheckOrigin func(r *http.Request) bool
+
+	// EnableCompression negotiates the permessage-deflate extension, the
+	// messages being compressed at CompressionLevel, as defined by
+	// compress/flate, when they are at least CompressionThreshold bytes.
+	// The level is 1 and the threshold 1024 bytes by default.
+	EnableCompression    bool
+	CompressionLevel     int
+	CompressionThreshold int
 }
 
 // Default is default transport.
@@ -46,13 +54,14 @@ func (t *Transport) Name() string {
 // Dial creates a new client connection.
 func (t *Transport) Dial(u *url.URL, requestHeader http.Header) (transport.Conn, error) {
 	dialer := websocket.Dialer{
-		ReadBufferSize:   t.ReadBufferSize,
-		WriteBufferSize:  t.WriteBufferSize,
-		NetDial:          t.NetDial,
-		Proxy:            t.Proxy,
-		TLSClientConfig:  t.TLSClientConfig,
-		HandshakeTimeout: t.HandshakeTimeout,
-		Subprotocols:     t.SubProtocols,
+		ReadBufferSize:    t.ReadBufferSize,
+		WriteBufferSize:   t.WriteBufferSize,
+		NetDial:           t.NetDial,
+		Proxy:             t.Proxy,
+		TLSClientConfig:   t.TLSClientConfig,
+		HandshakeTimeout:  t.HandshakeTimeout,
+		Subprotocols:      t.SubProtocols,
+		EnableCompression: t.EnableCompression,
 	}
 
 	switch u.Scheme {
@@ -75,20 +84,63 @@ func (t *Transport) Dial(u *url.URL, requestHeader http.Header) (transport.Conn,
 		}
 	}
 
-	return newConn(c, *u, resp.Header), nil
+	if err := t.setCompression(c); err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+
+	return newConn(c, *u, resp.Header, t.compression()), nil
 }
 
 // Accept accepts a http request and create Conn.
 func (t *Transport) Accept(w http.ResponseWriter, r *http.Request) (transport.Conn, error) {
+	checkOrigin := t.CheckOrigin
+	if transport.OriginAllowed(r) {
+		// the origin was checked by the server
+		checkOrigin = func(*http.Request) bool {
+			return true
+		}
+	}
+
 	upgrader := websocket.Upgrader{
 		ReadBufferSize:  t.ReadBufferSize,
 		WriteBufferSize: t.WriteBufferSize,
-		CheckOrigin:     t.CheckOrigin,
+		CheckOrigin:     checkOrigin,
+
+		EnableCompression: t.EnableCompression,
 	}
 	c, err := upgrader.Upgrade(w, r, w.Header())
 	if err != nil {
 		return nil, err
 	}
 
-	return newConn(c, *r.URL, r.Header), nil
+	if err := t.setCompression(c); err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+
+	return newConn(c, *r.URL, r.Header, t.compression()), nil
+}
+
+func (t *Transport) setCompression(c *websocket.Conn) error {
+	if !t.EnableCompression || t.CompressionLevel == 0 {
+		return nil
+	}
+	return c.SetCompressionLevel(t.CompressionLevel)
+}
+
+// compression returns the compression of the messages of the connections.
+func (t *Transport) compression() compression {
+	if !t.EnableCompression {
+		return compression{}
+	}
+
+	threshold := t.CompressionThreshold
+	if threshold <= 0 {
+		threshold = defaultCompressionThreshold
+	}
+	return compression{
+		enabled:   true,
+		threshold: threshold,
+	}
 }
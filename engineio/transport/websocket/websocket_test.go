@@ -2,10 +2,13 @@ package websocket
 
 import (
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -139,3 +142,97 @@ func TestWebsocket(t *testing.T) {
 
 	wg.Wait()
 }
+
+// countingConn counts the bytes read on a connection
+type countingConn struct {
+	net.Conn
+
+	read atomic.Int64
+}
+
+func (c *countingConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.read.Add(int64(n))
+	return n, err
+}
+
+func TestWebsocketCompression(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	var counter *countingConn
+	wsTransport := &Transport{
+		EnableCompression:    true,
+		CompressionLevel:     9,
+		CompressionThreshold: 100,
+		NetDial: func(network, addr string) (net.Conn, error) {
+			c, err := net.Dial(network, addr)
+			if err != nil {
+				return nil, err
+			}
+			counter = &countingConn{Conn: c}
+			return counter, nil
+		},
+	}
+
+	conn := make(chan transport.Conn, 1)
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		c, err := wsTransport.Accept(w, r)
+		require.NoError(t, err)
+
+		conn <- c
+		c.(http.Handler).ServeHTTP(w, r)
+	}
+	httpSvr := httptest.NewServer(http.HandlerFunc(handler))
+	defer httpSvr.Close()
+
+	u, err := url.Parse(httpSvr.URL)
+	must.NoError(err)
+	u.Scheme = "ws"
+
+	cc, err := wsTransport.Dial(u, nil)
+	must.NoError(err)
+	defer cc.Close()
+
+	sc := <-conn
+	defer sc.Close()
+
+	should.Contains(cc.RemoteHeader().Get("Sec-Websocket-Extensions"), "permessage-deflate")
+
+	large := strings.Repeat("a", 10000)
+	tests := []struct {
+		name       string
+		data       string
+		compress   bool
+		compressed bool
+	}{
+		{"large", large, true, true},
+		{"below threshold", "hello", true, false},
+		{"compression disabled", large, false, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			before := counter.read.Load()
+
+			w, err := sc.NextWriter(frame.String, packet.MESSAGE)
+			must.NoError(err)
+			if !test.compress {
+				w.(transport.Compressor).SetCompress(false)
+			}
+			_, err = io.WriteString(w, test.data)
+			must.NoError(err)
+			must.NoError(w.Close())
+
+			_, _, r, err := cc.NextReader()
+			must.NoError(err)
+			b, err := io.ReadAll(r)
+			must.NoError(err)
+			must.NoError(r.Close())
+			should.Equal(test.data, string(b))
+
+			read := counter.read.Load() - before
+			should.Equal(test.compressed, read < int64(len(test.data)), read)
+		})
+	}
+}
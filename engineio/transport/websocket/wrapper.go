@@ -1,6 +1,8 @@
 package websocket
 
 import (
+	"bytes"
+	"errors"
 	"io"
 	"sync"
 	"time"
@@ -12,16 +14,27 @@ import (
 	"github.com/vchitai/go-socket.io/v4/engineio/transport"
 )
 
+const defaultCompressionThreshold = 1024
+
+// compression is the compression of the messages written, when the
+// permessage-deflate extension is negotiated.
+type compression struct {
+	enabled   bool
+	threshold int
+}
+
 type wrapper struct {
 	*websocket.Conn
 
+	compression compression
 	writeLocker *sync.Mutex
 	readLocker  *sync.Mutex
 }
 
-func newWrapper(conn *websocket.Conn) wrapper {
+func newWrapper(conn *websocket.Conn, compression compression) wrapper {
 	return wrapper{
 		Conn:        conn,
+		compression: compression,
 		writeLocker: new(sync.Mutex),
 		readLocker:  new(sync.Mutex),
 	}
@@ -34,7 +47,7 @@ func (w wrapper) NextReader() (frame.Type, io.ReadCloser, error) {
 	// The wrapper remains locked until the returned ReadCloser is Closed.
 	typ, r, err := w.Conn.NextReader()
 	if err != nil {
-		return 0, nil, err
+		return 0, nil, readError(err)
 	}
 
 	switch typ {
@@ -47,6 +60,15 @@ func (w wrapper) NextReader() (frame.Type, io.ReadCloser, error) {
 	return 0, nil, transport.ErrInvalidFrame
 }
 
+// readError returns transport.ErrPayloadTooLarge when the read limit of the
+// connection is exceeded.
+func readError(err error) error {
+	if errors.Is(err, websocket.ErrReadLimit) {
+		return transport.ErrPayloadTooLarge
+	}
+	return err
+}
+
 type rcWrapper struct {
 	io.Reader
 	nagTimer *time.Timer
@@ -75,6 +97,14 @@ func newRcWrapper(l *sync.Mutex, r io.Reader) rcWrapper {
 	}
 }
 
+func (r rcWrapper) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err != nil {
+		err = readError(err)
+	}
+	return n, err
+}
+
 func (r rcWrapper) Close() error {
 	// Stop the nagger.
 	r.l.Lock()
@@ -83,8 +113,12 @@ func (r rcWrapper) Close() error {
 	r.nagTimer.Stop()
 	close(r.quitNag)
 
-	// Attempt to drain the Reader.
+	// Attempt to drain the Reader. The readers of the compressed messages
+	// are closed once read until their end.
 	_, err := io.Copy(io.Discard, r)
+	if errors.Is(err, io.ErrClosedPipe) {
+		return nil
+	}
 
 	return err
 }
@@ -101,6 +135,12 @@ func (w wrapper) NextWriter(FType frame.Type) (io.WriteCloser, error) {
 		return nil, transport.ErrInvalidFrame
 	}
 
+	// the compressed messages are buffered to be compressed or not once
+	// their size is known
+	if w.compression.enabled {
+		return &compressWriter{w: w, typ: t, compress: true}, nil
+	}
+
 	w.writeLocker.Lock()
 	writer, err := w.Conn.NextWriter(t)
 	// The wrapper remains locked until the returned WriteCloser is Closed.
@@ -149,3 +189,26 @@ func (w wcWrapper) Close() error {
 	defer w.l.Unlock()
 	return w.WriteCloser.Close()
 }
+
+// compressWriter buffers a message, written once closed, compressed when it
+// is at least the compression threshold.
+type compressWriter struct {
+	bytes.Buffer
+
+	w        wrapper
+	typ      int
+	compress bool
+}
+
+// SetCompress disables or enables the compression of the message.
+func (w *compressWriter) SetCompress(compress bool) {
+	w.compress = compress
+}
+
+func (w *compressWriter) Close() error {
+	w.w.writeLocker.Lock()
+	defer w.w.writeLocker.Unlock()
+
+	w.w.EnableWriteCompression(w.compress && w.Len() >= w.w.compression.threshold)
+	return w.w.WriteMessage(w.typ, w.Bytes())
+}
@@ -0,0 +1,156 @@
+package webtransport
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/quic-go/webtransport-go"
+
+	"github.com/vchitai/go-socket.io/v4/engineio/frame"
+	"github.com/vchitai/go-socket.io/v4/engineio/packet"
+	"github.com/vchitai/go-socket.io/v4/engineio/transport"
+)
+
+var errInvalidOpen = errors.New("webtransport: invalid open packet")
+
+// conn implements base.Conn
+type conn struct {
+	transport.FrameReader
+	transport.FrameWriter
+
+	session *webtransport.Session
+	stream  *webtransport.Stream
+	dialer  *webtransport.Dialer
+	wrapper *wrapper
+
+	url          url.URL
+	remoteHeader http.Header
+	sid          string
+
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newConn(session *webtransport.Session, stream *webtransport.Stream, url url.URL, header http.Header) *conn {
+	w := newWrapper(stream)
+
+	return &conn{
+		session:      session,
+		stream:       stream,
+		wrapper:      w,
+		url:          url,
+		remoteHeader: header,
+		closed:       make(chan struct{}),
+		FrameReader:  packet.NewDecoder(w),
+		FrameWriter:  packet.NewEncoder(w),
+	}
+}
+
+// SessionID returns the sid the client sent in its open packet, empty for a
+// new session.
+func (c *conn) SessionID() string {
+	return c.sid
+}
+
+func (c *conn) URL() url.URL {
+	return c.url
+}
+
+func (c *conn) RemoteHeader() http.Header {
+	return c.remoteHeader
+}
+
+func (c *conn) LocalAddr() net.Addr {
+	return c.session.LocalAddr()
+}
+
+func (c *conn) RemoteAddr() net.Addr {
+	return c.session.RemoteAddr()
+}
+
+// SetMaxPayload limits the size of the frames read.
+func (c *conn) SetMaxPayload(n int) {
+	c.wrapper.maxPayload.Store(int64(n))
+}
+
+func (c *conn) SetReadDeadline(t time.Time) error {
+	return c.stream.SetReadDeadline(t)
+}
+
+func (c *conn) SetWriteDeadline(t time.Time) error {
+	return c.stream.SetWriteDeadline(t)
+}
+
+func (c *conn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	<-c.closed
+}
+
+func (c *conn) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.closed)
+
+		_ = c.stream.Close()
+		err = c.session.CloseWithError(0, "")
+		if c.dialer != nil {
+			_ = c.dialer.Close()
+		}
+	})
+	return err
+}
+
+type openPacket struct {
+	SID string `json:"sid,omitempty"`
+}
+
+// writeOpen sends the open packet starting the connection, with the sid of
+// the session it upgrades
+func (c *conn) writeOpen(sid string) error {
+	w, err := c.NextWriter(frame.String, packet.OPEN)
+	if err != nil {
+		return err
+	}
+
+	if sid != "" {
+		b, err := json.Marshal(openPacket{SID: sid})
+		if err != nil {
+			_ = w.Close()
+			return err
+		}
+		if _, err := w.Write(b); err != nil {
+			_ = w.Close()
+			return err
+		}
+	}
+
+	return w.Close()
+}
+
+// readOpen reads the open packet sent by the client
+func (c *conn) readOpen() error {
+	_, pt, r, err := c.NextReader()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+
+	if pt != packet.OPEN {
+		return errInvalidOpen
+	}
+
+	var open openPacket
+	if err := json.NewDecoder(r).Decode(&open); err != nil && !errors.Is(err, io.EOF) {
+		return errInvalidOpen
+	}
+	c.sid = open.SID
+
+	return nil
+}
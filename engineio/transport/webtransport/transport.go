@@ -0,0 +1,141 @@
+package webtransport
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/webtransport-go"
+
+	"github.com/vchitai/go-socket.io/v4/engineio/transport"
+	"github.com/vchitai/go-socket.io/v4/engineio/transport/utils"
+)
+
+var errNoServer = errors.New("webtransport: no server to upgrade the request")
+
+// DialError is the error when dialing to a server. It saves Response from
+// server.
+type DialError struct {
+	Response *http.Response
+
+	error
+}
+
+// Transport is webtransport transport, over HTTP/3.
+//
+// The client opens a bidirectional stream on the session and sends an open
+// packet on it, holding the sid of the session it upgrades if any. The
+// packets are then sent on the stream, each one prefixed by its length.
+type Transport struct {
+	// Server is the server the HTTP/3 server serving the requests was
+	// configured with, using webtransport.ConfigureHTTP3Server.
+	Server *webtransport.Server
+
+	TLSClientConfig  *tls.Config
+	QUICConfig       *quic.Config
+	HandshakeTimeout time.Duration
+}
+
+// Name is the name of webtransport transport.
+func (t *Transport) Name() string {
+	return "webtransport"
+}
+
+// Dial creates a new client connection.
+func (t *Transport) Dial(u *url.URL, requestHeader http.Header) (transport.Conn, error) {
+	dialer := &webtransport.Dialer{
+		TLSClientConfig: t.TLSClientConfig,
+		QUICConfig:      t.QUICConfig,
+	}
+
+	switch u.Scheme {
+	case "http", "ws", "wss":
+		u.Scheme = "https"
+	}
+
+	// the sid is sent in the open packet
+	query := u.Query()
+	sid := query.Get("sid")
+	query.Del("sid")
+	query.Set("transport", t.Name())
+	query.Set("t", utils.Timestamp())
+	u.RawQuery = query.Encode()
+
+	ctx, cancel := t.handshakeContext()
+	defer cancel()
+
+	resp, sess, err := dialer.Dial(ctx, u.String(), requestHeader)
+	if err != nil {
+		_ = dialer.Close()
+		return nil, DialError{
+			error:    err,
+			Response: resp,
+		}
+	}
+
+	stream, err := sess.OpenStreamSync(ctx)
+	if err != nil {
+		_ = sess.CloseWithError(0, "")
+		_ = dialer.Close()
+		return nil, err
+	}
+
+	c := newConn(sess, stream, *u, resp.Header)
+	c.dialer = dialer
+
+	if err := c.writeOpen(sid); err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Accept accepts a http request and create Conn.
+func (t *Transport) Accept(w http.ResponseWriter, r *http.Request) (transport.Conn, error) {
+	if t.Server == nil {
+		return nil, errNoServer
+	}
+
+	sess, err := t.Server.Upgrade(w, r)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := t.handshakeContext()
+	defer cancel()
+
+	stream, err := sess.AcceptStream(ctx)
+	if err != nil {
+		_ = sess.CloseWithError(0, "")
+		return nil, err
+	}
+
+	c := newConn(sess, stream, *r.URL, r.Header)
+
+	if err := stream.SetReadDeadline(time.Now().Add(t.handshakeTimeout())); err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+	if err := c.readOpen(); err != nil {
+		_ = c.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (t *Transport) handshakeTimeout() time.Duration {
+	if t.HandshakeTimeout > 0 {
+		return t.HandshakeTimeout
+	}
+	return 10 * time.Second
+}
+
+func (t *Transport) handshakeContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), t.handshakeTimeout())
+}
@@ -0,0 +1,382 @@
+package webtransport
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go/http3"
+	"github.com/quic-go/webtransport-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vchitai/go-socket.io/v4/engineio"
+	"github.com/vchitai/go-socket.io/v4/engineio/frame"
+	"github.com/vchitai/go-socket.io/v4/engineio/packet"
+	"github.com/vchitai/go-socket.io/v4/engineio/session"
+	"github.com/vchitai/go-socket.io/v4/engineio/transport"
+	"github.com/vchitai/go-socket.io/v4/engineio/transport/polling"
+)
+
+var tests = []struct {
+	ft   frame.Type
+	pt   packet.Type
+	data []byte
+}{
+	{frame.String, packet.MESSAGE, []byte{}},
+	{frame.String, packet.MESSAGE, []byte("hello")},
+	{frame.Binary, packet.MESSAGE, []byte{1, 2, 3, 4}},
+	{frame.String, packet.MESSAGE, bytes.Repeat([]byte("a"), 1000)},
+	{frame.Binary, packet.MESSAGE, bytes.Repeat([]byte{1}, 100000)},
+}
+
+// newTLSConfigs returns the configs of a server with a self-signed
+// certificate, and of the clients trusting it.
+func newTLSConfigs(t *testing.T) (*tls.Config, *tls.Config) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+		NextProtos:   []string{http3.NextProtoH3},
+	}, &tls.Config{
+		RootCAs:    pool,
+		NextProtos: []string{http3.NextProtoH3},
+	}
+}
+
+// newQUICServer serves handler over HTTP/3 on a local UDP port, returning the
+// transport accepting its webtransport requests and its address.
+func newQUICServer(t *testing.T, handler func(*Transport) http.Handler) (*Transport, string) {
+	serverConf, clientConf := newTLSConfigs(t)
+
+	wtServer := &webtransport.Server{
+		H3: &http3.Server{TLSConfig: serverConf},
+		CheckOrigin: func(*http.Request) bool {
+			return true
+		},
+	}
+	webtransport.ConfigureHTTP3Server(wtServer.H3)
+
+	wtTransport := &Transport{
+		Server:          wtServer,
+		TLSClientConfig: clientConf,
+	}
+	wtServer.H3.Handler = handler(wtTransport)
+
+	udpConn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+
+	served := make(chan struct{})
+	go func() {
+		defer close(served)
+		_ = wtServer.Serve(udpConn)
+	}()
+
+	t.Cleanup(func() {
+		_ = wtServer.Close()
+		<-served
+		_ = udpConn.Close()
+	})
+	return wtTransport, "https://" + udpConn.LocalAddr().String()
+}
+
+func TestWebtransport(t *testing.T) {
+	wtTransport := &Transport{}
+	assert.Equal(t, "webtransport", wtTransport.Name())
+
+	conn := make(chan transport.Conn, 1)
+	wtTransport, addr := newQUICServer(t, func(wtTransport *Transport) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			c, err := wtTransport.Accept(w, r)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			conn <- c
+			c.(http.Handler).ServeHTTP(w, r)
+		})
+	})
+
+	u, err := url.Parse(addr + "/?sid=abc")
+	require.NoError(t, err)
+
+	cc, err := wtTransport.Dial(u, nil)
+	require.NoError(t, err)
+	defer cc.Close()
+
+	var sc transport.Conn
+	select {
+	case sc = <-conn:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no connection accepted")
+	}
+	defer sc.Close()
+
+	// the sid is sent in the open packet
+	assert.Equal(t, "abc", sc.(transport.SessionJoiner).SessionID())
+	scURL := sc.URL()
+	query := scURL.Query()
+	assert.Equal(t, "webtransport", query.Get("transport"))
+	assert.NotEmpty(t, query.Get("t"))
+	assert.Empty(t, query.Get("sid"))
+	assert.Equal(t, sc.LocalAddr().String(), cc.RemoteAddr().String())
+
+	// the client echoes the packets, its errors being checked once done
+	echoed := make(chan error, 1)
+	go func() {
+		for _, test := range tests {
+			ft, pt, r, err := cc.NextReader()
+			if err != nil {
+				echoed <- err
+				return
+			}
+
+			assert.Equal(t, test.ft, ft)
+			assert.Equal(t, test.pt, pt)
+
+			b, err := io.ReadAll(r)
+			_ = r.Close()
+			if err != nil {
+				echoed <- err
+				return
+			}
+
+			assert.Equal(t, test.data, b)
+
+			w, err := cc.NextWriter(ft, pt)
+			if err != nil {
+				echoed <- err
+				return
+			}
+			if _, err = w.Write(b); err != nil {
+				_ = w.Close()
+				echoed <- err
+				return
+			}
+			if err = w.Close(); err != nil {
+				echoed <- err
+				return
+			}
+		}
+		echoed <- nil
+	}()
+
+	for _, test := range tests {
+		w, err := sc.NextWriter(test.ft, test.pt)
+		require.NoError(t, err)
+
+		_, err = w.Write(test.data)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+
+		ft, pt, r, err := sc.NextReader()
+		require.NoError(t, err)
+
+		assert.Equal(t, test.ft, ft)
+		assert.Equal(t, test.pt, pt)
+
+		b, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+
+		assert.Equal(t, test.data, b)
+	}
+
+	require.NoError(t, <-echoed)
+}
+
+func TestWrapperFrames(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	frames := []struct {
+		ft     frame.Type
+		data   []byte
+		header []byte
+	}{
+		{frame.String, []byte("4hello"), []byte{6}},
+		{frame.Binary, []byte{1, 2, 3}, []byte{0x83}},
+		{frame.String, make([]byte, 126), []byte{126, 0, 126}},
+		{frame.Binary, make([]byte, 1<<16), []byte{0xff, 0, 0, 0, 0, 0, 1, 0, 0}},
+	}
+
+	for _, f := range frames {
+		var buf bytes.Buffer
+		w := newWrapper(&buf)
+
+		fw, err := w.NextWriter(f.ft)
+		must.NoError(err)
+		_, err = fw.Write(f.data)
+		must.NoError(err)
+		must.NoError(fw.Close())
+
+		should.Equal(f.header, buf.Bytes()[:len(f.header)])
+		should.Equal(len(f.header)+len(f.data), buf.Len())
+
+		ft, r, err := w.NextReader()
+		must.NoError(err)
+		b, err := io.ReadAll(r)
+		must.NoError(err)
+		must.NoError(r.Close())
+
+		should.Equal(f.ft, ft)
+		should.Equal(f.data, b)
+	}
+}
+
+func TestEngineUpgradeToWebtransport(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	var svr *engineio.Server
+	wtTransport, addr := newQUICServer(t, func(wtTransport *Transport) http.Handler {
+		svr = engineio.NewServer(&engineio.Options{
+			Transports: []transport.Transport{
+				wtTransport,
+				polling.Default,
+			},
+		})
+		return svr
+	})
+	defer svr.Close()
+
+	httpSvr := httptest.NewServer(svr)
+	defer httpSvr.Close()
+
+	resp, err := http.Get(httpSvr.URL + "/?EIO=4&transport=polling")
+	must.NoError(err)
+	body, err := io.ReadAll(resp.Body)
+	must.NoError(err)
+	must.NoError(resp.Body.Close())
+
+	params, err := transport.ReadConnParameters(strings.NewReader(string(body[1:])))
+	must.NoError(err)
+	should.Equal([]string{"webtransport"}, params.Upgrades)
+
+	conns := make(chan engineio.Conn, 1)
+	go func() {
+		if conn, err := svr.Accept(); err == nil {
+			conns <- conn
+		}
+	}()
+	var conn engineio.Conn
+	select {
+	case conn = <-conns:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no connection accepted")
+	}
+	defer conn.Close()
+
+	u, err := url.Parse(addr + "/?EIO=4&sid=" + params.SID)
+	must.NoError(err)
+	cc, err := wtTransport.Dial(u, nil)
+	must.NoError(err)
+	defer cc.Close()
+
+	// probe then upgrade
+	writePacket(t, cc, packet.PING, "probe")
+	pt, data := readPacket(t, cc)
+	should.Equal(packet.PONG, pt)
+	should.Equal("probe", data)
+	writePacket(t, cc, packet.UPGRADE, "")
+
+	// the messages are sent on the webtransport connection once upgraded
+	writePacket(t, cc, packet.MESSAGE, "hello")
+	ft, r, err := conn.NextReader()
+	must.NoError(err)
+	b, err := io.ReadAll(r)
+	must.NoError(err)
+	must.NoError(r.Close())
+	should.Equal(session.TEXT, ft)
+	should.Equal("hello", string(b))
+	should.Equal("webtransport", conn.(*session.Session).Transport())
+
+	w, err := conn.NextWriter(session.TEXT)
+	must.NoError(err)
+	_, err = w.Write([]byte("world"))
+	must.NoError(err)
+	must.NoError(w.Close())
+
+	pt, data = readPacket(t, cc)
+	should.Equal(packet.MESSAGE, pt)
+	should.Equal("world", data)
+}
+
+func writePacket(t *testing.T, conn transport.Conn, pt packet.Type, data string) {
+	w, err := conn.NextWriter(frame.String, pt)
+	require.NoError(t, err)
+	_, err = io.WriteString(w, data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+}
+
+func readPacket(t *testing.T, conn transport.Conn) (packet.Type, string) {
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+	for {
+		_, pt, r, err := conn.NextReader()
+		require.NoError(t, err)
+		b, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+
+		// skip the pings of the session
+		if pt != packet.PING {
+			return pt, string(b)
+		}
+	}
+}
+
+func TestWrapperMaxPayload(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	var buf bytes.Buffer
+	w := newWrapper(&buf)
+	w.maxPayload.Store(3)
+
+	for _, data := range []string{"abc", "abcd"} {
+		fw, err := w.NextWriter(frame.String)
+		must.NoError(err)
+		_, err = fw.Write([]byte(data))
+		must.NoError(err)
+		must.NoError(fw.Close())
+	}
+
+	_, r, err := w.NextReader()
+	must.NoError(err)
+	must.NoError(r.Close())
+
+	_, _, err = w.NextReader()
+	should.ErrorIs(err, transport.ErrPayloadTooLarge)
+}
@@ -0,0 +1,144 @@
+package webtransport
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/vchitai/go-socket.io/v4/engineio/frame"
+	"github.com/vchitai/go-socket.io/v4/engineio/transport"
+)
+
+// binaryFlag is set in the first byte of the header of binary frames.
+const binaryFlag = 0x80
+
+// wrapper frames the packets on a stream. Each frame starts with its length,
+// in the first byte when lower than 126, else in the 2 (126) or 8 (127)
+// bytes following it, with binaryFlag set for binary frames.
+type wrapper struct {
+	rw io.ReadWriter
+
+	maxPayload atomic.Int64
+
+	writeLocker *sync.Mutex
+	readLocker  *sync.Mutex
+}
+
+func newWrapper(rw io.ReadWriter) *wrapper {
+	return &wrapper{
+		rw:          rw,
+		writeLocker: new(sync.Mutex),
+		readLocker:  new(sync.Mutex),
+	}
+}
+
+func (w *wrapper) NextReader() (frame.Type, io.ReadCloser, error) {
+	w.readLocker.Lock()
+
+	// The wrapper remains locked until the returned ReadCloser is Closed.
+	ft, n, err := w.readHeader()
+	if err != nil {
+		w.readLocker.Unlock()
+		return 0, nil, err
+	}
+	if max := w.maxPayload.Load(); max > 0 && n > uint64(max) {
+		w.readLocker.Unlock()
+		return 0, nil, transport.ErrPayloadTooLarge
+	}
+
+	return ft, &frameReader{
+		Reader: io.LimitReader(w.rw, int64(n)),
+		l:      w.readLocker,
+	}, nil
+}
+
+func (w *wrapper) readHeader() (frame.Type, uint64, error) {
+	var b [8]byte
+	if _, err := io.ReadFull(w.rw, b[:1]); err != nil {
+		return 0, 0, err
+	}
+
+	ft := frame.String
+	if b[0]&binaryFlag != 0 {
+		ft = frame.Binary
+	}
+
+	switch n := b[0] &^ binaryFlag; n {
+	case 126:
+		if _, err := io.ReadFull(w.rw, b[:2]); err != nil {
+			return 0, 0, err
+		}
+		return ft, uint64(binary.BigEndian.Uint16(b[:2])), nil
+	case 127:
+		if _, err := io.ReadFull(w.rw, b[:]); err != nil {
+			return 0, 0, err
+		}
+		return ft, binary.BigEndian.Uint64(b[:]), nil
+	default:
+		return ft, uint64(n), nil
+	}
+}
+
+type frameReader struct {
+	io.Reader
+
+	l    *sync.Mutex
+	once sync.Once
+}
+
+func (r *frameReader) Close() error {
+	// Drain the frame so that the next one can be read.
+	_, err := io.Copy(io.Discard, r.Reader)
+	r.once.Do(r.l.Unlock)
+
+	return err
+}
+
+func (w *wrapper) NextWriter(ft frame.Type) (io.WriteCloser, error) {
+	switch ft {
+	case frame.String, frame.Binary:
+	default:
+		return nil, transport.ErrInvalidFrame
+	}
+
+	return &frameWriter{w: w, ft: ft}, nil
+}
+
+// frameWriter buffers a frame, written with its header once closed.
+type frameWriter struct {
+	bytes.Buffer
+
+	w  *wrapper
+	ft frame.Type
+}
+
+func (w *frameWriter) Close() error {
+	data := w.Bytes()
+
+	var header [9]byte
+	var n int
+	switch size := len(data); {
+	case size < 126:
+		header[0] = byte(size)
+		n = 1
+	case size <= 0xffff:
+		header[0] = 126
+		binary.BigEndian.PutUint16(header[1:3], uint16(size))
+		n = 3
+	default:
+		header[0] = 127
+		binary.BigEndian.PutUint64(header[1:9], uint64(size))
+		n = 9
+	}
+	if w.ft == frame.Binary {
+		header[0] |= binaryFlag
+	}
+
+	w.w.writeLocker.Lock()
+	defer w.w.writeLocker.Unlock()
+
+	_, err := w.w.rw.Write(append(header[:n], data...))
+	return err
+}
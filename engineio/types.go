@@ -2,6 +2,8 @@ package engineio
 
 import (
 	"net/http"
+
+	"github.com/vchitai/go-socket.io/v4/engineio/session"
 )
 
 // CheckerFunc is function to check request.
@@ -9,3 +11,11 @@ type CheckerFunc func(*http.Request) (http.Header, error)
 
 // ConnInitiatorFunc is function to do after create connection.
 type ConnInitiatorFunc func(*http.Request, Conn)
+
+// ErrorHandlerFunc is function to handle the errors of a client breaking
+// the protocol, such as ErrPayloadTooLarge, before its connection is closed.
+type ErrorHandlerFunc func(Conn, error)
+
+// UpgradeHandlerFunc is function to handle the start, success and failure of
+// the upgrades of a connection to another transport.
+type UpgradeHandlerFunc func(Conn, session.UpgradeEvent)
@@ -10,6 +10,43 @@ var (
 	errFailedConnectNamespace = errors.New("failed connect to namespace without handler")
 )
 
+// adapter errors.
+var (
+	// ErrRequestTimeout is returned with partial results when some nodes of
+	// the cluster did not answer a room query in time.
+	ErrRequestTimeout = errors.New("cluster request timeout")
+
+	// ErrRoomNotFound is returned when attaching data to a room without connection.
+	ErrRoomNotFound = errors.New("room not found")
+
+	// ErrRoomDataConflict is returned when updating the data of a room which
+	// changed since the version read.
+	ErrRoomDataConflict = errors.New("room data version conflict")
+
+	errBusRequired = errors.New("bus adapter requires a bus")
+
+	errRoomDataUnsupported = errors.New("room data not supported by the adapter")
+
+	errSocketQueryUnsupported = errors.New("socket queries not supported by the adapter")
+
+	errRedisNoPong = errors.New("redis subscription did not answer the ping")
+
+	errNamespaceNotFound = errors.New("namespace not found")
+)
+
+// stream errors.
+var (
+	// ErrStreamCanceled is returned when the other side canceled a stream,
+	// wrapped with the reason it gave.
+	ErrStreamCanceled = errors.New("stream canceled")
+
+	errStreamClosed = errors.New("stream closed")
+
+	errStreamConnClosed = errors.New("connection closed during the stream")
+
+	errStreamWindow = errors.New("stream chunks sent beyond the window")
+)
+
 // common connection gotAck errors.
 var (
 	errHandleDispatch = errors.New("handler gotAck error")
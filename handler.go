@@ -1,6 +1,8 @@
 package socketio
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"reflect"
 	"sync"
@@ -11,6 +13,7 @@ import (
 // Handler contains all logics for working with connections
 type Handler struct {
 	broadcast Broadcaster
+	sockets   *connMap
 
 	events     map[string]*funcHandler
 	eventsLock sync.RWMutex
@@ -28,8 +31,13 @@ func NewHandler(nsp string, adapterOpts *RedisAdapterConfig) *Handler {
 		broadcast, _ = newBroadcastRemote(nsp, adapterOpts)
 	}
 
+	return newHandler(broadcast)
+}
+
+func newHandler(broadcast Broadcaster) *Handler {
 	return &Handler{
 		broadcast: broadcast,
+		sockets:   newConnMap(),
 		events:    make(map[string]*funcHandler),
 	}
 }
@@ -47,6 +55,10 @@ func (nh *Handler) OnError(f OnErrorHandler) {
 }
 
 func (nh *Handler) OnEvent(event string, f interface{}) {
+	if isStreamEvent(event) {
+		panic("events prefixed with " + streamEventPrefix + " are reserved for the streams")
+	}
+
 	nh.eventsLock.Lock()
 	defer nh.eventsLock.Unlock()
 
@@ -108,6 +120,16 @@ func (nh *Handler) Len(room string) int {
 	return nh.broadcast.Len(room)
 }
 
+func (nh *Handler) LenContext(ctx context.Context, room string) (int, error) {
+	if nh == nil {
+		return -1, errNamespaceNotFound
+	}
+	if querier, ok := nh.broadcast.(contextQuerier); ok {
+		return querier.lenContext(ctx, room)
+	}
+	return nh.broadcast.Len(room), nil
+}
+
 func (nh *Handler) Rooms(conn Conn) []string {
 	if nh == nil {
 		return nil
@@ -115,6 +137,16 @@ func (nh *Handler) Rooms(conn Conn) []string {
 	return nh.broadcast.Rooms(conn)
 }
 
+func (nh *Handler) AllRoomsContext(ctx context.Context) ([]string, error) {
+	if nh == nil {
+		return nil, errNamespaceNotFound
+	}
+	if querier, ok := nh.broadcast.(contextQuerier); ok {
+		return querier.allRoomsContext(ctx)
+	}
+	return nh.broadcast.AllRooms(), nil
+}
+
 func (nh *Handler) ForEach(room string, f EachFunc) bool {
 	if nh == nil {
 		return false
@@ -123,6 +155,229 @@ func (nh *Handler) ForEach(room string, f EachFunc) bool {
 	return true
 }
 
+func (nh *Handler) OnRoomCreated(f RoomHandler) {
+	if hooks, ok := nh.broadcast.(roomHooksSetter); ok {
+		hooks.onRoomCreated(f)
+	}
+}
+
+func (nh *Handler) OnRoomDeleted(f RoomHandler) {
+	if hooks, ok := nh.broadcast.(roomHooksSetter); ok {
+		hooks.onRoomDeleted(f)
+	}
+}
+
+func (nh *Handler) OnJoin(f RoomConnHandler) {
+	if hooks, ok := nh.broadcast.(roomHooksSetter); ok {
+		hooks.onJoin(f)
+	}
+}
+
+func (nh *Handler) OnLeave(f RoomConnHandler) {
+	if hooks, ok := nh.broadcast.(roomHooksSetter); ok {
+		hooks.onLeave(f)
+	}
+}
+
+// SetRoomData attaches data, encoded in JSON, to the room. It returns the new
+// version of the data.
+func (nh *Handler) SetRoomData(ctx context.Context, room string, data interface{}) (int64, error) {
+	store, err := nh.roomDataStore()
+	if err != nil {
+		return 0, err
+	}
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		return 0, err
+	}
+	return store.setRoomData(ctx, room, b)
+}
+
+// RoomData decodes the data attached to the room into v. It returns the
+// version of the data, which is 0 when no data was attached.
+func (nh *Handler) RoomData(ctx context.Context, room string, v interface{}) (int64, error) {
+	store, err := nh.roomDataStore()
+	if err != nil {
+		return 0, err
+	}
+
+	b, version, err := store.roomData(ctx, room)
+	if err != nil || version == 0 {
+		return version, err
+	}
+	return version, json.Unmarshal(b, v)
+}
+
+// UpdateRoomData attaches data to the room if its data are still at version,
+// else it fails with ErrRoomDataConflict and the current version.
+func (nh *Handler) UpdateRoomData(ctx context.Context, room string, version int64, data interface{}) (int64, error) {
+	store, err := nh.roomDataStore()
+	if err != nil {
+		return 0, err
+	}
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		return 0, err
+	}
+	return store.updateRoomData(ctx, room, version, b)
+}
+
+func (nh *Handler) roomDataStore() (roomDataStore, error) {
+	if nh == nil {
+		return nil, errNamespaceNotFound
+	}
+	store, ok := nh.broadcast.(roomDataStore)
+	if !ok {
+		return nil, errRoomDataUnsupported
+	}
+	return store, nil
+}
+
+// Socket gives the connection of this node with the id.
+func (nh *Handler) Socket(id string) (Conn, bool) {
+	if nh == nil {
+		return nil, false
+	}
+	return nh.sockets.getConn(id)
+}
+
+// Sockets gives the connections of this node.
+func (nh *Handler) Sockets() []Conn {
+	if nh == nil {
+		return nil
+	}
+
+	conns := make([]Conn, 0, nh.sockets.len())
+	nh.sockets.forEach(func(_ string, conn Conn) bool {
+		conns = append(conns, conn)
+		return true
+	})
+	return conns
+}
+
+// EmitTo sends given event & args to the connection with the id, through the
+// adapter when it is not a connection of this node. It returns false when the
+// connection is not on this node and no adapter reaches the other nodes, the
+// delivery to the other nodes not being confirmed.
+func (nh *Handler) EmitTo(id string, event string, args ...interface{}) bool {
+	if nh == nil {
+		return false
+	}
+
+	if conn, ok := nh.sockets.getConn(id); ok {
+		conn.Emit(event, args...)
+		return true
+	}
+	if _, ok := nh.broadcast.(clusterAdapter); !ok {
+		return false
+	}
+
+	// every connection joins the room named after its id
+	nh.broadcast.Send(id, event, args...)
+	return true
+}
+
+func (nh *Handler) addSocket(conn Conn) {
+	nh.sockets.join(conn)
+}
+
+func (nh *Handler) removeSocket(conn Conn) {
+	nh.sockets.leave(conn)
+}
+
+// FetchSockets describes the connections in the room, every connection of the
+// namespace when room is empty, whose data match filter on every node.
+func (nh *Handler) FetchSockets(ctx context.Context, room string, filter DataFilter) ([]SocketInfo, error) {
+	querier, err := nh.socketQuerier()
+	if err != nil {
+		return nil, err
+	}
+	return querier.fetchSockets(ctx, room, filter)
+}
+
+// SendWhere sends given event & args to the connections in the room, every
+// connection of the namespace when room is empty, whose data match filter.
+func (nh *Handler) SendWhere(room string, filter DataFilter, event string, args ...interface{}) bool {
+	querier, err := nh.socketQuerier()
+	if err != nil {
+		return false
+	}
+	querier.sendWhere(room, filter, event, args...)
+	return true
+}
+
+func (nh *Handler) socketQuerier() (socketQuerier, error) {
+	if nh == nil {
+		return nil, errNamespaceNotFound
+	}
+	querier, ok := nh.broadcast.(socketQuerier)
+	if !ok {
+		return nil, errSocketQueryUnsupported
+	}
+	return querier, nil
+}
+
+// BindUser binds the connection to the user, replacing its previous user. The
+// binding is removed when the connection leaves the namespace.
+func (nh *Handler) BindUser(user string, conn Conn) bool {
+	if nh == nil {
+		return false
+	}
+	if presence, ok := nh.broadcast.(presenceTracker); ok {
+		presence.bindUser(user, conn)
+		return true
+	}
+	return false
+}
+
+func (nh *Handler) UnbindUser(conn Conn) bool {
+	if nh == nil {
+		return false
+	}
+	if presence, ok := nh.broadcast.(presenceTracker); ok {
+		presence.unbindUser(conn)
+		return true
+	}
+	return false
+}
+
+func (nh *Handler) UserSockets(ctx context.Context, user string) ([]string, error) {
+	if nh == nil {
+		return nil, errNamespaceNotFound
+	}
+	if presence, ok := nh.broadcast.(presenceTracker); ok {
+		return presence.userSockets(ctx, user)
+	}
+	return nil, nil
+}
+
+func (nh *Handler) OnPresence(f PresenceHandler) {
+	if presence, ok := nh.broadcast.(presenceTracker); ok {
+		presence.onPresence(f)
+	}
+}
+
+// Health gives the state of the broadcast adapter of the namespace, which is
+// always healthy when it does not connect to other nodes.
+func (nh *Handler) Health() AdapterHealth {
+	if nh == nil {
+		return 0
+	}
+	if adapter, ok := nh.broadcast.(clusterAdapter); ok {
+		return adapter.health()
+	}
+	return AdapterHealthy
+}
+
+func (nh *Handler) close() error {
+	if adapter, ok := nh.broadcast.(clusterAdapter); ok {
+		return adapter.close()
+	}
+	return nil
+}
+
 func (nh *Handler) getEventTypes(event string) []reflect.Type {
 	nh.eventsLock.RLock()
 	namespaceHandler := nh.events[event]
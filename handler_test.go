@@ -1,8 +1,10 @@
 package socketio
 
 import (
+	"context"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -131,3 +133,64 @@ func TestNamespaceHandlerEvent(t *testing.T) {
 		})
 	}
 }
+
+func TestBusAdapterEmitTo(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	servers := newBusTestServers(t, 2, BusAdapterConfig{Bus: NewBus()})
+	clients := make([]*testClient, len(servers))
+	for i, srv := range servers {
+		clients[i] = newTestClient(t, newTestHTTPServer(t, srv))
+	}
+
+	conn, ok := servers[0].Socket("/", clients[0].sid)
+	must.True(ok)
+	should.Equal(clients[0].sid, conn.ID())
+	_, ok = servers[0].Socket("/", clients[1].sid)
+	should.False(ok)
+	must.Len(servers[1].Sockets("/"), 1)
+	should.Equal(clients[1].sid, servers[1].Sockets("/")[0].ID())
+
+	// the connections of the other nodes are reached through the adapter
+	for _, c := range clients {
+		must.True(servers[0].EmitTo("/", c.sid, "event", c.sid))
+		c.expect(t, "event", c.sid)
+	}
+
+	must.NoError(clients[1].ws.Close())
+	must.Eventually(func() bool {
+		return len(servers[1].Sockets("/")) == 0
+	}, time.Second, 10*time.Millisecond)
+
+	should.False(servers[0].EmitTo("/unknown", clients[0].sid, "event"))
+	_, ok = servers[0].Socket("/unknown", clients[0].sid)
+	should.False(ok)
+}
+
+// plainBroadcaster only implements Broadcaster, like the broadcasters of the
+// other packages
+type plainBroadcaster struct {
+	Broadcaster
+}
+
+func TestHandlerPlainBroadcaster(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	h := newHandler(plainBroadcaster{newBroadcast()})
+
+	// the room hooks are not called without the broadcaster support
+	h.OnJoin(func(string, Conn) {
+		t.Error("unexpected join hook")
+	})
+	h.Join("room", newBusTestConn("c1"))
+
+	n, err := h.LenContext(context.Background(), "room")
+	must.NoError(err)
+	should.Equal(1, n)
+
+	rooms, err := h.AllRoomsContext(context.Background())
+	must.NoError(err)
+	should.Equal([]string{"room"}, rooms)
+}
@@ -29,3 +29,13 @@ func (h *Handlers) Get(nsp string) (*Handler, bool) {
 	handler, ok := h.handlers[nsp]
 	return handler, ok
 }
+
+// Range calls f for every namespace handler.
+func (h *Handlers) Range(f func(namespace string, handler *Handler)) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for namespace, handler := range h.handlers {
+		f(namespace, handler)
+	}
+}
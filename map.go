@@ -7,26 +7,89 @@ import (
 // roomMap as sync.Map
 
 func newRoomMap() *roomMap {
-	return &roomMap{data: make(map[string]*connMap)}
+	return &roomMap{
+		data: make(map[string]*connMap),
+		meta: make(map[string]*roomMeta),
+	}
 }
 
 type roomMap struct {
 	data  map[string]*connMap
+	meta  map[string]*roomMeta
 	mutex sync.RWMutex
+
+	// hooks are called for the transitions queued in pending, see notify.
+	// lifecycle hooks are set by the adapters and called before the others.
+	hooks      roomHooks
+	lifecycle  roomHooks
+	pending    []roomEvent
+	delivering bool
+}
+
+// roomMeta is the data attached to a room, which is deleted with it
+type roomMeta struct {
+	version int64
+	data    []byte
+}
+
+// roomHooks are called when rooms are created or deleted and when connections
+// join or leave them
+type roomHooks struct {
+	onRoomCreated RoomHandler
+	onRoomDeleted RoomHandler
+	onJoin        RoomConnHandler
+	onLeave       RoomConnHandler
+}
+
+type roomEventType int
+
+const (
+	roomCreatedEvent roomEventType = iota
+	roomDeletedEvent
+	roomJoinEvent
+	roomLeaveEvent
+)
+
+// roomEvent is a transition of a room
+type roomEvent struct {
+	typ  roomEventType
+	room string
+	conn Conn
+}
+
+// setHooks changes the hooks with set
+func (rm *roomMap) setHooks(set func(hooks *roomHooks)) {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+
+	set(&rm.hooks)
+}
+
+// setLifecycleHooks changes the lifecycle hooks with set
+func (rm *roomMap) setLifecycleHooks(set func(hooks *roomHooks)) {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+
+	set(&rm.lifecycle)
 }
 
 // join register the connection to room
 func (rm *roomMap) join(room string, conn Conn) {
 	rm.mutex.Lock()
-	defer rm.mutex.Unlock()
 
 	cm, ok := rm.data[room]
 	if !ok {
 		rm.data[room] = newConnMap()
 		cm = rm.data[room]
+		rm.pending = append(rm.pending, roomEvent{typ: roomCreatedEvent, room: room})
+	}
+
+	if cm.join(conn) {
+		rm.pending = append(rm.pending, roomEvent{typ: roomJoinEvent, room: room, conn: conn})
 	}
 
-	cm.join(conn)
+	rm.mutex.Unlock()
+	rm.notify()
 }
 
 func (rm *roomMap) listRoomID() []string {
@@ -45,29 +108,177 @@ func (rm *roomMap) leaveAll(conn Conn) {
 	}
 }
 
-// leave remove the connection from the specific room
+// leave remove the connection from the specific room, and the room when it is empty
 func (rm *roomMap) leave(room string, conn Conn) {
 	rm.mutex.Lock()
-	defer rm.mutex.Unlock()
 
 	// find conn map
 	cm, ok := rm.data[room]
 	if !ok {
+		rm.mutex.Unlock()
 		return
 	}
 
-	cm.leave(conn)
+	if cm.leave(conn) {
+		rm.pending = append(rm.pending, roomEvent{typ: roomLeaveEvent, room: room, conn: conn})
+	}
 	if cm.len() == 0 {
 		delete(rm.data, room)
+		delete(rm.meta, room)
+		rm.pending = append(rm.pending, roomEvent{typ: roomDeletedEvent, room: room})
 	}
+
+	rm.mutex.Unlock()
+	rm.notify()
 }
 
-// delete remove the specific room
+// delete remove the specific room, after each of its connections left it
 func (rm *roomMap) delete(room string) {
 	rm.mutex.Lock()
-	defer rm.mutex.Unlock()
+
+	cm, ok := rm.data[room]
+	if !ok {
+		rm.mutex.Unlock()
+		return
+	}
 
 	delete(rm.data, room)
+	delete(rm.meta, room)
+	for _, conn := range cm.iterableData() {
+		rm.pending = append(rm.pending, roomEvent{typ: roomLeaveEvent, room: room, conn: conn})
+	}
+	rm.pending = append(rm.pending, roomEvent{typ: roomDeletedEvent, room: room})
+
+	rm.mutex.Unlock()
+	rm.notify()
+}
+
+// notify calls the hooks for the pending transitions, once each and in the
+// order they happened. A single goroutine delivers them at a time, the others
+// return at once, which lets the hooks use the room map, including joining
+// and leaving rooms. When a hook panics, the transitions not delivered yet
+// stay pending and the panic goes on.
+func (rm *roomMap) notify() {
+	rm.mutex.Lock()
+	if rm.delivering {
+		rm.mutex.Unlock()
+		return
+	}
+	rm.delivering = true
+
+	var events []roomEvent
+	delivered := false
+	defer func() {
+		if delivered {
+			return
+		}
+		rm.mutex.Lock()
+		rm.pending = append(events, rm.pending...)
+		rm.delivering = false
+		rm.mutex.Unlock()
+	}()
+
+	for len(rm.pending) > 0 {
+		var hooks, lifecycle roomHooks
+		events, hooks, lifecycle = rm.pending, rm.hooks, rm.lifecycle
+		rm.pending = nil
+		rm.mutex.Unlock()
+
+		for len(events) > 0 {
+			event := events[0]
+			events = events[1:]
+			lifecycle.call(event)
+			hooks.call(event)
+		}
+
+		rm.mutex.Lock()
+	}
+
+	rm.delivering = false
+	delivered = true
+	rm.mutex.Unlock()
+}
+
+func (h roomHooks) call(event roomEvent) {
+	switch event.typ {
+	case roomCreatedEvent:
+		if h.onRoomCreated != nil {
+			h.onRoomCreated(event.room)
+		}
+	case roomDeletedEvent:
+		if h.onRoomDeleted != nil {
+			h.onRoomDeleted(event.room)
+		}
+	case roomJoinEvent:
+		if h.onJoin != nil {
+			h.onJoin(event.room, event.conn)
+		}
+	case roomLeaveEvent:
+		if h.onLeave != nil {
+			h.onLeave(event.room, event.conn)
+		}
+	}
+}
+
+// setData attaches data to the room
+func (rm *roomMap) setData(room string, data []byte) (int64, error) {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+
+	if _, ok := rm.data[room]; !ok {
+		return 0, ErrRoomNotFound
+	}
+
+	meta, ok := rm.meta[room]
+	if !ok {
+		meta = &roomMeta{}
+		rm.meta[room] = meta
+	}
+	meta.version++
+	meta.data = data
+
+	return meta.version, nil
+}
+
+// getData returns the data of the room and its version, which is 0 when no
+// data was set
+func (rm *roomMap) getData(room string) ([]byte, int64, error) {
+	rm.mutex.RLock()
+	defer rm.mutex.RUnlock()
+
+	if _, ok := rm.data[room]; !ok {
+		return nil, 0, ErrRoomNotFound
+	}
+
+	meta, ok := rm.meta[room]
+	if !ok {
+		return nil, 0, nil
+	}
+	return meta.data, meta.version, nil
+}
+
+// updateData attaches data to the room if its data is still at version
+func (rm *roomMap) updateData(room string, version int64, data []byte) (int64, error) {
+	rm.mutex.Lock()
+	defer rm.mutex.Unlock()
+
+	if _, ok := rm.data[room]; !ok {
+		return 0, ErrRoomNotFound
+	}
+
+	meta, ok := rm.meta[room]
+	if !ok {
+		meta = &roomMeta{}
+	}
+	if meta.version != version {
+		return meta.version, ErrRoomDataConflict
+	}
+
+	rm.meta[room] = meta
+	meta.version++
+	meta.data = data
+
+	return meta.version, nil
 }
 
 // getConnections return connMap for specific room
@@ -115,18 +326,24 @@ type connMap struct {
 	data  map[string]Conn
 }
 
-func (cm *connMap) join(conn Conn) {
+// join adds the connection, it reports whether it was not there yet
+func (cm *connMap) join(conn Conn) bool {
 	cm.mutex.Lock()
 	defer cm.mutex.Unlock()
 
+	_, ok := cm.data[conn.ID()]
 	cm.data[conn.ID()] = conn
+	return !ok
 }
 
-func (cm *connMap) leave(conn Conn) {
+// leave removes the connection, it reports whether it was there
+func (cm *connMap) leave(conn Conn) bool {
 	cm.mutex.Lock()
 	defer cm.mutex.Unlock()
 
+	_, ok := cm.data[conn.ID()]
 	delete(cm.data, conn.ID())
+	return ok
 }
 
 // forEach is use for iterate for read purpose only
@@ -185,7 +402,7 @@ func copyMap[K comparable, V any](m map[K]V) map[K]V {
 }
 
 func getKeysOfMap[K comparable, V any](m map[K]V) []K {
-	res := make([]K, len(m))
+	res := make([]K, 0, len(m))
 	for k := range m {
 		res = append(res, k)
 	}
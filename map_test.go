@@ -0,0 +1,167 @@
+package socketio
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type roomHooksRecorder struct {
+	events []string
+	mutex  sync.Mutex
+}
+
+func (r *roomHooksRecorder) record(format string, args ...interface{}) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.events = append(r.events, fmt.Sprintf(format, args...))
+}
+
+func (r *roomHooksRecorder) get() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return append([]string{}, r.events...)
+}
+
+func (r *roomHooksRecorder) set(hooks *roomHooks) {
+	hooks.onRoomCreated = func(room string) { r.record("created %s", room) }
+	hooks.onRoomDeleted = func(room string) { r.record("deleted %s", room) }
+	hooks.onJoin = func(room string, conn Conn) { r.record("join %s %s", room, conn.ID()) }
+	hooks.onLeave = func(room string, conn Conn) { r.record("leave %s %s", room, conn.ID()) }
+}
+
+func TestRoomMapHooks(t *testing.T) {
+	should := assert.New(t)
+
+	var recorder roomHooksRecorder
+	rm := newRoomMap()
+	rm.setHooks(recorder.set)
+
+	c1 := newBusTestConn("c1")
+	c2 := newBusTestConn("c2")
+
+	rm.join("a", c1)
+	rm.join("a", c1)
+	rm.join("a", c2)
+	rm.leave("a", c1)
+	rm.leave("a", c1)
+	rm.leave("a", c2)
+	rm.leave("unknown", c2)
+
+	rm.join("b", c1)
+	rm.join("b", c2)
+	rm.delete("b")
+	rm.delete("b")
+
+	events := recorder.get()
+	should.Equal([]string{
+		"created a", "join a c1", "join a c2", "leave a c1", "leave a c2", "deleted a",
+		"created b", "join b c1", "join b c2",
+	}, events[:9])
+	should.ElementsMatch([]string{"leave b c1", "leave b c2"}, events[9:11])
+	should.Equal([]string{"deleted b"}, events[11:])
+}
+
+func TestRoomMapHooksConcurrency(t *testing.T) {
+	should := assert.New(t)
+
+	var recorder roomHooksRecorder
+	rm := newRoomMap()
+	rm.setHooks(func(hooks *roomHooks) {
+		hooks.onRoomCreated = func(room string) { recorder.record("created") }
+		hooks.onRoomDeleted = func(room string) { recorder.record("deleted") }
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(conn Conn) {
+			defer wg.Done()
+
+			for j := 0; j < 100; j++ {
+				rm.join("room", conn)
+				rm.leave("room", conn)
+			}
+		}(newBusTestConn(fmt.Sprint(i)))
+	}
+	wg.Wait()
+
+	// the room is created and deleted alternately, whatever the interleaving
+	events := recorder.get()
+	should.NotEmpty(events)
+	for i, event := range events {
+		if i%2 == 0 {
+			should.Equal("created", event)
+		} else {
+			should.Equal("deleted", event)
+		}
+	}
+	should.Equal(0, len(events)%2)
+}
+
+func TestRoomMapHooksReentrant(t *testing.T) {
+	must := require.New(t)
+
+	rm := newRoomMap()
+	rm.setHooks(func(hooks *roomHooks) {
+		hooks.onJoin = func(room string, conn Conn) {
+			if room == "lobby" {
+				rm.join("game", conn)
+			}
+		}
+	})
+
+	rm.join("lobby", newBusTestConn("c1"))
+	must.ElementsMatch([]string{"lobby", "game"}, rm.listRoomID())
+}
+
+func TestServerRoomHooks(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	srv := NewServer(nil)
+
+	var recorder roomHooksRecorder
+	srv.OnRoomCreated("/", func(room string) { recorder.record("created %s", room) })
+	srv.OnRoomDeleted("/", func(room string) { recorder.record("deleted %s", room) })
+	srv.OnJoin("/", func(room string, conn Conn) { recorder.record("join %s %s", room, conn.ID()) })
+	srv.OnLeave("/", func(room string, conn Conn) { recorder.record("leave %s %s", room, conn.ID()) })
+
+	c := newBusTestConn("c")
+	must.True(srv.JoinRoom("/", "room", c))
+	must.True(srv.ClearRoom("/", "room"))
+
+	should.Equal([]string{"created room", "join room c", "leave room c", "deleted room"}, recorder.get())
+}
+
+func TestRoomMapHooksPanic(t *testing.T) {
+	should := assert.New(t)
+
+	var recorder roomHooksRecorder
+	rm := newRoomMap()
+	rm.setHooks(func(hooks *roomHooks) {
+		recorder.set(hooks)
+		hooks.onJoin = func(room string, conn Conn) {
+			if conn.ID() == "bad" {
+				panic("hook failed")
+			}
+			recorder.record("join %s %s", room, conn.ID())
+		}
+	})
+
+	// the panic of the hook goes on, the created hook being called first
+	should.PanicsWithValue("hook failed", func() {
+		rm.join("a", newBusTestConn("bad"))
+	})
+	should.Equal([]string{"created a"}, recorder.get())
+
+	// the later transitions are delivered
+	rm.join("a", newBusTestConn("c1"))
+	rm.leave("a", newBusTestConn("c1"))
+	should.Equal([]string{"created a", "join a c1", "leave a c1"}, recorder.get())
+}
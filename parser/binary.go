@@ -0,0 +1,282 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+var (
+	bufferType    = reflect.TypeOf(Buffer{})
+	bytesType     = reflect.TypeOf([]byte(nil))
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+const placeholderKey = "_placeholder"
+
+// attachBuffers gives a copy of v where every Buffer and []byte is replaced by
+// a placeholder, and the binary attachments the placeholders refer to. The
+// values without binary data are left untouched, so that they are encoded as
+// they would be by encoding/json.
+func attachBuffers(v interface{}) (interface{}, [][]byte) {
+	var buffers [][]byte
+	ret, ok := attachBuffer(reflect.ValueOf(v), &buffers)
+	if !ok {
+		return v, nil
+	}
+	return ret, buffers
+}
+
+// attachBuffer replaces the binary data of v by placeholders appended to
+// buffers. It reports whether v held binary data.
+func attachBuffer(v reflect.Value, buffers *[][]byte) (interface{}, bool) {
+	if !v.IsValid() {
+		return nil, false
+	}
+
+	switch {
+	case v.Type() == bufferType:
+		return placeholder(v.Interface().(Buffer).Data, buffers), true
+	case v.Type() == bytesType && !v.IsNil():
+		return placeholder(v.Bytes(), buffers), true
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil, false
+		}
+		return attachBuffer(v.Elem(), buffers)
+
+	case reflect.Struct:
+		if v.Type().Implements(marshalerType) || reflect.PtrTo(v.Type()).Implements(marshalerType) {
+			return nil, false
+		}
+
+		fields := make(map[string]interface{})
+		if !attachStructBuffers(v, fields, buffers) {
+			return nil, false
+		}
+		return fields, true
+
+	case reflect.Slice, reflect.Array:
+		if v.Type().Implements(marshalerType) {
+			return nil, false
+		}
+
+		var found bool
+		elems := make([]interface{}, v.Len())
+		for i := range elems {
+			elem, ok := attachBuffer(v.Index(i), buffers)
+			if !ok {
+				elem = interfaceOf(v.Index(i))
+			}
+			elems[i] = elem
+			found = found || ok
+		}
+		if !found {
+			return nil, false
+		}
+		return elems, true
+
+	case reflect.Map:
+		if v.Type().Implements(marshalerType) {
+			return nil, false
+		}
+
+		// sort the keys so that attachments are numbered in a stable order
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+
+		var found bool
+		values := make(map[string]interface{}, len(keys))
+		for _, key := range keys {
+			value, ok := attachBuffer(v.MapIndex(key), buffers)
+			if !ok {
+				value = interfaceOf(v.MapIndex(key))
+			}
+			values[fmt.Sprint(key.Interface())] = value
+			found = found || ok
+		}
+		if !found {
+			return nil, false
+		}
+		return values, true
+	}
+
+	return nil, false
+}
+
+// attachStructBuffers puts the fields of the struct v, named as encoding/json
+// does, in fields. It reports whether any held binary data.
+func attachStructBuffers(v reflect.Value, fields map[string]interface{}, buffers *[][]byte) bool {
+	var found bool
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+
+		value := v.Field(i)
+		if field.Anonymous && name == "" {
+			embedded := value
+			if embedded.Kind() == reflect.Ptr {
+				if embedded.IsNil() {
+					continue
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				found = attachStructBuffers(embedded, fields, buffers) || found
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if strings.Contains(opts, "omitempty") && isEmptyValue(value) {
+			continue
+		}
+
+		fieldValue, ok := attachBuffer(value, buffers)
+		if !ok {
+			fieldValue = interfaceOf(value)
+		}
+		fields[name] = fieldValue
+		found = found || ok
+	}
+
+	return found
+}
+
+func placeholder(data []byte, buffers *[][]byte) map[string]interface{} {
+	num := len(*buffers)
+	*buffers = append(*buffers, data)
+
+	return map[string]interface{}{
+		placeholderKey: true,
+		"num":          num,
+	}
+}
+
+func interfaceOf(v reflect.Value) interface{} {
+	if !v.IsValid() || !v.CanInterface() {
+		return nil
+	}
+	return v.Interface()
+}
+
+// isEmptyValue tells whether v is omitted by the omitempty option
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	default:
+		return v.IsZero()
+	}
+}
+
+// detachBuffers decodes the JSON argument raw into a new value of type typ,
+// replacing its placeholders by the binary attachments they refer to.
+func detachBuffers(raw json.RawMessage, typ reflect.Type, buffers [][]byte) (reflect.Value, error) {
+	ret := reflect.New(typ)
+	if len(raw) == 0 {
+		return ret, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	var tree interface{}
+	if err := decoder.Decode(&tree); err != nil {
+		return ret, err
+	}
+	tree, err := detachBuffer(tree, buffers)
+	if err != nil {
+		return ret, err
+	}
+
+	// the attachments are given as []byte to empty interfaces, instead of
+	// the base64 strings they would be decoded as
+	if typ.Kind() == reflect.Interface && typ.NumMethod() == 0 {
+		if tree = plainNumbers(tree); tree != nil {
+			ret.Elem().Set(reflect.ValueOf(tree))
+		}
+		return ret, nil
+	}
+
+	b, err := json.Marshal(tree)
+	if err != nil {
+		return ret, err
+	}
+	return ret, json.Unmarshal(b, ret.Interface())
+}
+
+func detachBuffer(v interface{}, buffers [][]byte) (interface{}, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if isPlaceholder, _ := v[placeholderKey].(bool); isPlaceholder {
+			num, ok := v["num"].(json.Number)
+			if !ok {
+				return nil, errMissingAttachment
+			}
+			i, err := num.Int64()
+			if err != nil || i < 0 || i >= int64(len(buffers)) {
+				return nil, errMissingAttachment
+			}
+			return buffers[i], nil
+		}
+
+		for key, value := range v {
+			value, err := detachBuffer(value, buffers)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = value
+		}
+
+	case []interface{}:
+		for i, value := range v {
+			value, err := detachBuffer(value, buffers)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = value
+		}
+	}
+
+	return v, nil
+}
+
+// plainNumbers converts the numbers of v to float64, as encoding/json decodes
+// them into empty interfaces
+func plainNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for key, value := range v {
+			v[key] = plainNumbers(value)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = plainNumbers(value)
+		}
+	}
+	return v
+}
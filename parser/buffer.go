@@ -6,12 +6,9 @@ import (
 	"strconv"
 )
 
-// Buffer is a binary buffer handler used in emit args. All buffers will be
-// sent as binary in the transport layer.
+// Buffer is a binary buffer handler used in emit args. All buffers, as well
+// as plain []byte values, will be sent as binary in the transport layer.
 type Buffer struct {
-	num      uint64
-	isBinary bool
-
 	Data []byte
 }
 
@@ -21,26 +18,11 @@ type BufferData struct {
 	Data        []byte
 }
 
-// MarshalJSON marshals to JSON.
-func (a *Buffer) MarshalJSON() ([]byte, error) {
+// MarshalJSON marshals to JSON, as the node Buffer does. It is only used when
+// the buffer is encoded by a json.Marshaler of the args, the encoder sending
+// the other buffers as binary attachments.
+func (a Buffer) MarshalJSON() ([]byte, error) {
 	var buf bytes.Buffer
-	if err := a.marshalJSONBuf(&buf); err != nil {
-		return nil, err
-	}
-
-	return buf.Bytes(), nil
-}
-
-func (a *Buffer) marshalJSONBuf(buf *bytes.Buffer) error {
-	encode := a.encodeText
-	if a.isBinary {
-		encode = a.encodeBinary
-	}
-
-	return encode(buf)
-}
-
-func (a *Buffer) encodeText(buf *bytes.Buffer) error {
 	buf.WriteString(`{"type":"Buffer","data":[`)
 	for i, d := range a.Data {
 		if i > 0 {
@@ -50,27 +32,25 @@ func (a *Buffer) encodeText(buf *bytes.Buffer) error {
 	}
 	buf.WriteString("]}")
 
-	return nil
-}
-
-func (a *Buffer) encodeBinary(buf *bytes.Buffer) error {
-	buf.WriteString(`{"_placeholder":true,"num":`)
-	buf.WriteString(strconv.FormatUint(a.num, 10))
-	buf.WriteString("}")
-
-	return nil
+	return buf.Bytes(), nil
 }
 
-// UnmarshalJSON unmarshal data from JSON.
+// UnmarshalJSON unmarshal data from JSON, either a node Buffer or the base64
+// string of the attachment the decoder replaced its placeholder with.
 func (a *Buffer) UnmarshalJSON(b []byte) error {
+	if len(b) > 0 && b[0] == '"' {
+		return json.Unmarshal(b, &a.Data)
+	}
+
 	var data BufferData
 	if err := json.Unmarshal(b, &data); err != nil {
 		return err
 	}
+	if data.PlaceHolder {
+		return errMissingAttachment
+	}
 
-	a.isBinary = data.PlaceHolder
 	a.Data = data.Data
-	a.num = data.Num
 
 	return nil
 }
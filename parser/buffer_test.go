@@ -9,24 +9,20 @@ import (
 )
 
 var attachmentTests = []struct {
-	buffer         Buffer
-	textEncoding   string
-	binaryEncoding string
+	buffer       Buffer
+	textEncoding string
 }{
 	{
-		Buffer{0, false, []byte{1, 255}},
+		Buffer{[]byte{1, 255}},
 		`{"type":"Buffer","data":[1,255]}`,
-		`{"_placeholder":true,"num":0}`,
 	},
 	{
-		Buffer{1, false, []byte{}},
+		Buffer{[]byte{}},
 		`{"type":"Buffer","data":[]}`,
-		`{"_placeholder":true,"num":1}`,
 	},
 	{
-		Buffer{2, false, nil},
+		Buffer{nil},
 		`{"type":"Buffer","data":[]}`,
-		`{"_placeholder":true,"num":2}`,
 	},
 }
 
@@ -35,9 +31,7 @@ func TestAttachmentEncodeText(t *testing.T) {
 	must := require.New(t)
 
 	for _, test := range attachmentTests {
-		a := test.buffer
-		a.isBinary = false
-		j, err := json.Marshal(a)
+		j, err := json.Marshal(test.buffer)
 
 		must.NoError(err)
 
@@ -45,21 +39,6 @@ func TestAttachmentEncodeText(t *testing.T) {
 	}
 }
 
-func TestAttachmentEncodeBinary(t *testing.T) {
-	should := assert.New(t)
-	must := require.New(t)
-
-	for _, test := range attachmentTests {
-		a := test.buffer
-		a.isBinary = true
-		j, err := json.Marshal(a)
-
-		must.NoError(err)
-
-		should.Equal(test.binaryEncoding, string(j))
-	}
-}
-
 func TestAttachmentDecodeText(t *testing.T) {
 	should := assert.New(t)
 	must := require.New(t)
@@ -69,7 +48,6 @@ func TestAttachmentDecodeText(t *testing.T) {
 		err := json.Unmarshal([]byte(test.textEncoding), &a)
 
 		must.NoError(err)
-		should.False(a.isBinary)
 
 		if len(test.buffer.Data) == 0 {
 			should.Equal([]byte{}, a.Data)
@@ -84,13 +62,10 @@ func TestAttachmentDecodeBinary(t *testing.T) {
 	should := assert.New(t)
 	must := require.New(t)
 
-	for _, test := range attachmentTests {
-		var a Buffer
-		err := json.Unmarshal([]byte(test.binaryEncoding), &a)
+	var a Buffer
+	must.NoError(json.Unmarshal([]byte(`"AQL/"`), &a))
+	should.Equal([]byte{1, 2, 255}, a.Data)
 
-		must.NoError(err)
-
-		should.True(a.isBinary)
-		should.Equal(test.buffer.num, a.num)
-	}
+	// a placeholder is replaced by its attachment before being decoded
+	should.Error(json.Unmarshal([]byte(`{"_placeholder":true,"num":0}`), &a))
 }
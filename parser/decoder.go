@@ -11,10 +11,6 @@ import (
 	"github.com/vchitai/go-socket.io/v4/engineio/session"
 )
 
-const (
-	bufferTypeName = "Buffer"
-)
-
 type FrameReader interface {
 	NextReader() (session.FrameType, io.ReadCloser, error)
 }
@@ -99,59 +95,145 @@ func (d *Decoder) DecodeHeader(header *Header, event *string) error {
 }
 
 func (d *Decoder) DecodeArgs(types []reflect.Type) ([]reflect.Value, error) {
-	r := d.packetReader.(io.Reader)
-	r = io.MultiReader(strings.NewReader("["), r, strings.NewReader("]"))
-
-	ret := make([]reflect.Value, len(types))
-	values := make([]interface{}, len(types))
-
-	for i, typ := range types {
-		if typ.Kind() == reflect.Ptr {
-			typ = typ.Elem()
+	r, err := d.argsReader()
+	if err != nil {
+		if err == io.EOF {
+			err = nil
 		}
-		ret[i] = reflect.New(typ)
-		values[i] = ret[i].Interface()
+		_ = d.DiscardLast()
+		if err == nil {
+			err = d.discardBuffers()
+		}
+		return nil, err
 	}
 
-	if err := json.NewDecoder(r).Decode(&values); err != nil {
+	if d.bufferCount > 0 {
+		return d.decodeBinaryArgs(r, types)
+	}
+
+	var raws []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raws); err != nil {
 		if err == io.EOF {
 			err = nil
 		}
 		_ = d.DiscardLast()
-		return nil, err
+		if err == nil {
+			return nil, nil
+		}
+		return nil, &ArgsError{Index: -1, Err: err}
 	}
 
 	//we can't use defer or call DiscardLast before decoding, because
 	//there are buffered readers involved and if we invoke .Close() json will encounter unexpected EOF.
 	_ = d.DiscardLast()
 
+	ret := make([]reflect.Value, len(types))
 	for i, typ := range types {
+		elem := typ
+		if typ.Kind() == reflect.Ptr {
+			elem = typ.Elem()
+		}
+
+		v := reflect.New(elem)
+		if i < len(raws) {
+			if err := json.Unmarshal(raws[i], v.Interface()); err != nil {
+				return nil, &ArgsError{Index: i, Err: err}
+			}
+		}
 		if typ.Kind() != reflect.Ptr {
-			ret[i] = ret[i].Elem()
+			v = v.Elem()
+		}
+		ret[i] = v
+	}
+	return ret, nil
+}
+
+// argsReader gives the args of the packet as a JSON array. The args of an
+// event follow its name in the array, the data of an ack or error packet are
+// an array already, and the data of a connect packet are wrapped in one.
+func (d *Decoder) argsReader() (io.Reader, error) {
+	if d.isEvent {
+		return io.MultiReader(strings.NewReader("["), d.packetReader), nil
+	}
+
+	b, err := d.packetReader.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if err := d.packetReader.UnreadByte(); err != nil {
+		return nil, err
+	}
+
+	if b == '[' {
+		return d.packetReader, nil
+	}
+	return io.MultiReader(strings.NewReader("["), d.packetReader, strings.NewReader("]")), nil
+}
+
+// decodeBinaryArgs decodes args whose placeholders refer to the binary
+// attachments following the packet.
+func (d *Decoder) decodeBinaryArgs(r io.Reader, types []reflect.Type) ([]reflect.Value, error) {
+	var raws []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raws); err != nil {
+		_ = d.DiscardLast()
+		if discardErr := d.discardBuffers(); discardErr != nil {
+			return nil, discardErr
 		}
+		return nil, &ArgsError{Index: -1, Err: err}
 	}
+	_ = d.DiscardLast()
 
-	buffers := make([]Buffer, d.bufferCount)
+	buffers := make([][]byte, d.bufferCount)
 	for i := range buffers {
 		ft, r, err := d.r.NextReader()
 		if err != nil {
 			return nil, err
 		}
 
-		buffers[i].Data, err = d.readBuffer(ft, r)
+		buffers[i], err = d.readBuffer(ft, r)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	for i := range ret {
-		if err := d.detachBuffer(ret[i], buffers); err != nil {
-			return nil, err
+	ret := make([]reflect.Value, len(types))
+	for i, typ := range types {
+		elem := typ
+		if typ.Kind() == reflect.Ptr {
+			elem = typ.Elem()
+		}
+
+		var raw json.RawMessage
+		if i < len(raws) {
+			raw = raws[i]
+		}
+
+		v, err := detachBuffers(raw, elem, buffers)
+		if err != nil {
+			return nil, &ArgsError{Index: i, Err: err}
 		}
+		if typ.Kind() != reflect.Ptr {
+			v = v.Elem()
+		}
+		ret[i] = v
 	}
 	return ret, nil
 }
 
+// discardBuffers skips the binary attachments of the packet
+func (d *Decoder) discardBuffers() error {
+	for i := uint64(0); i < d.bufferCount; i++ {
+		ft, r, err := d.r.NextReader()
+		if err != nil {
+			return err
+		}
+		if _, err := d.readBuffer(ft, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (d *Decoder) readUint64FromText(r byteReader) (uint64, bool, error) {
 	var ret uint64
 	var hasRead bool
@@ -331,44 +413,3 @@ func (d *Decoder) readBuffer(ft session.FrameType, r io.ReadCloser) ([]byte, err
 
 	return io.ReadAll(r)
 }
-
-func (d *Decoder) detachBuffer(v reflect.Value, buffers []Buffer) error {
-	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
-		v = v.Elem()
-	}
-
-	switch v.Kind() {
-	case reflect.Struct:
-		if v.Type().Name() == bufferTypeName {
-			if !v.CanAddr() {
-				return errFailedBufferAddress
-			}
-			buffer := v.Addr().Interface().(*Buffer)
-			if buffer.isBinary {
-				*buffer = buffers[buffer.num]
-			}
-			return nil
-		}
-		for i := 0; i < v.NumField(); i++ {
-			if err := d.detachBuffer(v.Field(i), buffers); err != nil {
-				return err
-			}
-		}
-
-	case reflect.Map:
-		for _, key := range v.MapKeys() {
-			if err := d.detachBuffer(v.MapIndex(key), buffers); err != nil {
-				return err
-			}
-		}
-
-	case reflect.Array, reflect.Slice:
-		for i := 0; i < v.Len(); i++ {
-			if err := d.detachBuffer(v.Index(i), buffers); err != nil {
-				return err
-			}
-		}
-	}
-
-	return nil
-}
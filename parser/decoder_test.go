@@ -84,3 +84,123 @@ func TestDecoder(t *testing.T) {
 		})
 	}
 }
+
+func TestDecoderInvalidArgs(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	r := fakeReader{data: [][]byte{
+		[]byte("51-[\"msg\",{\"_placeholder\":true,\"num\":0}]"),
+		{1, 2, 3},
+	}}
+	decoder := NewDecoder(&r)
+
+	var header Header
+	var event string
+	must.NoError(decoder.DecodeHeader(&header, &event))
+	should.Equal("msg", event)
+
+	_, err := decoder.DecodeArgs([]reflect.Type{reflect.TypeOf(0)})
+	var argsErr *ArgsError
+	must.ErrorAs(err, &argsErr)
+	should.Equal(0, argsErr.Index)
+
+	// the attachments of the invalid packet are skipped
+	should.Equal(2, r.index)
+}
+
+func TestDecoderInvalidArgsIndex(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	tests := []struct {
+		name  string
+		data  string
+		index int
+	}{
+		{"type", `2["msg","x",1]`, 1},
+		{"syntax", `2["msg","x",`, -1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			decoder := NewDecoder(&fakeReader{data: [][]byte{[]byte(test.data)}})
+
+			var header Header
+			var event string
+			must.NoError(decoder.DecodeHeader(&header, &event))
+
+			_, err := decoder.DecodeArgs([]reflect.Type{reflect.TypeOf(""), reflect.TypeOf("")})
+			var argsErr *ArgsError
+			must.ErrorAs(err, &argsErr)
+			should.Equal(test.index, argsErr.Index)
+		})
+	}
+}
+
+type roundTripChunk struct {
+	Name string `json:"name"`
+	Data []byte `json:"data"`
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		header Header
+		args   []interface{}
+		types  []reflect.Type
+		want   []interface{}
+	}{
+		{"event",
+			Header{Event, 0, false, "/woot", ""},
+			[]interface{}{"msg", []byte{1, 2}, roundTripChunk{"chunk", []byte{3}}, "text"},
+			[]reflect.Type{reflect.TypeOf([]byte(nil)), reflect.TypeOf(roundTripChunk{}), reflect.TypeOf("")},
+			[]interface{}{[]byte{1, 2}, roundTripChunk{"chunk", []byte{3}}, "text"},
+		},
+		{"event into buffer and interface",
+			Header{Event, 1, true, "", ""},
+			[]interface{}{"msg", &Buffer{Data: []byte{1, 2}}, map[string]interface{}{"data": []byte{3}, "n": 1}},
+			[]reflect.Type{reflect.TypeOf(&Buffer{}), reflect.TypeOf((*interface{})(nil)).Elem()},
+			[]interface{}{&Buffer{Data: []byte{1, 2}}, map[string]interface{}{"data": []byte{3}, "n": float64(1)}},
+		},
+		{"ack",
+			Header{Ack, 13, true, "", ""},
+			[]interface{}{[]byte{4, 5}, Buffer{Data: []byte{6}}},
+			[]reflect.Type{reflect.TypeOf([]byte(nil)), reflect.TypeOf(Buffer{})},
+			[]interface{}{[]byte{4, 5}, Buffer{Data: []byte{6}}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			should := assert.New(t)
+			must := require.New(t)
+
+			w := fakeWriter{}
+			must.NoError(NewEncoder(&w).Encode(test.header, test.args))
+
+			data := make([][]byte, len(w.data))
+			for i := range w.data {
+				data[i] = w.data[i].Bytes()
+			}
+			must.Len(data, 3, "the packet is followed by its attachments")
+			should.Equal([]session.FrameType{session.TEXT, session.BINARY, session.BINARY}, w.types)
+
+			decoder := NewDecoder(&fakeReader{data: data})
+
+			var header Header
+			var event string
+			must.NoError(decoder.DecodeHeader(&header, &event))
+			should.Equal(test.header, header)
+
+			ret, err := decoder.DecodeArgs(test.types)
+			must.NoError(err)
+
+			got := make([]interface{}, len(ret))
+			for i := range ret {
+				got[i] = ret[i].Interface()
+			}
+			should.Equal(test.want, got)
+		})
+	}
+}
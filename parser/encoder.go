@@ -4,9 +4,9 @@ import (
 	"bufio"
 	"encoding/json"
 	"io"
-	"reflect"
 
 	"github.com/vchitai/go-socket.io/v4/engineio/session"
+	"github.com/vchitai/go-socket.io/v4/engineio/transport"
 )
 
 type FrameWriter interface {
@@ -23,21 +23,36 @@ func NewEncoder(w FrameWriter) *Encoder {
 	}
 }
 
-func (e *Encoder) Encode(h Header, args ...interface{}) (err error) {
+func (e *Encoder) Encode(h Header, args ...interface{}) error {
+	return e.encode(h, true, args)
+}
+
+// EncodeUncompressed encodes a packet as Encode, its frames not being
+// compressed by the transport.
+func (e *Encoder) EncodeUncompressed(h Header, args ...interface{}) error {
+	return e.encode(h, false, args)
+}
+
+func (e *Encoder) encode(h Header, compress bool, args []interface{}) (err error) {
+	var buffers [][]byte
+	if len(args) > 0 {
+		args = append([]interface{}{}, args...)
+		args[0], buffers = attachBuffers(args[0])
+	}
+
 	var w io.WriteCloser
-	w, err = e.w.NextWriter(session.BINARY)
+	w, err = e.nextWriter(session.TEXT, compress)
 	if err != nil {
 		return
 	}
 
-	var buffers [][]byte
-	buffers, err = e.writePacket(w, h, args)
+	err = e.writePacket(w, h, args, uint64(len(buffers)))
 	if err != nil {
 		return
 	}
 
 	for _, b := range buffers {
-		w, err = e.w.NextWriter(session.BINARY)
+		w, err = e.nextWriter(session.BINARY, compress)
 		if err != nil {
 			return
 		}
@@ -50,6 +65,20 @@ func (e *Encoder) Encode(h Header, args ...interface{}) (err error) {
 	return
 }
 
+// nextWriter returns the writer of a frame, not compressed by the transport
+// unless compress.
+func (e *Encoder) nextWriter(ft session.FrameType, compress bool) (io.WriteCloser, error) {
+	w, err := e.w.NextWriter(ft)
+	if err != nil {
+		return nil, err
+	}
+
+	if c, ok := w.(transport.Compressor); ok && !compress {
+		c.SetCompress(false)
+	}
+	return w, nil
+}
+
 type byteWriter interface {
 	io.Writer
 	WriteByte(byte) error
@@ -59,7 +88,7 @@ type flusher interface {
 	Flush() error
 }
 
-func (e *Encoder) writePacket(w io.WriteCloser, h Header, args []interface{}) ([][]byte, error) {
+func (e *Encoder) writePacket(w io.WriteCloser, h Header, args []interface{}, attachments uint64) error {
 	defer func() {
 		_ = w.Close()
 	}()
@@ -69,59 +98,53 @@ func (e *Encoder) writePacket(w io.WriteCloser, h Header, args []interface{}) ([
 		bw = bufio.NewWriter(w)
 	}
 
-	max := uint64(0)
-	buffers, err := e.attachBuffer(reflect.ValueOf(args), &max)
-	if err != nil {
-		return nil, err
-	}
-
-	if len(buffers) > 0 && (h.Type == Event || h.Type == Ack) {
+	if attachments > 0 && (h.Type == Event || h.Type == Ack) {
 		h.Type += 3
 	}
 
 	if err := bw.WriteByte(byte(h.Type + '0')); err != nil {
-		return nil, err
+		return err
 	}
 
 	if h.Type == binaryAck || h.Type == binaryEvent {
-		if err := e.writeUint64(bw, max); err != nil {
-			return nil, err
+		if err := e.writeUint64(bw, attachments); err != nil {
+			return err
 		}
 		if err := bw.WriteByte('-'); err != nil {
-			return nil, err
+			return err
 		}
 	}
 
 	if h.Namespace != "" {
 		if _, err := bw.Write([]byte(h.Namespace)); err != nil {
-			return nil, err
+			return err
 		}
 		if h.ID != 0 || args != nil {
 			if err := bw.WriteByte(','); err != nil {
-				return nil, err
+				return err
 			}
 		}
 	}
 
 	if h.NeedAck {
 		if err := e.writeUint64(bw, h.ID); err != nil {
-			return nil, err
+			return err
 		}
 	}
 
 	if len(args) > 0 {
 		if err := json.NewEncoder(bw).Encode(args[0]); err != nil {
-			return nil, err
+			return err
 		}
 	}
 
 	if f, ok := bw.(flusher); ok {
 		if err := f.Flush(); err != nil {
-			return nil, err
+			return err
 		}
 	}
 
-	return buffers, nil
+	return nil
 }
 
 func (e *Encoder) writeUint64(w byteWriter, i uint64) error {
@@ -140,57 +163,6 @@ func (e *Encoder) writeUint64(w byteWriter, i uint64) error {
 	return nil
 }
 
-func (e *Encoder) attachBuffer(v reflect.Value, index *uint64) ([][]byte, error) {
-	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
-		v = v.Elem()
-	}
-
-	var ret [][]byte
-	switch v.Kind() {
-	case reflect.Struct:
-		if v.Type().Name() == bufferTypeName {
-			if !v.CanAddr() {
-				return nil, errFailedBufferAddress
-			}
-			buffer := v.Addr().Interface().(*Buffer)
-			buffer.num = *index
-			buffer.isBinary = true
-			ret = append(ret, buffer.Data)
-			*index++
-		} else {
-			for i := 0; i < v.NumField(); i++ {
-				b, err := e.attachBuffer(v.Field(i), index)
-				if err != nil {
-					return nil, err
-				}
-				ret = append(ret, b...)
-			}
-		}
-
-	case reflect.Array, reflect.Slice:
-		for i := 0; i < v.Len(); i++ {
-			b, err := e.attachBuffer(v.Index(i), index)
-			if err != nil {
-				return nil, err
-			}
-
-			ret = append(ret, b...)
-		}
-
-	case reflect.Map:
-		for _, key := range v.MapKeys() {
-			b, err := e.attachBuffer(v.MapIndex(key), index)
-			if err != nil {
-				return nil, err
-			}
-
-			ret = append(ret, b...)
-		}
-	}
-
-	return ret, nil
-}
-
 func (e *Encoder) writeBuffer(w io.WriteCloser, buffer []byte) error {
 	defer func() {
 		_ = w.Close()
@@ -2,8 +2,8 @@ package parser
 
 import (
 	"bytes"
+	"encoding/json"
 	"io"
-	"reflect"
 	"testing"
 
 	"github.com/vchitai/go-socket.io/v4/engineio/session"
@@ -79,47 +79,83 @@ func TestAttachBuffer(t *testing.T) {
 	tests := []struct {
 		name   string
 		data   interface{}
-		max    uint64
+		json   string
 		binary [][]byte
 	}{
-		{"&Buffer", &Buffer{Data: []byte{1, 2}}, 1, [][]byte{[]byte{1, 2}}},
-		{"[]interface{}{Buffer}", []interface{}{&Buffer{Data: []byte{1, 2}}}, 1, [][]byte{[]byte{1, 2}}},
-		{"[]interface{}{Buffer,Buffer}", []interface{}{
+		{"&Buffer", &Buffer{Data: []byte{1, 2}}, `{"_placeholder":true,"num":0}`, [][]byte{{1, 2}}},
+		{"Buffer", Buffer{Data: []byte{1, 2}}, `{"_placeholder":true,"num":0}`, [][]byte{{1, 2}}},
+		{"[]byte", []byte{1, 2}, `{"_placeholder":true,"num":0}`, [][]byte{{1, 2}}},
+		{"[]interface{}{Buffer}", []interface{}{&Buffer{Data: []byte{1, 2}}}, `[{"_placeholder":true,"num":0}]`, [][]byte{{1, 2}}},
+		{"[]interface{}{Buffer,[]byte}", []interface{}{
 			&Buffer{Data: []byte{1, 2}},
-			&Buffer{Data: []byte{3, 4}},
-		}, 2, [][]byte{[]byte{1, 2}, []byte{3, 4}}},
-		{"[1]interface{}{Buffer}", [...]interface{}{&Buffer{Data: []byte{1, 2}}}, 1, [][]byte{[]byte{1, 2}}},
-		{"[2]interface{}{Buffer,Buffer}", [...]interface{}{
-			&Buffer{Data: []byte{1, 2}},
-			&Buffer{Data: []byte{3, 4}},
-		}, 2, [][]byte{[]byte{1, 2}, []byte{3, 4}}},
+			[]byte{3, 4},
+		}, `[{"_placeholder":true,"num":0},{"_placeholder":true,"num":1}]`, [][]byte{{1, 2}, {3, 4}}},
+		{"[1]interface{}{Buffer}", [...]interface{}{&Buffer{Data: []byte{1, 2}}}, `[{"_placeholder":true,"num":0}]`, [][]byte{{1, 2}}},
 		{"Struct{Buffer}", struct {
-			Data *Buffer
-			I    int
+			Data *Buffer `json:"data"`
+			I    int     `json:"i,omitempty"`
+			J    int     `json:"-"`
 		}{
 			&Buffer{Data: []byte{1, 2}},
+			0,
 			3,
-		}, 1, [][]byte{[]byte{1, 2}}},
-		{"map{Buffer}", map[string]interface{}{
-			"data": &Buffer{Data: []byte{1, 2}},
+		}, `{"data":{"_placeholder":true,"num":0}}`, [][]byte{{1, 2}}},
+		{"map{[]byte}", map[string]interface{}{
+			"data": []byte{1, 2},
 			"i":    3,
-		}, 1, [][]byte{[]byte{1, 2}}},
+		}, `{"data":{"_placeholder":true,"num":0},"i":3}`, [][]byte{{1, 2}}},
+		{"no binary", []interface{}{"msg", 1}, `["msg",1]`, nil},
+		{"json.RawMessage", json.RawMessage(`{"a":1}`), `{"a":1}`, nil},
 	}
 
-	e := Encoder{}
-
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			should := assert.New(t)
 			must := require.New(t)
 
-			index := uint64(0)
-			b, err := e.attachBuffer(reflect.ValueOf(test.data), &index)
+			v, b := attachBuffers(test.data)
 
+			j, err := json.Marshal(v)
 			must.NoError(err)
 
-			should.Equal(test.max, index)
+			should.JSONEq(test.json, string(j))
 			should.Equal(test.binary, b)
 		})
 	}
 }
+
+// compressWriter is a fakeWriter recording the frames not compressed
+type compressWriter struct {
+	fakeWriter
+
+	uncompressed int
+}
+
+func (w *compressWriter) NextWriter(ft session.FrameType) (io.WriteCloser, error) {
+	_, _ = w.fakeWriter.NextWriter(ft)
+	return w, nil
+}
+
+func (w *compressWriter) SetCompress(compress bool) {
+	if !compress {
+		w.uncompressed++
+	}
+}
+
+func TestEncodeUncompressed(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	w := compressWriter{}
+	encoder := NewEncoder(&w)
+	header := Header{Type: Event}
+	args := []interface{}{"msg", []byte{1, 2}}
+
+	must.NoError(encoder.Encode(header, args))
+	should.Equal(0, w.uncompressed)
+
+	// every frame of the packet is not compressed
+	must.NoError(encoder.EncodeUncompressed(header, args))
+	should.Equal(2, w.uncompressed)
+	should.Equal(4, len(w.data))
+}
@@ -9,5 +9,23 @@ var (
 
 	errInvalidFirstPacketType = errors.New("first packet should be text frame")
 
-	errFailedBufferAddress = errors.New("can't get Buffer address")
+	errMissingAttachment = errors.New("placeholder without binary attachment")
 )
+
+// ArgsError is returned by DecodeArgs when the arguments of a packet are not
+// valid JSON or do not fit the types of the handler. The packet is discarded,
+// so the next one can be decoded.
+type ArgsError struct {
+	// Index is the index of the argument which does not fit its type, or -1
+	// when the arguments are not valid JSON.
+	Index int
+	Err   error
+}
+
+func (e *ArgsError) Error() string {
+	return "invalid args: " + e.Err.Error()
+}
+
+func (e *ArgsError) Unwrap() error {
+	return e.Err
+}
@@ -36,4 +36,7 @@ type Payload struct {
 
 	Data []interface{}
 	Args []interface{}
+
+	// NoCompress disables the compression of the packet by the transport.
+	NoCompress bool
 }
@@ -0,0 +1,126 @@
+package socketio
+
+import (
+	"context"
+	"sync"
+)
+
+// PresenceHandler is called when a user comes online with its first socket in
+// the cluster, or goes offline when its last socket disconnected or expired.
+type PresenceHandler func(user string, online bool)
+
+// presenceTracker is implemented by the broadcasters tracking the user the
+// connections are bound to.
+type presenceTracker interface {
+	bindUser(user string, conn Conn)
+	unbindUser(conn Conn)
+	userSockets(ctx context.Context, user string) ([]string, error)
+	onPresence(f PresenceHandler)
+}
+
+func newLocalPresence() *localPresence {
+	return &localPresence{
+		users:     make(map[string]map[string]Conn),
+		connUsers: make(map[string]string),
+	}
+}
+
+// localPresence maps the users to the connections of this node.
+type localPresence struct {
+	users     map[string]map[string]Conn
+	connUsers map[string]string
+	mutex     sync.RWMutex
+
+	handler      PresenceHandler
+	handlerMutex sync.RWMutex
+}
+
+// bind binds conn to user. It returns the user conn was bound to before,
+// which is user itself when nothing changed, and whether user came online and
+// the previous user went offline on this node.
+func (p *localPresence) bind(user string, conn Conn) (prev string, online, prevOffline bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	prev = p.connUsers[conn.ID()]
+	if prev == user {
+		return prev, false, false
+	}
+	if prev != "" {
+		prevOffline = p.remove(prev, conn.ID())
+	}
+
+	conns, ok := p.users[user]
+	if !ok {
+		conns = make(map[string]Conn)
+		p.users[user] = conns
+	}
+	conns[conn.ID()] = conn
+	p.connUsers[conn.ID()] = user
+
+	return prev, len(conns) == 1, prevOffline
+}
+
+// unbind removes the binding of conn. It returns the user conn was bound to,
+// if any, and whether the user went offline on this node.
+func (p *localPresence) unbind(conn Conn) (user string, offline bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	user, ok := p.connUsers[conn.ID()]
+	if !ok {
+		return "", false
+	}
+
+	return user, p.remove(user, conn.ID())
+}
+
+func (p *localPresence) remove(user, connID string) bool {
+	delete(p.connUsers, connID)
+
+	conns := p.users[user]
+	delete(conns, connID)
+	if len(conns) > 0 {
+		return false
+	}
+
+	delete(p.users, user)
+	return true
+}
+
+// sockets gives the ids of the connections of user on this node
+func (p *localPresence) sockets(user string) []string {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	return getKeysOfMap(p.users[user])
+}
+
+// bindings gives the ids of the bound connections of this node, by user
+func (p *localPresence) bindings() map[string][]string {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	bindings := make(map[string][]string, len(p.users))
+	for user, conns := range p.users {
+		bindings[user] = getKeysOfMap(conns)
+	}
+	return bindings
+}
+
+func (p *localPresence) setHandler(f PresenceHandler) {
+	p.handlerMutex.Lock()
+	defer p.handlerMutex.Unlock()
+
+	p.handler = f
+}
+
+func (p *localPresence) notify(user string, online bool) {
+	p.handlerMutex.RLock()
+	handler := p.handler
+	p.handlerMutex.RUnlock()
+
+	if handler != nil {
+		handler(user, online)
+	}
+}
@@ -0,0 +1,195 @@
+package socketio
+
+import (
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type presenceRecorder struct {
+	events []string
+	mutex  sync.Mutex
+}
+
+func (r *presenceRecorder) handle(user string, online bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if online {
+		r.events = append(r.events, user+" online")
+	} else {
+		r.events = append(r.events, user+" offline")
+	}
+}
+
+func (r *presenceRecorder) get() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return append([]string{}, r.events...)
+}
+
+func TestPresence(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	srv := NewServer(nil)
+	srv.OnConnect("/", func(Conn, map[string]interface{}) error {
+		return nil
+	})
+
+	var recorder presenceRecorder
+	srv.OnPresence("/", recorder.handle)
+
+	c1 := newBusTestConn("c1")
+	c2 := newBusTestConn("c2")
+
+	must.True(srv.BindUser("/", "alice", c1))
+	must.True(srv.BindUser("/", "alice", c2))
+	must.True(srv.BindUser("/", "alice", c2))
+	should.True(srv.IsOnline("/", "alice"))
+
+	sockets := srv.UserSockets("/", "alice")
+	sort.Strings(sockets)
+	should.Equal([]string{"c1", "c2"}, sockets)
+
+	// rebinding moves the connection to the other user
+	must.True(srv.BindUser("/", "bob", c1))
+	should.Equal([]string{"c2"}, srv.UserSockets("/", "alice"))
+
+	must.True(srv.UnbindUser("/", c2))
+	should.False(srv.IsOnline("/", "alice"))
+	should.True(srv.IsOnline("/", "bob"))
+
+	should.Equal([]string{"alice online", "bob online", "alice offline"}, recorder.get())
+
+	should.False(srv.BindUser("/unknown", "alice", c1))
+	should.False(srv.IsOnline("/unknown", "bob"))
+}
+
+func TestBusAdapterPresence(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	servers := newBusTestServers(t, 2, BusAdapterConfig{Bus: NewBus()})
+
+	recorders := make([]presenceRecorder, len(servers))
+	for i, srv := range servers {
+		srv.OnPresence("/", recorders[i].handle)
+	}
+
+	c1 := newBusTestConn("c1")
+	c2 := newBusTestConn("c2")
+	must.True(servers[0].BindUser("/", "alice", c1))
+	must.True(servers[1].BindUser("/", "alice", c2))
+
+	should.True(servers[1].IsOnline("/", "alice"))
+	sockets := servers[1].UserSockets("/", "alice")
+	sort.Strings(sockets)
+	should.Equal([]string{"c1", "c2"}, sockets)
+
+	must.True(servers[0].UnbindUser("/", c1))
+	should.True(servers[0].IsOnline("/", "alice"))
+	must.True(servers[1].UnbindUser("/", c2))
+	should.False(servers[0].IsOnline("/", "alice"))
+
+	for i := range recorders {
+		should.Equal([]string{"alice online", "alice offline"}, recorders[i].get())
+	}
+}
+
+func TestBusAdapterPresenceConcurrent(t *testing.T) {
+	should := assert.New(t)
+
+	servers := newBusTestServers(t, 2, BusAdapterConfig{Bus: NewBus()})
+
+	recorders := make([]presenceRecorder, len(servers))
+	nodes := make([]*broadcastRemote, len(servers))
+	for i, srv := range servers {
+		srv.OnPresence("/", recorders[i].handle)
+		nodes[i] = srv.getNamespaceHandler("/").broadcast.(*broadcastRemote)
+	}
+	conns := []Conn{newBusTestConn("c1"), newBusTestConn("c2")}
+
+	// both nodes bind the user before either tells the bus
+	for i, node := range nodes {
+		node.local.presence.bind("alice", conns[i])
+	}
+	for i, node := range nodes {
+		node.remote.bindUser("alice", conns[i].ID())
+	}
+
+	// and unbind it the same way
+	for i, node := range nodes {
+		node.local.presence.unbind(conns[i])
+	}
+	for i, node := range nodes {
+		node.remote.unbindUser("alice", conns[i].ID())
+	}
+
+	for i := range recorders {
+		should.Equal([]string{"alice online", "alice offline"}, recorders[i].get())
+	}
+
+	// the nodes binding and unbinding at the same time
+	for round := 0; round < 50; round++ {
+		var wg sync.WaitGroup
+		start := make(chan struct{})
+		for i, srv := range servers {
+			wg.Add(1)
+			go func(srv *Server, conn Conn) {
+				defer wg.Done()
+				<-start
+				srv.BindUser("/", "bob", conn)
+				srv.UnbindUser("/", conn)
+			}(srv, conns[i])
+		}
+		close(start)
+		wg.Wait()
+	}
+
+	for i := range recorders {
+		var online, offline int
+		for _, event := range recorders[i].get() {
+			switch event {
+			case "bob online":
+				online++
+			case "bob offline":
+				offline++
+			}
+		}
+		should.Equal(online, offline)
+		should.GreaterOrEqual(online, 50)
+	}
+	should.False(servers[0].IsOnline("/", "bob"))
+}
+
+func TestBusAdapterPresenceClose(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	servers := newBusTestServers(t, 2, BusAdapterConfig{Bus: NewBus()})
+
+	var recorder presenceRecorder
+	servers[0].OnPresence("/", recorder.handle)
+
+	c1 := newBusTestConn("c1")
+	c2 := newBusTestConn("c2")
+	must.True(servers[1].BindUser("/", "alice", c1))
+	must.True(servers[1].BindUser("/", "alice", c2))
+	must.True(servers[1].BindUser("/", "bob", newBusTestConn("c3")))
+	should.True(servers[0].IsOnline("/", "alice"))
+
+	// the users of a closed node go offline on the others
+	must.NoError(servers[1].Close())
+	should.False(servers[0].IsOnline("/", "alice"))
+	should.False(servers[0].IsOnline("/", "bob"))
+	should.Empty(servers[0].UserSockets("/", "alice"))
+
+	events := recorder.get()
+	sort.Strings(events)
+	should.Equal([]string{"alice offline", "alice online", "bob offline", "bob online"}, events)
+}
@@ -0,0 +1,12 @@
+package socketio
+
+import "context"
+
+// roomDataStore is implemented by the broadcasters attaching data to their
+// rooms. The data are encoded in JSON and versioned, the version increasing
+// with every change. They are deleted with the room.
+type roomDataStore interface {
+	setRoomData(ctx context.Context, room string, data []byte) (int64, error)
+	roomData(ctx context.Context, room string) ([]byte, int64, error)
+	updateRoomData(ctx context.Context, room string, version int64, data []byte) (int64, error)
+}
@@ -0,0 +1,97 @@
+package socketio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type roomTopic struct {
+	Topic string `json:"topic"`
+	Owner string `json:"owner"`
+}
+
+func testRoomData(t *testing.T, servers []*Server) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	first, last := servers[0], servers[len(servers)-1]
+
+	var data roomTopic
+	_, err := first.RoomData("/", "room", &data)
+	should.ErrorIs(err, ErrRoomNotFound)
+	should.ErrorIs(first.SetRoomData("/", "room", roomTopic{}), ErrRoomNotFound)
+
+	c1 := newBusTestConn("c1")
+	c2 := newBusTestConn("c2")
+	must.True(first.JoinRoom("/", "room", c1))
+	must.True(last.JoinRoom("/", "room", c2))
+
+	version, err := last.RoomData("/", "room", &data)
+	must.NoError(err)
+	should.Zero(version)
+
+	must.NoError(first.SetRoomData("/", "room", roomTopic{Topic: "go", Owner: "c1"}))
+
+	version, err = last.RoomData("/", "room", &data)
+	must.NoError(err)
+	should.Equal(int64(1), version)
+	should.Equal(roomTopic{Topic: "go", Owner: "c1"}, data)
+
+	// only the first of concurrent updates from the same version succeeds
+	version, err = last.UpdateRoomData("/", "room", version, roomTopic{Topic: "rust", Owner: "c2"})
+	must.NoError(err)
+	should.Equal(int64(2), version)
+
+	current, err := first.UpdateRoomData("/", "room", 1, roomTopic{Topic: "zig"})
+	should.ErrorIs(err, ErrRoomDataConflict)
+	should.Equal(int64(2), current)
+
+	_, err = first.RoomData("/", "room", &data)
+	must.NoError(err)
+	should.Equal("rust", data.Topic)
+
+	// the data live as long as the room has connections
+	must.True(first.LeaveRoom("/", "room", c1))
+	_, err = last.RoomData("/", "room", &data)
+	must.NoError(err)
+	should.Equal("rust", data.Topic)
+
+	must.True(last.LeaveRoom("/", "room", c2))
+	_, err = first.RoomData("/", "room", &data)
+	should.ErrorIs(err, ErrRoomNotFound)
+
+	must.True(last.JoinRoom("/", "room", c2))
+	version, err = first.RoomData("/", "room", &roomTopic{})
+	must.NoError(err)
+	should.Zero(version, "the data of a deleted room are not restored")
+
+	// clearing the room deletes its data
+	must.NoError(first.SetRoomData("/", "room", roomTopic{Topic: "go"}))
+	must.True(first.ClearRoom("/", "room"))
+	must.Eventually(func() bool {
+		return last.RoomLen("/", "room") == 0
+	}, time.Second, 10*time.Millisecond, "the other nodes clear the room asynchronously")
+	must.True(last.JoinRoom("/", "room", c2))
+	version, err = first.RoomData("/", "room", &roomTopic{})
+	must.NoError(err)
+	should.Zero(version)
+}
+
+func TestRoomData(t *testing.T) {
+	srv := NewServer(nil)
+	srv.OnConnect("/", func(Conn, map[string]interface{}) error {
+		return nil
+	})
+
+	testRoomData(t, []*Server{srv})
+
+	_, err := srv.RoomData("/unknown", "room", &roomTopic{})
+	assert.Error(t, err)
+}
+
+func TestBusAdapterRoomData(t *testing.T) {
+	testRoomData(t, newBusTestServers(t, 2, BusAdapterConfig{Bus: NewBus()}))
+}
@@ -1,6 +1,7 @@
 package socketio
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/vchitai/go-socket.io/v4/engineio"
@@ -12,6 +13,7 @@ type Server struct {
 
 	nspHandlers  *Handlers
 	redisAdapter *RedisAdapterConfig
+	busAdapter   *BusAdapterConfig
 }
 
 // NewServer returns a server.
@@ -29,8 +31,23 @@ func (s *Server) Adapter(opts *RedisAdapterConfig) (bool, error) {
 	return true, nil
 }
 
-// Close closes server.
+// BusAdapter sets in-process bus broadcast adapter.
+func (s *Server) BusAdapter(opts *BusAdapterConfig) (bool, error) {
+	if opts == nil || opts.Bus == nil {
+		return false, errBusRequired
+	}
+
+	s.busAdapter = opts
+
+	return true, nil
+}
+
+// Close closes server and the broadcast adapters of its namespaces.
 func (s *Server) Close() error {
+	s.nspHandlers.Range(func(_ string, handler *Handler) {
+		_ = handler.close()
+	})
+
 	return s.engine.Close()
 }
 
@@ -57,7 +74,35 @@ func (s *Server) OnError(namespace string, f OnErrorHandler) {
 	h.OnError(f)
 }
 
+// OnRoomCreated set a handler function f called when a room of the namespace
+// gets its first connection on this node.
+func (s *Server) OnRoomCreated(namespace string, f RoomHandler) {
+	h := s.getOrCreateNamespaceHandler(namespace)
+	h.OnRoomCreated(f)
+}
+
+// OnRoomDeleted set a handler function f called when a room of the namespace
+// is cleared or its last connection on this node leaves it.
+func (s *Server) OnRoomDeleted(namespace string, f RoomHandler) {
+	h := s.getOrCreateNamespaceHandler(namespace)
+	h.OnRoomDeleted(f)
+}
+
+// OnJoin set a handler function f called when a connection joins a room of the namespace.
+func (s *Server) OnJoin(namespace string, f RoomConnHandler) {
+	h := s.getOrCreateNamespaceHandler(namespace)
+	h.OnJoin(f)
+}
+
+// OnLeave set a handler function f called when a connection leaves a room of the namespace.
+func (s *Server) OnLeave(namespace string, f RoomConnHandler) {
+	h := s.getOrCreateNamespaceHandler(namespace)
+	h.OnLeave(f)
+}
+
 // OnEvent set a handler function f to handle event for
+// namespace. The events prefixed with "$stream:" are reserved for the streams,
+// OnEvent panics with them.
 func (s *Server) OnEvent(namespace string, event string, f interface{}) {
 	h := s.getOrCreateNamespaceHandler(namespace)
 	h.OnEvent(event, f)
@@ -124,18 +169,151 @@ func (s *Server) RoomLen(namespace string, room string) int {
 	return nspHandler.Len(room)
 }
 
+// RoomLenContext gives number of connections in the room. When some nodes of
+// the cluster did not answer before ctx is done or the adapter requests timeout,
+// it returns the connections counted so far with ErrRequestTimeout.
+func (s *Server) RoomLenContext(ctx context.Context, namespace string, room string) (int, error) {
+	nspHandler := s.getNamespaceHandler(namespace)
+	return nspHandler.LenContext(ctx, room)
+}
+
 // Rooms gives list of all the rooms.
 func (s *Server) Rooms(namespace string) []string {
 	nspHandler := s.getNamespaceHandler(namespace)
 	return nspHandler.Rooms(nil)
 }
 
+// RoomsContext gives list of all the rooms. When some nodes of the cluster did
+// not answer before ctx is done or the adapter requests timeout, it returns the
+// rooms known so far with ErrRequestTimeout.
+func (s *Server) RoomsContext(ctx context.Context, namespace string) ([]string, error) {
+	nspHandler := s.getNamespaceHandler(namespace)
+	return nspHandler.AllRoomsContext(ctx)
+}
+
 // ForEach sends data by DataFunc, if room does not exit sends anything.
 func (s *Server) ForEach(namespace string, room string, f EachFunc) bool {
 	nspHandler := s.getNamespaceHandler(namespace)
 	return nspHandler.ForEach(room, f)
 }
 
+// SetRoomData attaches data, encoded in JSON, to a room with connections. The
+// data are shared by the nodes of the cluster and deleted with the room, when
+// its last connection leaves it or it is cleared.
+func (s *Server) SetRoomData(namespace string, room string, data interface{}) error {
+	nspHandler := s.getNamespaceHandler(namespace)
+	_, err := nspHandler.SetRoomData(context.Background(), room, data)
+	return err
+}
+
+// RoomData decodes the data attached to the room into v, and returns their
+// version for UpdateRoomData. The version is 0, and v left untouched, when no
+// data was attached.
+func (s *Server) RoomData(namespace string, room string, v interface{}) (int64, error) {
+	nspHandler := s.getNamespaceHandler(namespace)
+	return nspHandler.RoomData(context.Background(), room, v)
+}
+
+// UpdateRoomData attaches data to the room if its data did not change since
+// version was read, version 0 meaning no data. Otherwise, it fails with
+// ErrRoomDataConflict and the current version. It returns the new version.
+func (s *Server) UpdateRoomData(namespace string, room string, version int64, data interface{}) (int64, error) {
+	nspHandler := s.getNamespaceHandler(namespace)
+	return nspHandler.UpdateRoomData(context.Background(), room, version, data)
+}
+
+// Socket gives the connection of this node with the id in the namespace.
+func (s *Server) Socket(namespace string, id string) (Conn, bool) {
+	nspHandler := s.getNamespaceHandler(namespace)
+	return nspHandler.Socket(id)
+}
+
+// Sockets gives the connections of this node in the namespace. FetchSockets
+// describes the connections of every node of the cluster.
+func (s *Server) Sockets(namespace string) []Conn {
+	nspHandler := s.getNamespaceHandler(namespace)
+	return nspHandler.Sockets()
+}
+
+// EmitTo sends given event & args to the connection with the id in the
+// namespace, wherever it lives in the cluster. It returns false when the
+// connection is not on this node and the server has no adapter.
+func (s *Server) EmitTo(namespace string, id string, event string, args ...interface{}) bool {
+	nspHandler := s.getNamespaceHandler(namespace)
+	return nspHandler.EmitTo(id, event, args...)
+}
+
+// FetchSockets describes the connections in the room, every connection of the
+// namespace when room is empty, whose data match filter on every node of the
+// cluster. A nil filter matches every connection.
+func (s *Server) FetchSockets(namespace string, room string, filter DataFilter) []SocketInfo {
+	sockets, _ := s.FetchSocketsContext(context.Background(), namespace, room, filter)
+	return sockets
+}
+
+// FetchSocketsContext describes the connections in the room whose data match
+// filter. When some nodes of the cluster did not answer before ctx is done or
+// the adapter requests timeout, it returns the connections known so far with
+// ErrRequestTimeout.
+func (s *Server) FetchSocketsContext(ctx context.Context, namespace string, room string, filter DataFilter) ([]SocketInfo, error) {
+	nspHandler := s.getNamespaceHandler(namespace)
+	return nspHandler.FetchSockets(ctx, room, filter)
+}
+
+// BroadcastWhere broadcasts given event & args to the connections in the room,
+// every connection of the namespace when room is empty, whose data match filter.
+func (s *Server) BroadcastWhere(namespace string, room string, filter DataFilter, event string, args ...interface{}) bool {
+	nspHandler := s.getNamespaceHandler(namespace)
+	return nspHandler.SendWhere(room, filter, event, args...)
+}
+
+// BindUser binds the connection to the user, replacing its previous user. The
+// binding is removed when the connection leaves the namespace.
+func (s *Server) BindUser(namespace string, user string, conn Conn) bool {
+	nspHandler := s.getNamespaceHandler(namespace)
+	return nspHandler.BindUser(user, conn)
+}
+
+// UnbindUser removes the binding of the connection to its user.
+func (s *Server) UnbindUser(namespace string, conn Conn) bool {
+	nspHandler := s.getNamespaceHandler(namespace)
+	return nspHandler.UnbindUser(conn)
+}
+
+// IsOnline tells whether the user has a connection anywhere in the cluster.
+func (s *Server) IsOnline(namespace string, user string) bool {
+	return len(s.UserSockets(namespace, user)) > 0
+}
+
+// UserSockets gives the ids of the connections of the user on every node.
+func (s *Server) UserSockets(namespace string, user string) []string {
+	sockets, _ := s.UserSocketsContext(context.Background(), namespace, user)
+	return sockets
+}
+
+// UserSocketsContext gives the ids of the connections of the user on every
+// node, or the connections known so far with an error if the nodes could not
+// be queried before ctx is done.
+func (s *Server) UserSocketsContext(ctx context.Context, namespace string, user string) ([]string, error) {
+	nspHandler := s.getNamespaceHandler(namespace)
+	return nspHandler.UserSockets(ctx, user)
+}
+
+// OnPresence set a handler function f called when a user comes online with its
+// first connection in the cluster, or goes offline with its last one.
+func (s *Server) OnPresence(namespace string, f PresenceHandler) {
+	h := s.getOrCreateNamespaceHandler(namespace)
+	h.OnPresence(f)
+}
+
+// AdapterHealth gives the state of the broadcast adapter of the namespace.
+// A redis adapter reports AdapterReconnecting while its subscription is
+// restored, during which broadcasts of the other nodes are not received.
+func (s *Server) AdapterHealth(namespace string) AdapterHealth {
+	nspHandler := s.getNamespaceHandler(namespace)
+	return nspHandler.Health()
+}
+
 // Count number of connections.
 func (s *Server) Count() int {
 	return s.engine.Count()
@@ -155,7 +333,12 @@ func (s *Server) createNamespaceHandler(nsp string) *Handler {
 		nsp = rootNamespace
 	}
 
-	handler := NewHandler(nsp, s.redisAdapter)
+	var handler *Handler
+	if s.busAdapter != nil {
+		handler = newHandler(newBroadcastBus(nsp, s.busAdapter))
+	} else {
+		handler = NewHandler(nsp, s.redisAdapter)
+	}
 	s.nspHandlers.Set(nsp, handler)
 
 	return handler
@@ -0,0 +1,153 @@
+package socketio
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestHTTPServer serves srv over HTTP until the end of the test, and
+// returns its URL
+func newTestHTTPServer(t *testing.T, srv *Server) string {
+	go func() {
+		_ = srv.Serve()
+	}()
+
+	httpSrv := httptest.NewServer(srv)
+	t.Cleanup(func() {
+		httpSrv.Close()
+		_ = srv.Close()
+	})
+	return httpSrv.URL
+}
+
+// testClient is a socket.io client of the root namespace over the websocket
+// transport
+type testClient struct {
+	ws     *websocket.Conn
+	sid    string
+	events chan []interface{}
+}
+
+func newTestClient(t *testing.T, url string) *testClient {
+	must := require.New(t)
+
+	url = "ws" + strings.TrimPrefix(url, "http") + "/socket.io/?EIO=4&transport=websocket"
+	ws, _, err := websocket.DefaultDialer.Dial(url, nil)
+	must.NoError(err)
+	t.Cleanup(func() {
+		_ = ws.Close()
+	})
+
+	// the engine.io open packet, then the connection to the namespace
+	_, msg, err := ws.ReadMessage()
+	must.NoError(err)
+	must.Equal(byte('0'), msg[0], "unexpected packet %s", msg)
+	must.NoError(ws.WriteMessage(websocket.TextMessage, []byte("40")))
+
+	c := &testClient{
+		ws:     ws,
+		events: make(chan []interface{}, 10),
+	}
+
+	// the events emitted from OnConnect come before the connection ack
+	for c.sid == "" {
+		_, msg, err = ws.ReadMessage()
+		must.NoError(err)
+		if c.onEvent(msg) {
+			continue
+		}
+
+		must.True(strings.HasPrefix(string(msg), "40"), "unexpected packet %s", msg)
+		var connected struct {
+			SID string `json:"sid"`
+		}
+		must.NoError(json.Unmarshal(msg[2:], &connected))
+		c.sid = connected.SID
+	}
+
+	go c.read()
+	return c
+}
+
+// onEvent queues the event of msg, it returns false if msg is not an event
+func (c *testClient) onEvent(msg []byte) bool {
+	if !strings.HasPrefix(string(msg), "42") {
+		return false
+	}
+
+	var event []interface{}
+	if json.Unmarshal(msg[2:], &event) == nil {
+		c.events <- event
+	}
+	return true
+}
+
+// read receives the events, answering the pings, until the connection closes
+func (c *testClient) read() {
+	defer close(c.events)
+
+	for {
+		_, msg, err := c.ws.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		if string(msg) == "2" {
+			_ = c.ws.WriteMessage(websocket.TextMessage, []byte("3"))
+			continue
+		}
+		c.onEvent(msg)
+	}
+}
+
+func (c *testClient) emit(t *testing.T, event string, args ...interface{}) {
+	msg, err := json.Marshal(append([]interface{}{event}, args...))
+	require.NoError(t, err)
+	require.NoError(t, c.ws.WriteMessage(websocket.TextMessage, append([]byte("42"), msg...)))
+}
+
+// expect waits for the next event of the client
+func (c *testClient) expect(t *testing.T, expected ...interface{}) {
+	select {
+	case got := <-c.events:
+		assert.Equal(t, expected, got)
+	case <-time.After(time.Second):
+		t.Fatalf("%v not received", expected)
+	}
+}
+
+func TestServerEmitTo(t *testing.T) {
+	should := assert.New(t)
+
+	srv := NewServer(nil)
+	// the connection is found from its own OnConnect
+	welcomed := make(chan bool, 2)
+	srv.OnConnect("/", func(c Conn, _ map[string]interface{}) error {
+		welcomed <- srv.EmitTo("/", c.ID(), "welcome", c.ID())
+		return nil
+	})
+	srv.OnEvent("/", "to", func(_ Conn, id string) {
+		srv.EmitTo("/", id, "event", id)
+	})
+	url := newTestHTTPServer(t, srv)
+
+	c1 := newTestClient(t, url)
+	should.True(<-welcomed)
+	c1.expect(t, "welcome", c1.sid)
+	c2 := newTestClient(t, url)
+	should.True(<-welcomed)
+	c2.expect(t, "welcome", c2.sid)
+
+	c1.emit(t, "to", c2.sid)
+	c2.expect(t, "event", c2.sid)
+
+	// without an adapter, the unknown connections are not on another node
+	should.False(srv.EmitTo("/", "unknown", "event"))
+}
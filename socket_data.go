@@ -0,0 +1,172 @@
+package socketio
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// SocketData is the data attached to a connection of a namespace. The values
+// are encoded in JSON when other nodes of the cluster query the connections,
+// so they should be serializable. SocketData is safe for concurrent use, by
+// the event handlers as well as by the broadcast callbacks.
+type SocketData struct {
+	values map[string]interface{}
+	mutex  sync.RWMutex
+}
+
+func newSocketData() *SocketData {
+	return &SocketData{
+		values: make(map[string]interface{}),
+	}
+}
+
+// Get gives the value of key.
+func (d *SocketData) Get(key string) (interface{}, bool) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	v, ok := d.values[key]
+	return v, ok
+}
+
+// Set sets the value of key.
+func (d *SocketData) Set(key string, value interface{}) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.values[key] = value
+}
+
+// Delete removes key.
+func (d *SocketData) Delete(key string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	delete(d.values, key)
+}
+
+// Values gives a copy of the values.
+func (d *SocketData) Values() SocketValues {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	values := make(SocketValues, len(d.values))
+	for k, v := range d.values {
+		values[k] = v
+	}
+	return values
+}
+
+// MarshalJSON encodes the values as a JSON object.
+func (d *SocketData) MarshalJSON() ([]byte, error) {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	return json.Marshal(d.values)
+}
+
+// DataHolder is implemented by the connections holding data, which the
+// connections of the server do. Unlike the context, the data are returned to
+// the other nodes of the cluster querying the connections, and can be used to
+// filter broadcasts.
+type DataHolder interface {
+	Data() *SocketData
+}
+
+// ConnData gives the data of conn, or nil when it holds none.
+func ConnData(conn Conn) *SocketData {
+	if holder, ok := conn.(DataHolder); ok {
+		return holder.Data()
+	}
+	return nil
+}
+
+// connValues gives the values of the data of conn, empty when it holds none
+func connValues(conn Conn) SocketValues {
+	if data := ConnData(conn); data != nil {
+		return data.Values()
+	}
+	return SocketValues{}
+}
+
+// SocketValues are the values of the data of a connection, as returned by
+// the socket queries.
+type SocketValues map[string]interface{}
+
+// Get gives the value of key.
+func (v SocketValues) Get(key string) (interface{}, bool) {
+	value, ok := v[key]
+	return value, ok
+}
+
+// DataGetter is implemented by *SocketData and SocketValues.
+type DataGetter interface {
+	Get(key string) (interface{}, bool)
+}
+
+// DataValue gives the value of key in data as a T. The values of the
+// connections of other nodes are decoded from JSON, they are converted to T
+// through JSON when they are not a T already.
+func DataValue[T any](data DataGetter, key string) (T, bool) {
+	var res T
+
+	v, ok := data.Get(key)
+	if !ok {
+		return res, false
+	}
+	if res, ok := v.(T); ok {
+		return res, true
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return res, false
+	}
+	if err := json.Unmarshal(b, &res); err != nil {
+		return res, false
+	}
+	return res, true
+}
+
+// DataFilter selects the connections whose data have every key of the filter
+// with an equal value, the values being compared by their JSON encoding.
+type DataFilter map[string]interface{}
+
+// match tells whether values have every key of the filter with an equal value
+func (f DataFilter) match(values SocketValues) bool {
+	for key, want := range f {
+		got, ok := values[key]
+		if !ok || !jsonEqual(got, want) {
+			return false
+		}
+	}
+	return true
+}
+
+func jsonEqual(a, b interface{}) bool {
+	aJSON, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	bJSON, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(aJSON, bJSON)
+}
+
+// SocketInfo describes a connection of a node of the cluster.
+type SocketInfo struct {
+	ID    string
+	Rooms []string
+	Data  SocketValues
+}
+
+// socketQuerier is implemented by the broadcasters able to list the
+// connections with their data, and to broadcast to those matching a filter.
+type socketQuerier interface {
+	fetchSockets(ctx context.Context, room string, filter DataFilter) ([]SocketInfo, error)
+	sendWhere(room string, filter DataFilter, event string, args ...interface{})
+}
@@ -0,0 +1,122 @@
+package socketio
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSocketData(t *testing.T) {
+	should := assert.New(t)
+
+	data := newSocketData()
+	data.Set("user", "alice")
+	data.Set("level", 3)
+
+	level, ok := DataValue[int](data, "level")
+	should.True(ok)
+	should.Equal(3, level)
+
+	_, ok = DataValue[int](data, "user")
+	should.False(ok)
+
+	// values decoded from JSON are converted to the requested type
+	values := SocketValues{"level": float64(3)}
+	level, ok = DataValue[int](values, "level")
+	should.True(ok)
+	should.Equal(3, level)
+
+	should.True(DataFilter{"level": 3}.match(data.Values()))
+	should.True(DataFilter{"level": 3}.match(values))
+	should.False(DataFilter{"level": 3, "user": "bob"}.match(data.Values()))
+	should.True(DataFilter(nil).match(data.Values()))
+
+	data.Delete("user")
+	_, ok = data.Get("user")
+	should.False(ok)
+}
+
+func testSocketData(t *testing.T, servers []*Server) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	first, last := servers[0], servers[len(servers)-1]
+
+	c1 := newBusTestConn("c1")
+	c2 := newBusTestConn("c2")
+	c3 := newBusTestConn("c3")
+	c1.Data().Set("team", "red")
+	c2.Data().Set("team", "blue")
+	c3.Data().Set("team", "red")
+	c3.Data().Set("level", 2)
+
+	// the connections join the room of their id like the real ones, which
+	// the node adapter sends to
+	must.True(first.JoinRoom("/", c1.id, c1))
+	must.True(last.JoinRoom("/", c2.id, c2))
+	must.True(last.JoinRoom("/", c3.id, c3))
+
+	must.True(first.JoinRoom("/", "room", c1))
+	must.True(last.JoinRoom("/", "room", c2))
+	must.True(last.JoinRoom("/", "other", c3))
+
+	sockets, err := first.FetchSocketsContext(context.Background(), "/", "room", nil)
+	must.NoError(err)
+	ids := make([]string, 0, len(sockets))
+	for _, socket := range sockets {
+		ids = append(ids, socket.ID)
+	}
+	sort.Strings(ids)
+	should.Equal([]string{"c1", "c2"}, ids)
+
+	sockets = first.FetchSockets("/", "", DataFilter{"team": "red"})
+	sort.Slice(sockets, func(i, j int) bool {
+		return sockets[i].ID < sockets[j].ID
+	})
+	must.Len(sockets, 2)
+	should.Equal("c1", sockets[0].ID)
+	should.ElementsMatch([]string{"c1", "room"}, sockets[0].Rooms)
+	should.Equal("c3", sockets[1].ID)
+	should.ElementsMatch([]string{"c3", "other"}, sockets[1].Rooms)
+
+	level, ok := DataValue[int](sockets[1].Data, "level")
+	should.True(ok)
+	should.Equal(2, level)
+
+	must.True(first.BroadcastWhere("/", "", DataFilter{"team": "red"}, "event", "payload"))
+	for _, c := range []*busTestConn{c1, c3} {
+		select {
+		case got := <-c.events:
+			should.Equal([]interface{}{"event", "payload"}, got)
+		case <-time.After(time.Second):
+			t.Fatalf("%s did not receive the broadcast", c.id)
+		}
+	}
+
+	select {
+	case got := <-c2.events:
+		t.Fatalf("unexpected event %v", got)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestServerSocketData(t *testing.T) {
+	srv := NewServer(nil)
+	srv.OnConnect("/", func(Conn, map[string]interface{}) error {
+		return nil
+	})
+
+	testSocketData(t, []*Server{srv})
+
+	_, err := srv.FetchSocketsContext(context.Background(), "/unknown", "", nil)
+	assert.Error(t, err)
+	assert.False(t, srv.BroadcastWhere("/unknown", "", nil, "event"))
+}
+
+func TestBusAdapterSocketData(t *testing.T) {
+	testSocketData(t, newBusTestServers(t, 2, BusAdapterConfig{Bus: NewBus()}))
+}
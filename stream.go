@@ -0,0 +1,356 @@
+package socketio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/vchitai/go-socket.io/v4/parser"
+)
+
+// stream events, the data of a stream being sent as binary chunks acked by
+// the receiver once read, with at most streamWindow chunks not acked yet.
+const (
+	streamEventPrefix = "$stream:"
+
+	streamOpenEvent   = streamEventPrefix + "open"   // id, event
+	streamDataEvent   = streamEventPrefix + "data"   // id, chunk, with an ack
+	streamEndEvent    = streamEventPrefix + "end"    // id
+	streamCancelEvent = streamEventPrefix + "cancel" // id, reason
+
+	streamChunkSize = 64 << 10
+	streamWindow    = 8
+)
+
+var (
+	streamOpenTypes   = []reflect.Type{reflect.TypeOf(""), reflect.TypeOf("")}
+	streamDataTypes   = []reflect.Type{reflect.TypeOf(""), reflect.TypeOf([]byte(nil))}
+	streamIDTypes     = []reflect.Type{reflect.TypeOf("")}
+	streamCancelTypes = []reflect.Type{reflect.TypeOf(""), reflect.TypeOf("")}
+
+	streamReaderType = reflect.TypeOf((*inStream)(nil))
+)
+
+func isStreamEvent(event string) bool {
+	return strings.HasPrefix(event, streamEventPrefix)
+}
+
+// outStream is a stream sent by SendStream
+type outStream struct {
+	window   chan struct{}
+	canceled chan struct{}
+	once     sync.Once
+	err      error
+}
+
+func newOutStream() *outStream {
+	return &outStream{
+		window:   make(chan struct{}, streamWindow),
+		canceled: make(chan struct{}),
+	}
+}
+
+// acquire waits for a chunk to fit in the window
+func (s *outStream) acquire(ctx context.Context, quit <-chan struct{}) error {
+	select {
+	case s.window <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-s.canceled:
+		return s.err
+	case <-quit:
+		return errStreamConnClosed
+	}
+}
+
+// release frees the window of an acked chunk
+func (s *outStream) release() {
+	select {
+	case <-s.window:
+	default:
+	}
+}
+
+func (s *outStream) cancel(reason string) {
+	s.stop(fmt.Errorf("%w: %s", ErrStreamCanceled, reason))
+}
+
+// stop stops the stream with err, returned by the pending SendStream
+func (s *outStream) stop(err error) {
+	s.once.Do(func() {
+		s.err = err
+		close(s.canceled)
+	})
+}
+
+// inStream is a stream received by a handler, as an io.ReadCloser
+type inStream struct {
+	nc *namespaceConn
+	id string
+
+	chunks  chan streamChunk
+	current streamChunk
+	eof     bool
+
+	done chan struct{}
+	once sync.Once
+	err  error
+}
+
+type streamChunk struct {
+	header parser.Header
+	data   []byte
+}
+
+func newInStream(nc *namespaceConn, id string) *inStream {
+	return &inStream{
+		nc: nc,
+		id: id,
+		// twice the window, so that a peer ignoring it is detected
+		chunks: make(chan streamChunk, 2*streamWindow),
+		done:   make(chan struct{}),
+	}
+}
+
+// Read reads the data of the stream, acking each chunk once read so that
+// the sender can send more.
+func (s *inStream) Read(p []byte) (int, error) {
+	for len(s.current.data) == 0 {
+		if s.eof {
+			return 0, io.EOF
+		}
+
+		select {
+		case chunk, ok := <-s.chunks:
+			if !ok {
+				s.eof = true
+				return 0, io.EOF
+			}
+			s.current = chunk
+			if len(chunk.data) == 0 {
+				s.ack()
+			}
+		case <-s.done:
+			return 0, s.err
+		}
+	}
+
+	n := copy(p, s.current.data)
+	s.current.data = s.current.data[n:]
+	if len(s.current.data) == 0 {
+		s.ack()
+	}
+	return n, nil
+}
+
+// Close closes the stream, canceling it when the sender did not end it.
+func (s *inStream) Close() error {
+	if !s.abort(errStreamClosed) {
+		return nil
+	}
+
+	s.nc.inStreams.Delete(s.id)
+	if !s.eof {
+		s.nc.Emit(streamCancelEvent, s.id, "closed by the receiver")
+	}
+	return nil
+}
+
+func (s *inStream) ack() {
+	header := s.current.header
+	header.Type = parser.Ack
+	s.nc.conn.write(header)
+}
+
+// push queues a chunk read from the connection, it fails when the sender
+// does not respect the window
+func (s *inStream) push(chunk streamChunk) bool {
+	select {
+	case s.chunks <- chunk:
+		return true
+	default:
+		return false
+	}
+}
+
+// abort stops the reads with err, it reports whether the stream was not
+// stopped already
+func (s *inStream) abort(err error) bool {
+	aborted := false
+	s.once.Do(func() {
+		s.err = err
+		close(s.done)
+		aborted = true
+	})
+	return aborted
+}
+
+// SendStream sends the data of r to the handler of event on the other side,
+// as chunks of binary attachments. At most a window of chunks is sent before
+// the other side reads them, and other events are sent between the chunks.
+// It returns once every chunk was read, or the stream was canceled.
+//
+// Only the peers using this package read the streams: the others never ack
+// the chunks, and SendStream blocks once the window is full, until the
+// connection closes. SendStreamContext bounds it with a context.
+func (nc *namespaceConn) SendStream(event string, r io.Reader) error {
+	return nc.SendStreamContext(context.Background(), event, r)
+}
+
+// SendStreamContext is SendStream canceling the stream when ctx is done.
+func (nc *namespaceConn) SendStreamContext(ctx context.Context, event string, r io.Reader) error {
+	id := newV4UUID()
+	s := newOutStream()
+	nc.outStreams.Store(id, s)
+	defer nc.outStreams.Delete(id)
+
+	nc.Emit(streamOpenEvent, id, event)
+
+	buf := make([]byte, streamChunkSize)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			if err := s.acquire(ctx, nc.quitChan); err != nil {
+				nc.cancelOutStream(id, err)
+				return err
+			}
+
+			chunk := append([]byte(nil), buf[:n]...)
+			nc.Emit(streamDataEvent, id, chunk, s.release)
+		}
+
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			nc.cancelOutStream(id, err)
+			return err
+		}
+	}
+
+	// wait for every chunk to be read before ending the stream
+	for i := 0; i < streamWindow; i++ {
+		if err := s.acquire(ctx, nc.quitChan); err != nil {
+			nc.cancelOutStream(id, err)
+			return err
+		}
+	}
+
+	nc.Emit(streamEndEvent, id)
+	return nil
+}
+
+// cancelOutStream tells the other side the stream stopped with err, unless
+// the other side canceled it or the connection is closed
+func (nc *namespaceConn) cancelOutStream(id string, err error) {
+	if errors.Is(err, ErrStreamCanceled) || errors.Is(err, errStreamConnClosed) {
+		return
+	}
+	nc.Emit(streamCancelEvent, id, err.Error())
+}
+
+// closeStreams stops the streams being sent or received when the connection
+// closes
+func (nc *namespaceConn) closeStreams() {
+	nc.inStreams.Range(func(id, s interface{}) bool {
+		s.(*inStream).abort(errStreamConnClosed)
+		nc.inStreams.Delete(id)
+		return true
+	})
+	nc.outStreams.Range(func(id, s interface{}) bool {
+		s.(*outStream).stop(errStreamConnClosed)
+		return true
+	})
+}
+
+// streamPacketHandler handles the events of the streams, sent or received
+func (c *conn) streamPacketHandler(nc *namespaceConn, handler *Handler, event string, header parser.Header) error {
+	var types []reflect.Type
+	switch event {
+	case streamOpenEvent:
+		types = streamOpenTypes
+	case streamDataEvent:
+		types = streamDataTypes
+	case streamCancelEvent:
+		types = streamCancelTypes
+	default:
+		types = streamIDTypes
+	}
+
+	args, err := c.decoder.DecodeArgs(types)
+	if err != nil {
+		c.onError(header.Namespace, err)
+
+		var argsErr *parser.ArgsError
+		if !errors.As(err, &argsErr) {
+			return errDecodeArgs
+		}
+		return nil
+	}
+	if len(args) != len(types) {
+		return nil
+	}
+	id := args[0].String()
+
+	switch event {
+	case streamOpenEvent:
+		c.openStream(nc, handler, id, args[1].String())
+
+	case streamDataEvent:
+		v, ok := nc.inStreams.Load(id)
+		if !ok {
+			return nil
+		}
+		s := v.(*inStream)
+		if !s.push(streamChunk{header: header, data: args[1].Bytes()}) {
+			nc.inStreams.Delete(id)
+			s.abort(errStreamWindow)
+			nc.Emit(streamCancelEvent, id, errStreamWindow.Error())
+		}
+
+	case streamEndEvent:
+		if v, ok := nc.inStreams.LoadAndDelete(id); ok {
+			close(v.(*inStream).chunks)
+		}
+
+	case streamCancelEvent:
+		reason := args[1].String()
+		if v, ok := nc.outStreams.Load(id); ok {
+			v.(*outStream).cancel(reason)
+		}
+		if v, ok := nc.inStreams.LoadAndDelete(id); ok {
+			v.(*inStream).abort(fmt.Errorf("%w: %s", ErrStreamCanceled, reason))
+		}
+	}
+
+	return nil
+}
+
+// openStream calls the handler of the event with the stream in its own
+// goroutine, so that the connection keeps reading its chunks. The stream is
+// closed when the handler returns.
+func (c *conn) openStream(nc *namespaceConn, handler *Handler, id string, event string) {
+	types := handler.getEventTypes(event)
+	if len(types) != 1 || !streamReaderType.AssignableTo(types[0]) {
+		nc.Emit(streamCancelEvent, id, fmt.Sprintf("no stream handler for event %q", event))
+		return
+	}
+
+	s := newInStream(nc, id)
+	nc.inStreams.Store(id, s)
+
+	go func() {
+		defer func() {
+			_ = s.Close()
+		}()
+
+		if _, err := handler.dispatchEvent(nc, event, reflect.ValueOf(s)); err != nil {
+			c.onError(nc.namespace, err)
+		}
+	}()
+}
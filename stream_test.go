@@ -0,0 +1,253 @@
+package socketio
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vchitai/go-socket.io/v4/engineio/session"
+)
+
+type pipeFrame struct {
+	ft   session.FrameType
+	data []byte
+}
+
+// pipeEngineConn is an engine connection whose frames are read by its peer
+type pipeEngineConn struct {
+	id    string
+	in    chan pipeFrame
+	peer  *pipeEngineConn
+	done  chan struct{}
+	close func()
+}
+
+func newPipeEngineConns() (*pipeEngineConn, *pipeEngineConn) {
+	done := make(chan struct{})
+	closeOnce := func() func() {
+		closed := false
+		return func() {
+			if !closed {
+				closed = true
+				close(done)
+			}
+		}
+	}()
+
+	a := &pipeEngineConn{id: "a", in: make(chan pipeFrame, 64), done: done, close: closeOnce}
+	b := &pipeEngineConn{id: "b", in: make(chan pipeFrame, 64), done: done, close: closeOnce}
+	a.peer, b.peer = b, a
+	return a, b
+}
+
+func (c *pipeEngineConn) ID() string {
+	return c.id
+}
+
+func (c *pipeEngineConn) NextReader() (session.FrameType, io.ReadCloser, error) {
+	select {
+	case f := <-c.in:
+		return f.ft, io.NopCloser(bytes.NewReader(f.data)), nil
+	case <-c.done:
+		return 0, nil, io.EOF
+	}
+}
+
+func (c *pipeEngineConn) NextWriter(ft session.FrameType) (io.WriteCloser, error) {
+	return &pipeFrameWriter{conn: c, ft: ft}, nil
+}
+
+func (c *pipeEngineConn) Close() error {
+	c.close()
+	return nil
+}
+
+func (c *pipeEngineConn) URL() url.URL              { return url.URL{} }
+func (c *pipeEngineConn) LocalAddr() net.Addr       { return nil }
+func (c *pipeEngineConn) RemoteAddr() net.Addr      { return nil }
+func (c *pipeEngineConn) RemoteHeader() http.Header { return nil }
+func (c *pipeEngineConn) SetContext(interface{})    {}
+func (c *pipeEngineConn) Context() interface{}      { return nil }
+func (c *pipeEngineConn) Done() <-chan struct{}     { return c.done }
+
+type pipeFrameWriter struct {
+	conn *pipeEngineConn
+	ft   session.FrameType
+	buf  bytes.Buffer
+}
+
+func (w *pipeFrameWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *pipeFrameWriter) Close() error {
+	select {
+	case w.conn.peer.in <- pipeFrame{ft: w.ft, data: w.buf.Bytes()}:
+		return nil
+	case <-w.conn.done:
+		return io.ErrClosedPipe
+	}
+}
+
+// newStreamTestConns connects two connections on the root namespace, the
+// second one using handlers
+func newStreamTestConns(t *testing.T, handlers *Handler) (*namespaceConn, *namespaceConn) {
+	a, b := newPipeEngineConns()
+
+	sides := make([]*namespaceConn, 2)
+	for i, engineConn := range []*pipeEngineConn{a, b} {
+		h := handlers
+		if i == 0 {
+			h = newHandler(newBroadcast())
+		}
+		hs := NewHandlers()
+		hs.Set(rootNamespace, h)
+
+		c := NewConn(engineConn, hs)
+		nc := newNamespaceConn(c, rootNamespace, h.broadcast)
+		c.namespaceConns.Set(rootNamespace, nc)
+		sides[i] = nc
+
+		go c.Serve()
+	}
+
+	t.Cleanup(func() {
+		_ = sides[0].Close()
+		_ = sides[1].Close()
+	})
+	return sides[0], sides[1]
+}
+
+func TestSendStream(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	data := make([]byte, 10*streamChunkSize+123)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	received := make(chan []byte, 1)
+	events := make(chan string, 1)
+
+	h := newHandler(newBroadcast())
+	h.OnEvent("upload", func(_ Conn, r io.ReadCloser) {
+		first := make([]byte, streamChunkSize)
+		if _, err := io.ReadFull(r, first); err != nil {
+			return
+		}
+
+		// the other events are received while the stream waits to be read
+		select {
+		case <-events:
+		case <-time.After(time.Second):
+			return
+		}
+
+		rest, _ := io.ReadAll(r)
+		received <- append(first, rest...)
+	})
+	h.OnEvent("msg", func(_ Conn, msg string) {
+		events <- msg
+	})
+
+	sender, _ := newStreamTestConns(t, h)
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- sender.SendStream("upload", bytes.NewReader(data))
+	}()
+	sender.Emit("msg", "hello")
+
+	select {
+	case err := <-errs:
+		must.NoError(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream not sent")
+	}
+
+	select {
+	case got := <-received:
+		should.Equal(data, got)
+	case <-time.After(time.Second):
+		t.Fatal("stream not received")
+	}
+}
+
+func TestSendStreamCancel(t *testing.T) {
+	should := assert.New(t)
+
+	h := newHandler(newBroadcast())
+	h.OnEvent("upload", func(_ Conn, r io.ReadCloser) {
+		// read a single chunk then close the stream
+		_, _ = r.Read(make([]byte, 1))
+		_ = r.Close()
+	})
+
+	sender, _ := newStreamTestConns(t, h)
+
+	// the receiver cancels the stream by closing it
+	err := sender.SendStream("upload", bytes.NewReader(make([]byte, 100*streamChunkSize)))
+	should.True(errors.Is(err, ErrStreamCanceled), err)
+
+	// the sender cancels the stream with its context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = sender.SendStreamContext(ctx, "upload", bytes.NewReader(make([]byte, 100*streamChunkSize)))
+	should.ErrorIs(err, context.Canceled)
+
+	// no handler of the event takes a stream
+	err = sender.SendStream("unknown", bytes.NewReader(make([]byte, 100*streamChunkSize)))
+	should.ErrorIs(err, ErrStreamCanceled)
+}
+
+func TestSendStreamNamespaceClosed(t *testing.T) {
+	should := assert.New(t)
+
+	opened := make(chan struct{})
+	h := newHandler(newBroadcast())
+	h.OnEvent("upload", func(_ Conn, r io.ReadCloser) {
+		// never read, so that the sender waits for its window
+		close(opened)
+		<-r.(*inStream).done
+	})
+
+	sender, _ := newStreamTestConns(t, h)
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- sender.SendStream("upload", bytes.NewReader(make([]byte, 100*streamChunkSize)))
+	}()
+
+	select {
+	case <-opened:
+	case <-time.After(time.Second):
+		t.Fatal("stream not opened")
+	}
+
+	// the sent streams stop when the namespace is disconnected, while the
+	// connection stays open
+	sender.closeStreams()
+	select {
+	case err := <-errs:
+		should.ErrorIs(err, errStreamConnClosed)
+	case <-time.After(time.Second):
+		t.Fatal("stream not stopped")
+	}
+}
+
+func TestStreamEventsReserved(t *testing.T) {
+	h := newHandler(newBroadcast())
+	assert.Panics(t, func() {
+		h.OnEvent(streamOpenEvent, func(Conn, string, string) {})
+	})
+}
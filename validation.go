@@ -0,0 +1,292 @@
+package socketio
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/vchitai/go-socket.io/v4/parser"
+)
+
+// Validator is implemented by the arguments of event handlers checking their
+// own values once decoded. The error returned by Validate is sent to the
+// client as the message of a ValidationError, unless it is one already.
+type Validator interface {
+	Validate() error
+}
+
+// ValidationError is reported to OnError when the arguments of an event are
+// invalid. The event is not dispatched, and the error is sent to the client as
+// the ack of the event when it requested one, encoded as {error, details}.
+type ValidationError struct {
+	Event   string       `json:"-"`
+	Message string       `json:"error"`
+	Details []FieldError `json:"details"`
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Details) == 0 {
+		return fmt.Sprintf("event %q: %s", e.Event, e.Message)
+	}
+
+	details := make([]string, len(e.Details))
+	for i, detail := range e.Details {
+		details[i] = detail.String()
+	}
+	return fmt.Sprintf("event %q: %s: %s", e.Event, e.Message, strings.Join(details, "; "))
+}
+
+// FieldError describes an invalid argument, or field of an argument. Field is
+// the path to the value, starting with the index of the argument, like
+// "0.user.name".
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) String() string {
+	return e.Field + ": " + e.Message
+}
+
+const (
+	validateTag           = "validate"
+	invalidArgumentsError = "invalid arguments"
+)
+
+// newDecodeValidationError describes args which could not be decoded, the
+// field starting with the index of the argument like the tag failures
+func newDecodeValidationError(event string, err *parser.ArgsError) *ValidationError {
+	detail := FieldError{Message: err.Err.Error()}
+	if err.Index >= 0 {
+		detail.Field = strconv.Itoa(err.Index)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &typeErr):
+		if typeErr.Field != "" {
+			detail.Field += "." + typeErr.Field
+		}
+		detail.Rule = "type"
+		detail.Message = fmt.Sprintf("expected %s, got %s", typeErr.Type, typeErr.Value)
+	case errors.As(err, &syntaxErr):
+		detail.Rule = "syntax"
+	}
+
+	return &ValidationError{
+		Event:   event,
+		Message: invalidArgumentsError,
+		Details: []FieldError{detail},
+	}
+}
+
+// validateArgs checks the validate tags of the args, then calls their
+// Validate method. It returns nil when the args are valid.
+func validateArgs(event string, args []reflect.Value) *ValidationError {
+	var details []FieldError
+	for i, arg := range args {
+		details = appendFieldErrors(details, strconv.Itoa(i), arg)
+	}
+
+	if len(details) == 0 {
+		for i, arg := range args {
+			details = appendValidatorError(details, strconv.Itoa(i), arg)
+		}
+	}
+
+	if len(details) == 0 {
+		return nil
+	}
+	return &ValidationError{
+		Event:   event,
+		Message: invalidArgumentsError,
+		Details: details,
+	}
+}
+
+func appendValidatorError(details []FieldError, field string, v reflect.Value) []FieldError {
+	if !v.IsValid() || !v.CanInterface() {
+		return details
+	}
+
+	validator, ok := v.Interface().(Validator)
+	if !ok && v.CanAddr() {
+		validator, ok = v.Addr().Interface().(Validator)
+	}
+	if !ok || (v.Kind() == reflect.Ptr && v.IsNil()) {
+		return details
+	}
+
+	err := validator.Validate()
+	if err == nil {
+		return details
+	}
+
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		for _, detail := range validationErr.Details {
+			if detail.Field == "" {
+				detail.Field = field
+			} else {
+				detail.Field = field + "." + detail.Field
+			}
+			details = append(details, detail)
+		}
+		return details
+	}
+
+	return append(details, FieldError{
+		Field:   field,
+		Rule:    "custom",
+		Message: err.Error(),
+	})
+}
+
+// appendFieldErrors checks the validate tags of the fields of v, recursively
+func appendFieldErrors(details []FieldError, path string, v reflect.Value) []FieldError {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return details
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			fieldPath := path + "." + jsonFieldName(field)
+			fieldValue := v.Field(i)
+			if tag, ok := field.Tag.Lookup(validateTag); ok {
+				if detail, ok := checkRules(fieldPath, tag, fieldValue); !ok {
+					details = append(details, detail)
+					continue
+				}
+			}
+			details = appendFieldErrors(details, fieldPath, fieldValue)
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			details = appendFieldErrors(details, path+"."+strconv.Itoa(i), v.Index(i))
+		}
+
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			details = appendFieldErrors(details, fmt.Sprintf("%s.%v", path, iter.Key()), iter.Value())
+		}
+	}
+
+	return details
+}
+
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
+
+// checkRules checks v against the comma separated rules of a validate tag:
+// required, min=n, max=n and oneof=a b c. min and max bound the length of
+// strings, slices and maps, and the value of numbers.
+func checkRules(field string, tag string, v reflect.Value) (FieldError, bool) {
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		if name == "" {
+			continue
+		}
+
+		if msg, ok := checkRule(name, param, v); !ok {
+			return FieldError{Field: field, Rule: name, Message: msg}, false
+		}
+	}
+	return FieldError{}, true
+}
+
+func checkRule(name string, param string, v reflect.Value) (string, bool) {
+	switch name {
+	case "required":
+		if v.IsZero() {
+			return "is required", false
+		}
+		return "", true
+
+	case "min", "max":
+		bound, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return fmt.Sprintf("invalid %s rule %q", name, param), false
+		}
+
+		size, isLen, ok := measure(v)
+		if !ok {
+			return "", true
+		}
+		if name == "min" && size < bound {
+			if isLen {
+				return fmt.Sprintf("length must be at least %s", param), false
+			}
+			return fmt.Sprintf("must be at least %s", param), false
+		}
+		if name == "max" && size > bound {
+			if isLen {
+				return fmt.Sprintf("length must be at most %s", param), false
+			}
+			return fmt.Sprintf("must be at most %s", param), false
+		}
+		return "", true
+
+	case "oneof":
+		value := fmt.Sprint(indirect(v).Interface())
+		for _, allowed := range strings.Fields(param) {
+			if value == allowed {
+				return "", true
+			}
+		}
+		return fmt.Sprintf("must be one of %s", strings.Join(strings.Fields(param), ", ")), false
+
+	default:
+		return fmt.Sprintf("unknown rule %q", name), false
+	}
+}
+
+// measure gives the length of strings, slices and maps, or the value of
+// numbers, the rules bounding
+func measure(v reflect.Value) (size float64, isLen bool, ok bool) {
+	v = indirect(v)
+
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		if v.Kind() == reflect.String {
+			return float64(len([]rune(v.String()))), true, true
+		}
+		return float64(v.Len()), true, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), false, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), false, true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), false, true
+	default:
+		return 0, false, false
+	}
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v
+}
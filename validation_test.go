@@ -0,0 +1,165 @@
+package socketio
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vchitai/go-socket.io/v4/parser"
+)
+
+type chatMessage struct {
+	Room string    `json:"room" validate:"required"`
+	Text string    `json:"text" validate:"min=1,max=5"`
+	Kind string    `json:"kind" validate:"oneof=text image"`
+	Tags []string  `json:"tags" validate:"max=2"`
+	From *chatUser `json:"from"`
+}
+
+type chatUser struct {
+	Name string `json:"name" validate:"required"`
+	Age  int    `json:"age" validate:"min=13"`
+}
+
+type evenNumber int
+
+func (n evenNumber) Validate() error {
+	if n%2 != 0 {
+		return errors.New("must be even")
+	}
+	return nil
+}
+
+func TestValidateArgs(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	valid := chatMessage{Room: "go", Text: "hi", Kind: "text", From: &chatUser{Name: "alice", Age: 30}}
+	should.Nil(validateArgs("chat", []reflect.Value{reflect.ValueOf(valid)}))
+
+	invalid := chatMessage{Text: "too long", Kind: "video", Tags: []string{"a", "b", "c"}, From: &chatUser{Age: 7}}
+	err := validateArgs("chat", []reflect.Value{reflect.ValueOf(&invalid)})
+	must.NotNil(err)
+	should.Equal("chat", err.Event)
+	should.Equal([]FieldError{
+		{Field: "0.room", Rule: "required", Message: "is required"},
+		{Field: "0.text", Rule: "max", Message: "length must be at most 5"},
+		{Field: "0.kind", Rule: "oneof", Message: "must be one of text, image"},
+		{Field: "0.tags", Rule: "max", Message: "length must be at most 2"},
+		{Field: "0.from.name", Rule: "required", Message: "is required"},
+		{Field: "0.from.age", Rule: "min", Message: "must be at least 13"},
+	}, err.Details)
+
+	should.Nil(validateArgs("number", []reflect.Value{reflect.ValueOf("x"), reflect.ValueOf(evenNumber(2))}))
+	err = validateArgs("number", []reflect.Value{reflect.ValueOf("x"), reflect.ValueOf(evenNumber(3))})
+	must.NotNil(err)
+	should.Equal([]FieldError{{Field: "1", Rule: "custom", Message: "must be even"}}, err.Details)
+}
+
+func TestDecodeValidationError(t *testing.T) {
+	should := assert.New(t)
+
+	var n int
+	jsonErr := json.Unmarshal([]byte(`"x"`), &n)
+	err := newDecodeValidationError("count", &parser.ArgsError{Index: 1, Err: jsonErr})
+	should.Equal("count", err.Event)
+	should.Equal("invalid arguments", err.Message)
+	should.Len(err.Details, 1)
+	should.Equal("type", err.Details[0].Rule)
+
+	// the error is sent to the client as {error, details}
+	b, jsonErr := json.Marshal(err)
+	should.NoError(jsonErr)
+	should.JSONEq(`{"error":"invalid arguments","details":[{"field":"1","rule":"type","message":"expected int, got string"}]}`, string(b))
+
+	// the fields of an argument follow its index
+	var user chatUser
+	jsonErr = json.Unmarshal([]byte(`{"name":1}`), &user)
+	err = newDecodeValidationError("join", &parser.ArgsError{Index: 1, Err: jsonErr})
+	should.Equal("1.name", err.Details[0].Field)
+
+	// the arguments which are not valid JSON have no field
+	jsonErr = json.Unmarshal([]byte(`[`), &n)
+	err = newDecodeValidationError("count", &parser.ArgsError{Index: -1, Err: jsonErr})
+	should.Equal(FieldError{Rule: "syntax", Message: jsonErr.Error()}, err.Details[0])
+}
+
+func TestRejectEvent(t *testing.T) {
+	should := assert.New(t)
+	must := require.New(t)
+
+	errs := make(chan error, 1)
+	h := newHandler(newBroadcast())
+	h.OnError(func(_ Conn, err error) {
+		errs <- err
+	})
+	h.OnEvent("chat", func(_ Conn, msg chatMessage) string {
+		return "got " + msg.Text
+	})
+
+	sender, _ := newStreamTestConns(t, h)
+
+	nextError := func() *ValidationError {
+		var validationErr *ValidationError
+		select {
+		case err := <-errs:
+			must.ErrorAs(err, &validationErr)
+		case <-time.After(time.Second):
+			t.Fatal("error not reported")
+		}
+		return validationErr
+	}
+	acks := make(chan map[string]interface{}, 1)
+	nextAck := func() map[string]interface{} {
+		select {
+		case ack := <-acks:
+			return ack
+		case <-time.After(time.Second):
+			t.Fatal("ack not received")
+		}
+		return nil
+	}
+
+	// an argument which cannot be decoded, without ack
+	sender.Emit("chat", 42)
+	err := nextError()
+	should.Equal("chat", err.Event)
+	should.Equal([]FieldError{{Field: "0", Rule: "type", Message: "expected socketio.chatMessage, got number"}}, err.Details)
+
+	// with an ack, the client receives the error
+	sender.Emit("chat", map[string]interface{}{"room": "go", "text": 1}, func(ack map[string]interface{}) {
+		acks <- ack
+	})
+	should.Equal("0.text", nextError().Details[0].Field)
+	should.Equal(map[string]interface{}{
+		"error": "invalid arguments",
+		"details": []interface{}{map[string]interface{}{
+			"field": "0.text", "rule": "type", "message": "expected string, got number",
+		}},
+	}, nextAck())
+
+	// an argument failing its validate tags, with an ack
+	sender.Emit("chat", chatMessage{Room: "go", Text: "too long", Kind: "text"}, func(ack map[string]interface{}) {
+		acks <- ack
+	})
+	should.Equal([]FieldError{{Field: "0.text", Rule: "max", Message: "length must be at most 5"}}, nextError().Details)
+	should.Equal("invalid arguments", nextAck()["error"])
+
+	// the connection still dispatches the valid events
+	replies := make(chan string, 1)
+	sender.Emit("chat", chatMessage{Room: "go", Text: "hi", Kind: "text"}, func(reply string) {
+		replies <- reply
+	})
+	select {
+	case reply := <-replies:
+		should.Equal("got hi", reply)
+	case <-time.After(time.Second):
+		t.Fatal("event not dispatched")
+	}
+	should.Empty(errs)
+}